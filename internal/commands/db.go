@@ -0,0 +1,309 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/gnomegl/teleslurp/internal/config"
+	"github.com/gnomegl/teleslurp/internal/database"
+	"github.com/spf13/cobra"
+)
+
+var (
+	pruneDryRun bool
+	pruneKeep   map[string]string
+)
+
+func init() {
+	dbCmd := &cobra.Command{
+		Use:   "db",
+		Short: "Manage the teleslurp database",
+		Long:  `Manage the database used by the monitor, filters and search runs`,
+	}
+
+	// Migrate subcommand
+	migrateCmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Apply pending schema migrations",
+		RunE:  runDBMigrate,
+	}
+
+	// Status subcommand
+	statusCmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show the schema version and pending migrations",
+		RunE:  runDBStatus,
+	}
+
+	// Prune subcommand
+	pruneCmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Delete rows older than the configured retention",
+		Long: `Delete rows older than the retention configured per table in config.json, e.g.
+  "retention": {"user_status_updates": "30d", "messages": "1y"}
+Use --keep to override a table's retention for this run.
+
+Tables: ` + strings.Join(database.RetentionTables(), ", ") + `
+Media is out of scope: forwarded photos are re-uploaded from memory, so
+teleslurp stores no media files or media references to expire.`,
+		RunE: runDBPrune,
+	}
+
+	pruneCmd.Flags().BoolVar(&pruneDryRun, "dry-run", false, "Only count the rows that would be deleted")
+	pruneCmd.Flags().StringToStringVar(&pruneKeep, "keep", nil, "Retention override as table=period, e.g. --keep messages=90d")
+
+	// Vacuum subcommand
+	vacuumCmd := &cobra.Command{
+		Use:   "vacuum",
+		Short: "Reclaim the space left by deleted rows",
+		RunE:  runDBVacuum,
+	}
+
+	// Stats subcommand
+	statsCmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Show row counts and sizes per table",
+		RunE:  runDBStats,
+	}
+
+	// Backup subcommand
+	backupCmd := &cobra.Command{
+		Use:   "backup [file]",
+		Short: "Back up the SQLite database, safe while the monitor is running",
+		Args:  cobra.MaximumNArgs(1),
+		RunE:  runDBBackup,
+	}
+
+	dbCmd.AddCommand(migrateCmd, statusCmd, pruneCmd, vacuumCmd, statsCmd, backupCmd)
+	rootCmd.AddCommand(dbCmd)
+}
+
+// openDatabaseNoMigrate opens the database without applying migrations
+func openDatabaseNoMigrate() (*database.DB, error) {
+	db, err := database.Open(config.GetDatabaseDSN())
+	if err != nil {
+		return nil, fmt.Errorf("error opening database: %w", err)
+	}
+	return db, nil
+}
+
+func runDBMigrate(cmd *cobra.Command, args []string) error {
+	db, err := openDatabaseNoMigrate()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	applied, err := db.Migrate()
+	for _, m := range applied {
+		fmt.Printf("✓ Applied %04d_%s\n", m.Version, m.Name)
+	}
+	if err != nil {
+		return fmt.Errorf("error migrating database: %w", err)
+	}
+
+	if len(applied) == 0 {
+		fmt.Println("Database is up to date")
+	}
+
+	version, err := db.SchemaVersion()
+	if err != nil {
+		return fmt.Errorf("error reading schema version: %w", err)
+	}
+	fmt.Printf("Schema version: %d\n", version)
+	return nil
+}
+
+func runDBStatus(cmd *cobra.Command, args []string) error {
+	db, err := openDatabaseNoMigrate()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	applied, err := db.AppliedMigrations()
+	if err != nil {
+		return fmt.Errorf("error reading applied migrations: %w", err)
+	}
+
+	pending, err := db.PendingMigrations()
+	if err != nil {
+		return fmt.Errorf("error reading pending migrations: %w", err)
+	}
+
+	fmt.Printf("Database: %s (%s)\n", database.RedactDSN(config.GetDatabaseDSN()), db.Dialect())
+	fmt.Println("Applied Migrations:")
+	fmt.Println("===================")
+	if len(applied) == 0 {
+		fmt.Println("  (none)")
+	}
+	for _, m := range applied {
+		fmt.Printf("  %04d_%s (applied %s)\n", m.Version, m.Name, m.AppliedAt)
+	}
+
+	fmt.Println("\nPending Migrations:")
+	fmt.Println("===================")
+	if len(pending) == 0 {
+		fmt.Println("  (none)")
+	}
+	for _, m := range pending {
+		fmt.Printf("  %04d_%s\n", m.Version, m.Name)
+	}
+
+	return nil
+}
+
+// loadRetentionPolicy reads the retention policy from the config file and
+// applies overrides given on the command line
+func loadRetentionPolicy(overrides map[string]string) (map[string]time.Duration, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, fmt.Errorf("error loading config: %w", err)
+	}
+	if cfg == nil {
+		cfg = &config.Config{}
+	}
+
+	policy, err := cfg.RetentionPolicy()
+	if err != nil {
+		return nil, err
+	}
+	for table, value := range overrides {
+		d, err := config.ParseRetention(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid retention for %s: %w", table, err)
+		}
+		policy[table] = d
+	}
+	return policy, nil
+}
+
+func runDBPrune(cmd *cobra.Command, args []string) error {
+	policy, err := loadRetentionPolicy(pruneKeep)
+	if err != nil {
+		return err
+	}
+	if len(policy) == 0 {
+		fmt.Printf("No retention configured. Set \"retention\" in %s or use --keep (tables: %s)\n",
+			config.GetConfigPath(), strings.Join(database.RetentionTables(), ", "))
+		return nil
+	}
+
+	// Initialize database
+	db, err := database.New(config.GetDatabaseDSN())
+	if err != nil {
+		return fmt.Errorf("error initializing database: %w", err)
+	}
+	defer db.Close()
+
+	results, err := db.Prune(policy, pruneDryRun)
+	printPruneResults(results, pruneDryRun)
+	return err
+}
+
+func printPruneResults(results []database.PruneResult, dryRun bool) {
+	verb := "Deleted"
+	if dryRun {
+		verb = "Would delete"
+	}
+	for _, r := range results {
+		fmt.Printf("%s %d rows from %s\n", verb, r.Deleted, r.Table)
+	}
+}
+
+func runDBVacuum(cmd *cobra.Command, args []string) error {
+	// Initialize database
+	db, err := database.New(config.GetDatabaseDSN())
+	if err != nil {
+		return fmt.Errorf("error initializing database: %w", err)
+	}
+	defer db.Close()
+
+	before, err := db.Stats()
+	if err != nil {
+		return err
+	}
+	if err := db.Vacuum(); err != nil {
+		return fmt.Errorf("error vacuuming database: %w", err)
+	}
+	after, err := db.Stats()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Database size: %s -> %s\n", formatBytes(before.Size), formatBytes(after.Size))
+	return nil
+}
+
+func runDBStats(cmd *cobra.Command, args []string) error {
+	// Initialize database
+	db, err := database.New(config.GetDatabaseDSN())
+	if err != nil {
+		return fmt.Errorf("error initializing database: %w", err)
+	}
+	defer db.Close()
+
+	stats, err := db.Stats()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Database: %s (%s)\n", database.RedactDSN(config.GetDatabaseDSN()), db.Dialect())
+	fmt.Println("Tables:")
+	fmt.Println("=======")
+	for _, t := range stats.Tables {
+		size := "n/a"
+		if t.Size >= 0 {
+			size = formatBytes(t.Size)
+		}
+		fmt.Printf("%-24s %10d rows  %10s\n", t.Name, t.Rows, size)
+	}
+	fmt.Printf("\nTotal size: %s\n", formatBytes(stats.Size))
+	return nil
+}
+
+func runDBBackup(cmd *cobra.Command, args []string) error {
+	dest := fmt.Sprintf("teleslurp-backup-%s.db", time.Now().Format("20060102-150405"))
+	if len(args) > 0 {
+		dest = args[0]
+	}
+	if _, err := os.Stat(dest); err == nil {
+		return fmt.Errorf("backup file %s already exists", dest)
+	}
+
+	db, err := openDatabaseNoMigrate()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := db.Backup(ctx, dest); err != nil {
+		os.Remove(dest)
+		return fmt.Errorf("error backing up database: %w", err)
+	}
+
+	fmt.Printf("Database backed up to: %s\n", dest)
+	return nil
+}
+
+// formatBytes renders a byte count with a binary unit
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,149 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gnomegl/teleslurp/internal/config"
+	"github.com/gnomegl/teleslurp/internal/database"
+	"github.com/gnomegl/teleslurp/internal/diff"
+	"github.com/spf13/cobra"
+)
+
+var (
+	diffFormat string
+	diffOutput string
+)
+
+func init() {
+	diffCmd := &cobra.Command{
+		Use:   "diff <run-a> [run-b]",
+		Short: "Show what changed between two search runs",
+		Long: `Compare two recorded search runs for the same user and report new and left
+TGScan groups, new and deleted messages, username changes and admin status changes.
+Runs are matched by user ID, or by username when a run has no ID; runs for
+different users are refused.
+
+With a single run ID the run is compared against the previous completed run
+for the same user. Use 'teleslurp runs' to find run IDs.`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: runDiff,
+	}
+
+	diffCmd.Flags().StringVarP(&diffFormat, "format", "f", diff.FormatText, "Output format: text, json or markdown")
+	diffCmd.Flags().StringVarP(&diffOutput, "output", "o", "", "Write the report to a file instead of stdout")
+
+	rootCmd.AddCommand(diffCmd)
+}
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	var runIDs []int64
+	for _, arg := range args {
+		id, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid run ID: %s", arg)
+		}
+		runIDs = append(runIDs, id)
+	}
+
+	// Initialize database
+	db, err := database.New(config.GetDatabaseDSN())
+	if err != nil {
+		return fmt.Errorf("error initializing database: %w", err)
+	}
+	defer db.Close()
+
+	if len(runIDs) == 1 {
+		prev, err := db.GetPreviousSearchRun(runIDs[0])
+		if err != nil {
+			return fmt.Errorf("error finding previous run: %w", err)
+		}
+		if prev == nil {
+			return fmt.Errorf("no earlier completed run found for the target of run %d", runIDs[0])
+		}
+		runIDs = []int64{prev.ID, runIDs[0]}
+	}
+
+	report, err := diffRuns(db, runIDs[0], runIDs[1])
+	if err != nil {
+		return err
+	}
+
+	out := os.Stdout
+	if diffOutput != "" {
+		file, err := os.Create(diffOutput)
+		if err != nil {
+			return fmt.Errorf("error creating output file: %w", err)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	if err := diff.Write(out, report, diffFormat); err != nil {
+		return err
+	}
+
+	if diffOutput != "" {
+		fmt.Printf("Diff exported to: %s\n", diffOutput)
+	}
+	return nil
+}
+
+// diffRuns loads two runs and compares them
+func diffRuns(db *database.DB, runA, runB int64) (*diff.Report, error) {
+	a, err := diff.LoadSnapshot(db, runA)
+	if err != nil {
+		return nil, fmt.Errorf("error loading run %d: %w", runA, err)
+	}
+	b, err := diff.LoadSnapshot(db, runB)
+	if err != nil {
+		return nil, fmt.Errorf("error loading run %d: %w", runB, err)
+	}
+	if !sameTarget(a.Run, b.Run) {
+		return nil, fmt.Errorf("run %d is for %s but run %d is for %s; only runs for the same user can be compared",
+			runA, runTarget(a.Run), runB, runTarget(b.Run))
+	}
+	return diff.Compare(a, b), nil
+}
+
+// sameTarget reports whether two runs searched the same user, by user ID or,
+// when a run has no ID, by username
+func sameTarget(a, b database.SearchRun) bool {
+	if a.UserID != 0 && b.UserID != 0 {
+		return a.UserID == b.UserID
+	}
+	return a.Username != "" && strings.EqualFold(a.Username, b.Username)
+}
+
+// runTarget describes the user a run searched
+func runTarget(r database.SearchRun) string {
+	switch {
+	case r.Username != "" && r.UserID != 0:
+		return fmt.Sprintf("@%s (%d)", r.Username, r.UserID)
+	case r.Username != "":
+		return "@" + r.Username
+	case r.UserID != 0:
+		return strconv.FormatInt(r.UserID, 10)
+	}
+	return fmt.Sprintf("%q", r.Query)
+}
+
+// printChangesSincePreviousRun shows what changed since the last search of
+// the same user, if there was one
+func printChangesSincePreviousRun(db *database.DB, runID int64) {
+	prev, err := db.GetPreviousSearchRun(runID)
+	if err != nil || prev == nil {
+		return
+	}
+
+	report, err := diffRuns(db, prev.ID, runID)
+	if err != nil {
+		fmt.Printf("Warning: Failed to compare with previous run: %v\n", err)
+		return
+	}
+
+	fmt.Println()
+	diff.WriteText(os.Stdout, report)
+}
@@ -0,0 +1,369 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gnomegl/teleslurp/internal/config"
+	"github.com/gnomegl/teleslurp/internal/database"
+	"github.com/gnomegl/teleslurp/internal/export"
+	"github.com/gnomegl/teleslurp/internal/indicators"
+	"github.com/gnomegl/teleslurp/internal/timeutil"
+	"github.com/gnomegl/teleslurp/internal/types"
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportDBFormat    string
+	exportDBData      string
+	exportDBChannel   string
+	exportDBUser      string
+	exportDBSince     string
+	exportDBUntil     string
+	exportDBKeywords  []string
+	exportDBMetadata  bool
+	exportDBOutputDir string
+)
+
+// Values of export db --data
+const (
+	exportDataMessages = "messages"
+	exportDataStatuses = "statuses"
+	exportDataAll      = "all"
+)
+
+func init() {
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export stored data",
+		Long:  `Export data stored by the monitor in the formats searches use`,
+	}
+
+	// DB subcommand
+	dbExportCmd := &cobra.Command{
+		Use:   "db",
+		Short: "Export stored messages and status updates",
+		Long: `Export the messages and user status updates stored by the monitor, without
+writing SQL. Messages go through the same exporters as 'teleslurp search', one
+channel at a time, so every --format is available. Status updates can be
+exported as json, ndjson or csv.
+
+Examples:
+  teleslurp export db --channel somechannel --since 2024-01-01 -f html
+  teleslurp export db --user johndoe --keyword wallet --keyword btc -f xlsx
+  teleslurp export db --data statuses --user johndoe --since 30d -f csv`,
+		Args: cobra.NoArgs,
+		RunE: runExportDB,
+	}
+
+	dbExportCmd.Flags().StringVarP(&exportDBFormat, "format", "f", "json", "Export format:\n"+export.Usage())
+	dbExportCmd.Flags().StringVar(&exportDBData, "data", exportDataMessages, "What to export: messages, statuses or all")
+	dbExportCmd.Flags().StringVar(&exportDBChannel, "channel", "", "Only export messages from this channel ID or username")
+	dbExportCmd.Flags().StringVar(&exportDBUser, "user", "", "Only export messages sent by and status updates of this user ID or username")
+	dbExportCmd.Flags().StringVar(&exportDBSince, "since", "", "Only export data since this date (YYYY-MM-DD) or period (e.g. 30d, 2w)")
+	dbExportCmd.Flags().StringVar(&exportDBUntil, "until", "", "Only export data on or before this date (YYYY-MM-DD)")
+	dbExportCmd.Flags().StringArrayVar(&exportDBKeywords, "keyword", nil, "Only export messages containing this keyword, ignoring case (repeat to match any)")
+	dbExportCmd.Flags().BoolVar(&exportDBMetadata, "metadata", false, "Also export channel metadata in formats that write it separately")
+	dbExportCmd.Flags().StringVar(&exportDBOutputDir, "output-dir", "", "Write the export to a new folder in this directory, with a manifest.json of file hashes")
+
+	exportCmd.AddCommand(dbExportCmd)
+	rootCmd.AddCommand(exportCmd)
+}
+
+func runExportDB(cmd *cobra.Command, args []string) (err error) {
+	data := strings.ToLower(exportDBData)
+	if data != exportDataMessages && data != exportDataStatuses && data != exportDataAll {
+		return fmt.Errorf("invalid --data %q, expected messages, statuses or all", exportDBData)
+	}
+	format := strings.ToLower(exportDBFormat)
+
+	var exporter export.Exporter
+	if data != exportDataStatuses {
+		if exporter, err = export.Get(format); err != nil {
+			return err
+		}
+	}
+	if data != exportDataMessages && format != "json" && format != "ndjson" && format != "csv" {
+		return fmt.Errorf("status updates can only be exported as json, ndjson or csv")
+	}
+
+	q := database.ExportQuery{
+		Channel:  exportDBChannel,
+		Keywords: exportDBKeywords,
+	}
+	if q.Since, err = parseSince(exportDBSince); err != nil {
+		return err
+	}
+	if exportDBUntil != "" {
+		until, err := timeutil.ParseDate(exportDBUntil)
+		if err != nil {
+			return err
+		}
+		q.Until = timeutil.Format(until.AddDate(0, 0, 1))
+	}
+
+	// Initialize database
+	db, err := database.New(config.GetDatabaseDSN())
+	if err != nil {
+		return fmt.Errorf("error initializing database: %w", err)
+	}
+	defer db.Close()
+
+	var target types.User
+	if exportDBUser != "" {
+		if target, err = findExportUser(db, exportDBUser); err != nil {
+			return err
+		}
+		q.UserID = target.ID
+	}
+
+	name := "db"
+	if exportDBUser != "" {
+		name = export.TargetBasename(target)
+	}
+
+	var runDir string
+	if exportDBOutputDir != "" {
+		startedAt := time.Now()
+		runDir, err = export.NewRunDir(exportDBOutputDir, name, startedAt)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("📁 Output folder: %s\n", runDir)
+		defer func() {
+			manifest := &export.Manifest{
+				Tool:       "teleslurp",
+				Version:    version(),
+				Query:      "export db",
+				Parameters: exportDBParameters(),
+				StartedAt:  startedAt,
+				FinishedAt: time.Now(),
+			}
+			if err != nil {
+				manifest.Error = err.Error()
+			}
+			filename, merr := export.WriteManifest(runDir, manifest)
+			if merr != nil {
+				fmt.Printf("Warning: Failed to write manifest: %v\n", merr)
+				return
+			}
+			fmt.Printf("✓ Manifest written to: %s\n", filename)
+		}()
+	}
+	basename := filepath.Join(runDir, export.SafeName(name))
+
+	if data != exportDataStatuses {
+		search := &export.Search{Target: target, IncludeMetadata: exportDBMetadata}
+		if err := exportStoredMessages(db, q, exporter, search, basename); err != nil {
+			return err
+		}
+	}
+	if data != exportDataMessages {
+		if err := exportStoredStatuses(db, q, format, basename); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findExportUser resolves --user to the user's ID and, if they are
+// monitored, their name
+func findExportUser(db *database.DB, user string) (types.User, error) {
+	target := types.User{Username: strings.TrimPrefix(user, "@")}
+	if id, err := strconv.ParseInt(user, 10, 64); err == nil {
+		target = types.User{ID: id}
+	} else {
+		if target.ID, err = db.FindStatusUserID(user); err != nil {
+			return types.User{}, fmt.Errorf("error looking up user: %w", err)
+		}
+		if target.ID == 0 {
+			return types.User{}, fmt.Errorf("unknown user %s: not monitored and no status updates recorded", user)
+		}
+	}
+
+	monitored, err := db.GetMonitoredUsers()
+	if err != nil {
+		return types.User{}, fmt.Errorf("error getting monitored users: %w", err)
+	}
+	for _, m := range monitored {
+		if m.UserID == target.ID {
+			target = types.User{ID: m.UserID, Username: m.Username, FirstName: m.FirstName, LastName: m.LastName}
+		}
+	}
+	return target, nil
+}
+
+// exportStoredMessages passes the matching messages to the exporter one
+// channel at a time
+func exportStoredMessages(db *database.DB, q database.ExportQuery, exporter export.Exporter, search *export.Search, basename string) error {
+	var writer export.Writer
+	var channel types.ChannelMetadata
+	var messages []types.MessageData
+	channels, total := 0, 0
+
+	flush := func() error {
+		if len(messages) == 0 {
+			return nil
+		}
+		if writer == nil {
+			var err error
+			if writer, err = exporter.NewWriter(search, basename); err != nil {
+				return err
+			}
+		}
+		channels++
+		total += len(messages)
+		err := writer.WriteChannel(channel, messages)
+		messages = nil
+		return err
+	}
+
+	err := db.ExportMessages(q, func(m database.StoredMessage) error {
+		if len(messages) > 0 && m.ChannelID != channel.ChannelID {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+		if len(messages) == 0 {
+			channel = types.ChannelMetadata{
+				ChannelID:       m.ChannelID,
+				ChannelTitle:    m.ChannelTitle,
+				ChannelUsername: m.ChannelUsername,
+				MemberCount:     m.MemberCount,
+			}
+			if m.ChannelUsername != "" {
+				channel.ChannelLink = "https://t.me/" + m.ChannelUsername
+			}
+			if q.UserID != 0 {
+				channel.UserFirstMessage = m.Date
+			}
+		}
+		messages = append(messages, types.MessageData{
+			ChannelTitle:    m.ChannelTitle,
+			ChannelUsername: m.ChannelUsername,
+			MessageID:       m.MessageID,
+			Date:            m.Date,
+			DateUnix:        timeutil.Unix(m.Date),
+			Message:         m.Message,
+			URL:             m.URL,
+			Indicators:      indicators.Extract(m.Message),
+		})
+		return nil
+	})
+	if err == nil {
+		err = flush()
+	}
+
+	if writer == nil {
+		if err != nil {
+			return fmt.Errorf("error exporting messages: %w", err)
+		}
+		fmt.Println("No stored messages match the filters")
+		return nil
+	}
+
+	files, cerr := writer.Close()
+	if err != nil {
+		return fmt.Errorf("error exporting messages: %w", err)
+	}
+	if cerr != nil {
+		return fmt.Errorf("error exporting messages: %w", cerr)
+	}
+	fmt.Printf("✓ Exported %d messages from %d channels to:\n", total, channels)
+	for _, file := range files {
+		fmt.Printf("  %s\n", file)
+	}
+	return nil
+}
+
+// exportStoredStatuses writes the matching status updates as JSON, NDJSON
+// or CSV
+func exportStoredStatuses(db *database.DB, q database.ExportQuery, format, basename string) error {
+	file, err := export.CreateFile(basename, "statuses", format)
+	if err != nil {
+		return err
+	}
+	filename := file.Name()
+	count := 0
+
+	switch format {
+	case "json":
+		w := export.NewJSONArrayWriter(file)
+		err = db.ExportStatusUpdates(q, func(u database.UserStatusUpdate) error {
+			count++
+			return w.Write(u)
+		})
+		if cerr := w.Close(); err == nil {
+			err = cerr
+		}
+	case "ndjson":
+		w := export.NewNDJSONWriter(file)
+		err = db.ExportStatusUpdates(q, func(u database.UserStatusUpdate) error {
+			count++
+			return w.Write(u)
+		})
+		if cerr := w.Close(); err == nil {
+			err = cerr
+		}
+	case "csv":
+		w := export.NewCSVWriter(file)
+		err = w.WriteHeader([]string{"ID", "User ID", "Username", "First Name", "Last Name", "State", "Status", "Was Online", "Expires", "Status Time"})
+		if err == nil {
+			err = db.ExportStatusUpdates(q, func(u database.UserStatusUpdate) error {
+				count++
+				return w.WriteRecord([]string{
+					strconv.FormatInt(u.ID, 10),
+					strconv.FormatInt(u.UserID, 10),
+					u.Username,
+					u.FirstName,
+					u.LastName,
+					u.State,
+					u.Status,
+					u.WasOnline,
+					u.Expires,
+					u.StatusTime,
+				})
+			})
+		}
+		if cerr := w.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("error exporting status updates: %w", err)
+	}
+
+	fmt.Printf("✓ Exported %d status updates to: %s\n", count, filename)
+	return nil
+}
+
+// createOutput creates the file given with -o, or a new file for one kind of
+// data about basename when -o is empty, see export.CreateFile
+func createOutput(output, basename, dataType, format string) (*os.File, error) {
+	if output == "" {
+		return export.CreateFile(basename, dataType, format)
+	}
+	file, err := os.Create(output)
+	if err != nil {
+		return nil, fmt.Errorf("error creating output file: %w", err)
+	}
+	return file, nil
+}
+
+// exportDBParameters returns the flags of an export, recorded in its manifest
+func exportDBParameters() map[string]interface{} {
+	return map[string]interface{}{
+		"format":   exportDBFormat,
+		"data":     exportDBData,
+		"channel":  exportDBChannel,
+		"user":     exportDBUser,
+		"since":    exportDBSince,
+		"until":    exportDBUntil,
+		"keywords": exportDBKeywords,
+		"metadata": exportDBMetadata,
+	}
+}
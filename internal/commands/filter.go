@@ -2,6 +2,7 @@ package commands
 
 import (
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -12,12 +13,19 @@ import (
 )
 
 var (
-	filterName     string
-	filterType     string
-	filterPattern  string
-	filterAction   string
-	filterPriority int
-	filterID       int
+	filterName        string
+	filterType        string
+	filterPattern     string
+	filterAction      string
+	filterPriority    int
+	filterID          int
+	filterNormalize   bool
+	filterWholeWord   bool
+	filterMaxDistance int
+	filterKeywordFile string
+	auditChannelID    int64
+	auditMessageID    int
+	auditLimit        int
 )
 
 func init() {
@@ -39,6 +47,18 @@ Filter types:
 - user: Filter messages from specific user IDs
 - channel: Filter messages from specific channel IDs
 - length: Filter messages based on minimum length
+- indicator: Filter messages containing indicators of the given comma-separated
+  types (url, telegram_link, mention, email, phone, btc, eth, ltc, trx, xmr),
+  or "crypto" for any crypto address and "any" for any indicator
+- keyword_list: Match a large watchlist of terms in a single pass. The pattern
+  is either file:<path> (one term per line) or table (terms stored in the
+  database, managed with 'teleslurp filter keywords')
+
+Keyword matching options:
+- --normalize: Fold homoglyphs, Cyrillic/Greek look-alikes, leetspeak, diacritics
+  and zero-width characters before matching (also applies to keyword_list)
+- --word: Only match keywords on word boundaries
+- --max-distance: Tolerate up to N character edits per keyword
 
 Actions:
 - forward: Forward the message (default)
@@ -48,10 +68,14 @@ Actions:
 	}
 
 	addFilterCmd.Flags().StringVarP(&filterName, "name", "n", "", "Filter name (required)")
-	addFilterCmd.Flags().StringVarP(&filterType, "type", "t", "", "Filter type: keyword, regex, user, channel, length (required)")
+	addFilterCmd.Flags().StringVarP(&filterType, "type", "t", "", "Filter type: keyword, keyword_list, indicator, regex, user, channel, length (required)")
 	addFilterCmd.Flags().StringVarP(&filterPattern, "pattern", "p", "", "Filter pattern (required)")
 	addFilterCmd.Flags().StringVarP(&filterAction, "action", "a", "forward", "Action: forward, ignore, highlight")
 	addFilterCmd.Flags().IntVarP(&filterPriority, "priority", "P", 0, "Filter priority (higher = evaluated first)")
+	addFilterCmd.Flags().BoolVar(&filterNormalize, "normalize", false, "Keyword filters: match on normalized text")
+	addFilterCmd.Flags().BoolVar(&filterWholeWord, "word", false, "Keyword filters: only match whole words")
+	addFilterCmd.Flags().IntVar(&filterMaxDistance, "max-distance", 0, "Keyword filters: maximum edit distance per keyword")
+	addFilterCmd.Flags().StringVar(&filterKeywordFile, "keywords-file", "", "Keyword list filters: import terms from this file into the table")
 	addFilterCmd.MarkFlagRequired("name")
 	addFilterCmd.MarkFlagRequired("type")
 	addFilterCmd.MarkFlagRequired("pattern")
@@ -79,14 +103,71 @@ Actions:
 		RunE:  runDisableFilter,
 	}
 
-	filterCmd.AddCommand(addFilterCmd, listFiltersCmd, enableFilterCmd, disableFilterCmd)
+	// Filter stats subcommand
+	statsFilterCmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Show how often each filter matched",
+		Long: `Show match counts and last match time for every filter, including disabled ones.
+Filters that never match are candidates for removal.`,
+		RunE: runFilterStats,
+	}
+
+	// Filter audit subcommand
+	auditFilterCmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Show which filter decided each evaluated message",
+		Long: `Show the audit log of filter decisions, newest first.
+Use --channel and --message to explain why a given message was or wasn't forwarded.`,
+		RunE: runFilterAudit,
+	}
+
+	auditFilterCmd.Flags().Int64Var(&auditChannelID, "channel", 0, "Only show messages from this channel ID")
+	auditFilterCmd.Flags().IntVar(&auditMessageID, "message", 0, "Only show this message ID")
+	auditFilterCmd.Flags().IntVar(&auditLimit, "limit", 50, "Maximum number of audit rows to show")
+
+	// Keyword list management
+	keywordsCmd := &cobra.Command{
+		Use:   "keywords",
+		Short: "Manage the terms of table-backed keyword list filters",
+	}
+
+	addKeywordsCmd := &cobra.Command{
+		Use:   "add [filter-id] [term...]",
+		Short: "Add terms to a keyword list filter",
+		Args:  cobra.MinimumNArgs(2),
+		RunE:  runAddKeywords,
+	}
+
+	importKeywordsCmd := &cobra.Command{
+		Use:   "import [filter-id] [file]",
+		Short: "Import terms from a file (one per line) into a keyword list filter",
+		Args:  cobra.ExactArgs(2),
+		RunE:  runImportKeywords,
+	}
+
+	removeKeywordsCmd := &cobra.Command{
+		Use:   "remove [filter-id] [term...]",
+		Short: "Remove terms from a keyword list filter",
+		Args:  cobra.MinimumNArgs(2),
+		RunE:  runRemoveKeywords,
+	}
+
+	listKeywordsCmd := &cobra.Command{
+		Use:   "list [filter-id]",
+		Short: "List the terms of a keyword list filter",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runListKeywords,
+	}
+
+	keywordsCmd.AddCommand(addKeywordsCmd, importKeywordsCmd, removeKeywordsCmd, listKeywordsCmd)
+
+	filterCmd.AddCommand(addFilterCmd, listFiltersCmd, enableFilterCmd, disableFilterCmd, statsFilterCmd, auditFilterCmd, keywordsCmd)
 	rootCmd.AddCommand(filterCmd)
 }
 
 func runAddFilter(cmd *cobra.Command, args []string) error {
 	// Initialize database
-	dbPath := config.GetDatabasePath()
-	db, err := database.New(dbPath)
+	db, err := database.New(config.GetDatabaseDSN())
 	if err != nil {
 		return fmt.Errorf("error initializing database: %w", err)
 	}
@@ -94,11 +175,13 @@ func runAddFilter(cmd *cobra.Command, args []string) error {
 
 	// Validate filter type
 	validTypes := map[string]bool{
-		"keyword": true,
-		"regex":   true,
-		"user":    true,
-		"channel": true,
-		"length":  true,
+		"keyword":      true,
+		"keyword_list": true,
+		"indicator":    true,
+		"regex":        true,
+		"user":         true,
+		"channel":      true,
+		"length":       true,
 	}
 	if !validTypes[filterType] {
 		return fmt.Errorf("invalid filter type: %s", filterType)
@@ -118,7 +201,26 @@ func runAddFilter(cmd *cobra.Command, args []string) error {
 	switch filterType {
 	case "keyword":
 		keywords := strings.Split(filterPattern, ",")
-		err = filter.AddKeywordFilter(db, filterName, keywords, filterAction, filterPriority)
+		opts := filter.KeywordOptions{
+			Normalize:   filterNormalize,
+			WholeWord:   filterWholeWord,
+			MaxDistance: filterMaxDistance,
+		}
+		err = filter.AddKeywordFilter(db, filterName, keywords, opts, filterAction, filterPriority)
+	case "keyword_list":
+		var terms []string
+		if filterKeywordFile != "" {
+			if filterPattern != "table" {
+				return fmt.Errorf("--keywords-file requires --pattern table")
+			}
+			terms, err = filter.ReadKeywordFile(filterKeywordFile)
+			if err != nil {
+				return err
+			}
+		}
+		_, err = filter.AddKeywordListFilter(db, filterName, filterPattern, terms, filterNormalize, filterAction, filterPriority)
+	case "indicator":
+		err = filter.AddIndicatorFilter(db, filterName, strings.Split(filterPattern, ","), filterAction, filterPriority)
 	case "regex":
 		err = filter.AddRegexFilter(db, filterName, filterPattern, filterAction, filterPriority)
 	case "user":
@@ -145,8 +247,7 @@ func runAddFilter(cmd *cobra.Command, args []string) error {
 
 func runListFilters(cmd *cobra.Command, args []string) error {
 	// Initialize database
-	dbPath := config.GetDatabasePath()
-	db, err := database.New(dbPath)
+	db, err := database.New(config.GetDatabaseDSN())
 	if err != nil {
 		return fmt.Errorf("error initializing database: %w", err)
 	}
@@ -167,8 +268,8 @@ func runListFilters(cmd *cobra.Command, args []string) error {
 	fmt.Println("========================")
 	for _, f := range filters {
 		status := "enabled"
-		fmt.Printf("ID: %d | Name: %s | Type: %s | Pattern: %s | Action: %s | Priority: %d | Status: %s\n",
-			f.ID, f.Name, f.Type, f.Pattern, f.Action, f.Priority, status)
+		fmt.Printf("ID: %d | Name: %s | Type: %s | Pattern: %s | Action: %s | Priority: %d | Status: %s%s\n",
+			f.ID, f.Name, f.Type, f.Pattern, f.Action, f.Priority, status, formatFilterOptions(f.Options))
 	}
 
 	return nil
@@ -181,8 +282,7 @@ func runEnableFilter(cmd *cobra.Command, args []string) error {
 	}
 
 	// Initialize database
-	dbPath := config.GetDatabasePath()
-	db, err := database.New(dbPath)
+	db, err := database.New(config.GetDatabaseDSN())
 	if err != nil {
 		return fmt.Errorf("error initializing database: %w", err)
 	}
@@ -203,8 +303,7 @@ func runDisableFilter(cmd *cobra.Command, args []string) error {
 	}
 
 	// Initialize database
-	dbPath := config.GetDatabasePath()
-	db, err := database.New(dbPath)
+	db, err := database.New(config.GetDatabaseDSN())
 	if err != nil {
 		return fmt.Errorf("error initializing database: %w", err)
 	}
@@ -218,6 +317,181 @@ func runDisableFilter(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+func runFilterStats(cmd *cobra.Command, args []string) error {
+	// Initialize database
+	db, err := database.New(config.GetDatabaseDSN())
+	if err != nil {
+		return fmt.Errorf("error initializing database: %w", err)
+	}
+	defer db.Close()
+
+	stats, err := db.GetFilterStats()
+	if err != nil {
+		return fmt.Errorf("error getting filter stats: %w", err)
+	}
+
+	if len(stats) == 0 {
+		fmt.Println("No filters configured")
+		return nil
+	}
+
+	fmt.Println("Filter Statistics:")
+	fmt.Println("==================")
+	for _, st := range stats {
+		status := "enabled"
+		if !st.Enabled {
+			status = "disabled"
+		}
+		lastMatch := st.LastMatchAt
+		if lastMatch == "" {
+			lastMatch = "never"
+		}
+		fmt.Printf("ID: %d | Name: %s | Type: %s | Action: %s | Status: %s | Matches: %d | Last match: %s\n",
+			st.ID, st.Name, st.Type, st.Action, status, st.MatchCount, lastMatch)
+	}
+
+	return nil
+}
+
+func runFilterAudit(cmd *cobra.Command, args []string) error {
+	// Initialize database
+	db, err := database.New(config.GetDatabaseDSN())
+	if err != nil {
+		return fmt.Errorf("error initializing database: %w", err)
+	}
+	defer db.Close()
+
+	entries, err := db.GetFilterAudit(auditChannelID, auditMessageID, auditLimit)
+	if err != nil {
+		return fmt.Errorf("error getting filter audit: %w", err)
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("No audit entries found")
+		return nil
+	}
+
+	fmt.Println("Filter Audit Log:")
+	fmt.Println("=================")
+	for _, e := range entries {
+		decidedBy := "default (no filter matched)"
+		if e.FilterID != 0 {
+			decidedBy = fmt.Sprintf("%s (ID: %d)", e.FilterName, e.FilterID)
+		}
+		fmt.Printf("%s | Channel: %d | Message: %d | User: %d | Action: %s | Decided by: %s\n",
+			e.CreatedAt, e.ChannelID, e.MessageID, e.UserID, e.Action, decidedBy)
+	}
+
+	return nil
+}
+
+func runAddKeywords(cmd *cobra.Command, args []string) error {
+	filterID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid filter ID: %s", args[0])
+	}
+	return addKeywords(filterID, args[1:])
+}
+
+func runImportKeywords(cmd *cobra.Command, args []string) error {
+	filterID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid filter ID: %s", args[0])
+	}
+
+	terms, err := filter.ReadKeywordFile(args[1])
+	if err != nil {
+		return err
+	}
+	return addKeywords(filterID, terms)
+}
+
+func addKeywords(filterID int64, terms []string) error {
+	// Initialize database
+	db, err := database.New(config.GetDatabaseDSN())
+	if err != nil {
+		return fmt.Errorf("error initializing database: %w", err)
+	}
+	defer db.Close()
+
+	added, err := db.AddFilterKeywords(filterID, terms)
+	if err != nil {
+		return fmt.Errorf("error adding keywords: %w", err)
+	}
+
+	fmt.Printf("Added %d terms to filter %d (%d duplicates skipped)\n", added, filterID, len(terms)-added)
+	return nil
+}
+
+func runRemoveKeywords(cmd *cobra.Command, args []string) error {
+	filterID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid filter ID: %s", args[0])
+	}
+
+	// Initialize database
+	db, err := database.New(config.GetDatabaseDSN())
+	if err != nil {
+		return fmt.Errorf("error initializing database: %w", err)
+	}
+	defer db.Close()
+
+	if err := db.RemoveFilterKeywords(filterID, args[1:]); err != nil {
+		return fmt.Errorf("error removing keywords: %w", err)
+	}
+
+	fmt.Printf("Removed %d terms from filter %d\n", len(args)-1, filterID)
+	return nil
+}
+
+func runListKeywords(cmd *cobra.Command, args []string) error {
+	filterID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid filter ID: %s", args[0])
+	}
+
+	// Initialize database
+	db, err := database.New(config.GetDatabaseDSN())
+	if err != nil {
+		return fmt.Errorf("error initializing database: %w", err)
+	}
+	defer db.Close()
+
+	terms, err := db.GetFilterKeywords(filterID)
+	if err != nil {
+		return fmt.Errorf("error getting keywords: %w", err)
+	}
+
+	if len(terms) == 0 {
+		fmt.Printf("Filter %d has no stored terms\n", filterID)
+		return nil
+	}
+
+	fmt.Printf("Terms for filter %d (%d):\n", filterID, len(terms))
+	for _, term := range terms {
+		fmt.Printf("  • %s\n", term)
+	}
+	return nil
+}
+
+func formatFilterOptions(options map[string]string) string {
+	if len(options) == 0 {
+		return ""
+	}
+
+	keys := make([]string, 0, len(options))
+	for k := range options {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = fmt.Sprintf("%s=%s", k, options[k])
+	}
+	return " | Options: " + strings.Join(parts, ", ")
+}
+
 func parseInt64List(pattern string) []int64 {
 	parts := strings.Split(pattern, ",")
 	var ids []int64
@@ -5,11 +5,12 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
-	"path/filepath"
 	"syscall"
+	"time"
 
 	"github.com/gnomegl/teleslurp/internal/config"
 	"github.com/gnomegl/teleslurp/internal/database"
+	"github.com/gnomegl/teleslurp/internal/filter"
 	"github.com/gnomegl/teleslurp/internal/telegram"
 	"github.com/spf13/cobra"
 )
@@ -18,12 +19,16 @@ var (
 	configFile string
 )
 
+// filterPollInterval is how often the monitor checks the filter table for changes
+const filterPollInterval = 5 * time.Second
+
 func init() {
 	var (
-		apiKey   string
-		apiID    int
-		apiHash  string
-		noPrompt bool
+		apiKey        string
+		apiID         int
+		apiHash       string
+		noPrompt      bool
+		pruneInterval time.Duration
 	)
 
 	monitorCmd := &cobra.Command{
@@ -32,7 +37,7 @@ func init() {
 		Long: `Monitor specified Telegram chats and forward messages to target channels.
 Example: teleslurp monitor --config=monitor.config.yaml`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runMonitor(cmd, args, apiKey, apiID, apiHash, noPrompt)
+			return runMonitor(cmd, args, apiKey, apiID, apiHash, noPrompt, pruneInterval)
 		},
 	}
 
@@ -41,119 +46,98 @@ Example: teleslurp monitor --config=monitor.config.yaml`,
 	monitorCmd.Flags().IntVar(&apiID, "api-id", 0, "Telegram API ID")
 	monitorCmd.Flags().StringVar(&apiHash, "api-hash", "", "Telegram API Hash")
 	monitorCmd.Flags().BoolVar(&noPrompt, "no-prompt", false, "Disable interactive prompts")
+	monitorCmd.Flags().DurationVar(&pruneInterval, "prune-interval", 0, "Prune the database by the configured retention this often, e.g. 1h (disabled by default)")
 
 	rootCmd.AddCommand(monitorCmd)
 }
 
-// resolveSources resolves usernames to IDs for channels and groups
-func resolveSources(ctx context.Context, client *telegram.Client, channels, groups []config.MonitorSource) ([]int64, error) {
+// resolveSources resolves usernames to IDs for channels and groups. It must be
+// called from a running client session.
+func resolveSources(ctx context.Context, client *telegram.Client, channels, groups []config.MonitorSource) []int64 {
 	var ids []int64
 
-	// Create a temporary context wrapper to run the client for resolution
-	err := client.RunWithContext(ctx, func(ctx context.Context) error {
-		// Resolve channels
-		for _, ch := range channels {
-			if ch.ID != 0 {
-				ids = append(ids, ch.ID)
-				fmt.Printf("Added channel ID: %d\n", ch.ID)
-			} else if ch.Username != "" {
-				channelID, _, title, err := client.ResolveChannelUsername(ctx, ch.Username)
-				if err != nil {
-					fmt.Printf("Warning: Could not resolve channel %s: %v\n", ch.Username, err)
-					continue
-				}
-				ids = append(ids, channelID)
-				fmt.Printf("Resolved channel @%s (%s) to ID: %d\n", ch.Username, title, channelID)
+	// Resolve channels
+	for _, ch := range channels {
+		if ch.ID != 0 {
+			ids = append(ids, ch.ID)
+			fmt.Printf("Added channel ID: %d\n", ch.ID)
+		} else if ch.Username != "" {
+			channelID, _, title, err := client.ResolveChannelUsername(ctx, ch.Username)
+			if err != nil {
+				fmt.Printf("Warning: Could not resolve channel %s: %v\n", ch.Username, err)
+				continue
 			}
+			ids = append(ids, channelID)
+			fmt.Printf("Resolved channel @%s (%s) to ID: %d\n", ch.Username, title, channelID)
 		}
+	}
 
-		// Resolve groups
-		for _, grp := range groups {
-			if grp.ID != 0 {
-				ids = append(ids, grp.ID)
-				fmt.Printf("Added group ID: %d\n", grp.ID)
-			} else if grp.Username != "" {
-				groupID, _, title, err := client.ResolveChannelUsername(ctx, grp.Username)
-				if err != nil {
-					fmt.Printf("Warning: Could not resolve group %s: %v\n", grp.Username, err)
-					continue
-				}
-				ids = append(ids, groupID)
-				fmt.Printf("Resolved group @%s (%s) to ID: %d\n", grp.Username, title, groupID)
+	// Resolve groups
+	for _, grp := range groups {
+		if grp.ID != 0 {
+			ids = append(ids, grp.ID)
+			fmt.Printf("Added group ID: %d\n", grp.ID)
+		} else if grp.Username != "" {
+			groupID, _, title, err := client.ResolveChannelUsername(ctx, grp.Username)
+			if err != nil {
+				fmt.Printf("Warning: Could not resolve group %s: %v\n", grp.Username, err)
+				continue
 			}
+			ids = append(ids, groupID)
+			fmt.Printf("Resolved group @%s (%s) to ID: %d\n", grp.Username, title, groupID)
 		}
-
-		return nil
-	})
-
-	if err != nil {
-		return nil, fmt.Errorf("error resolving sources: %w", err)
 	}
 
-	return ids, nil
+	return ids
 }
 
-// resolveTargets resolves usernames to IDs for target channels
-func resolveTargets(ctx context.Context, client *telegram.Client, targets []config.MonitorTarget) ([]int64, error) {
+// resolveTargets resolves usernames to IDs for target channels. It must be
+// called from a running client session.
+func resolveTargets(ctx context.Context, client *telegram.Client, targets []config.MonitorTarget) []int64 {
 	var ids []int64
 
-	// Create a temporary context wrapper to run the client for resolution
-	err := client.RunWithContext(ctx, func(ctx context.Context) error {
-		for _, target := range targets {
-			if target.ID != 0 {
-				ids = append(ids, target.ID)
-				fmt.Printf("Added target channel ID: %d\n", target.ID)
-			} else if target.Username != "" {
-				channelID, _, title, err := client.ResolveChannelUsername(ctx, target.Username)
-				if err != nil {
-					fmt.Printf("Warning: Could not resolve target channel %s: %v\n", target.Username, err)
-					continue
-				}
-				ids = append(ids, channelID)
-				fmt.Printf("Resolved target channel @%s (%s) to ID: %d\n", target.Username, title, channelID)
+	for _, target := range targets {
+		if target.ID != 0 {
+			ids = append(ids, target.ID)
+			fmt.Printf("Added target channel ID: %d\n", target.ID)
+		} else if target.Username != "" {
+			channelID, _, title, err := client.ResolveChannelUsername(ctx, target.Username)
+			if err != nil {
+				fmt.Printf("Warning: Could not resolve target channel %s: %v\n", target.Username, err)
+				continue
 			}
+			ids = append(ids, channelID)
+			fmt.Printf("Resolved target channel @%s (%s) to ID: %d\n", target.Username, title, channelID)
 		}
-		return nil
-	})
-
-	if err != nil {
-		return nil, fmt.Errorf("error resolving targets: %w", err)
 	}
 
-	return ids, nil
+	return ids
 }
 
-// resolveUsers resolves usernames to IDs for user monitoring
-func resolveUsers(ctx context.Context, client *telegram.Client, users []config.MonitorSource) ([]int64, error) {
+// resolveUsers resolves usernames to IDs for user monitoring. It must be
+// called from a running client session.
+func resolveUsers(ctx context.Context, client *telegram.Client, users []config.MonitorSource) []int64 {
 	var ids []int64
 
-	// Create a temporary context wrapper to run the client for resolution
-	err := client.RunWithContext(ctx, func(ctx context.Context) error {
-		for _, user := range users {
-			if user.ID != 0 {
-				ids = append(ids, user.ID)
-				fmt.Printf("Added user ID for monitoring: %d\n", user.ID)
-			} else if user.Username != "" {
-				userID, _, username, fullName, err := client.ResolveUserUsername(ctx, user.Username)
-				if err != nil {
-					fmt.Printf("Warning: Could not resolve user %s: %v\n", user.Username, err)
-					continue
-				}
-				ids = append(ids, userID)
-				fmt.Printf("Resolved user @%s (%s) to ID: %d\n", username, fullName, userID)
+	for _, user := range users {
+		if user.ID != 0 {
+			ids = append(ids, user.ID)
+			fmt.Printf("Added user ID for monitoring: %d\n", user.ID)
+		} else if user.Username != "" {
+			userID, _, username, fullName, err := client.ResolveUserUsername(ctx, user.Username)
+			if err != nil {
+				fmt.Printf("Warning: Could not resolve user %s: %v\n", user.Username, err)
+				continue
 			}
+			ids = append(ids, userID)
+			fmt.Printf("Resolved user @%s (%s) to ID: %d\n", username, fullName, userID)
 		}
-		return nil
-	})
-
-	if err != nil {
-		return nil, fmt.Errorf("error resolving users: %w", err)
 	}
 
-	return ids, nil
+	return ids
 }
 
-func runMonitor(cmd *cobra.Command, args []string, apiKey string, apiID int, apiHash string, noPrompt bool) error {
+func runMonitor(cmd *cobra.Command, args []string, apiKey string, apiID int, apiHash string, noPrompt bool, pruneInterval time.Duration) error {
 	cfg, err := config.Load()
 	if err != nil {
 		return fmt.Errorf("error loading config: %w", err)
@@ -181,8 +165,10 @@ func runMonitor(cmd *cobra.Command, args []string, apiKey string, apiID int, api
 
 	// Load monitor configuration
 	var monitorCfg *config.MonitorConfig
+	monitorCfgPath := config.GetMonitorConfigPath()
 	if configFile != "" {
-		monitorCfg, err = config.LoadMonitorConfig()
+		monitorCfgPath = configFile
+		monitorCfg, err = config.LoadMonitorConfigFrom(configFile)
 		if err != nil {
 			return fmt.Errorf("error loading monitor config: %w", err)
 		}
@@ -194,71 +180,224 @@ func runMonitor(cmd *cobra.Command, args []string, apiKey string, apiID int, api
 		}
 	}
 
-	dbPath := config.GetDatabasePath()
-	dbDir := filepath.Dir(dbPath)
-	if err := os.MkdirAll(dbDir, 0755); err != nil {
-		return fmt.Errorf("error creating database directory: %w", err)
-	}
-
-	db, err := database.New(dbPath)
+	db, err := database.New(config.GetDatabaseDSN())
 	if err != nil {
 		return fmt.Errorf("error initializing database: %w", err)
 	}
 	defer db.Close()
 
+	// Messages, status updates and filter matches are written in batches
+	// from one goroutine so bursts don't contend for the database lock
+	writer := database.NewBatchWriter(db)
+	defer writer.Close()
+
 	client := telegram.NewClient(cfg)
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	// Resolve usernames to IDs and combine sources
-	sourceIDs, err := resolveSources(ctx, client, monitorCfg.SourceChannels, monitorCfg.SourceGroups)
+	var sourceIDs, targetIDs, userIDs []int64
+	err = client.RunWithContext(ctx, func(ctx context.Context) error {
+		var err error
+		sourceIDs, targetIDs, userIDs, err = resolveMonitorConfig(ctx, client, monitorCfg)
+		return err
+	})
 	if err != nil {
-		return fmt.Errorf("error resolving source channels/groups: %w", err)
+		return err
 	}
 
-	if len(sourceIDs) == 0 {
-		return fmt.Errorf("no valid source channels or groups specified in monitor config")
-	}
+	// For now, use the first target channel. In the future, we could support multiple targets
+	routes := telegram.NewMonitorRoutes(sourceIDs, targetIDs[0], userIDs)
 
-	// Resolve target channel usernames to IDs
-	targetIDs, err := resolveTargets(ctx, client, monitorCfg.TargetChannels)
+	// Users added with 'teleslurp watch user add' are watched alongside the config's
+	watchedVersion, err := loadMonitoredUsers(db, routes)
 	if err != nil {
-		return fmt.Errorf("error resolving target channels: %w", err)
+		fmt.Printf("Warning: Failed to load monitored users: %v\n", err)
 	}
 
-	if len(targetIDs) == 0 {
-		return fmt.Errorf("no valid target channels specified in monitor config")
+	if users := routes.Users(); len(users) > 0 {
+		fmt.Printf("Monitoring status changes for %d users\n", len(users))
 	}
 
-	// Resolve users for status monitoring
-	userIDs, err := resolveUsers(ctx, client, monitorCfg.MonitorUsers)
-	if err != nil {
-		return fmt.Errorf("error resolving users for monitoring: %w", err)
+	filterManager := filter.NewFilterManager(writer)
+	if err := filterManager.LoadFilters(); err != nil {
+		fmt.Printf("Warning: Failed to load message filters: %v\n", err)
+	} else {
+		fmt.Printf("Loaded %d message filters\n", filterManager.Count())
+	}
+
+	// Pick up filters and watched users added from another terminal
+	go filterManager.Watch(ctx, filterPollInterval)
+	go watchMonitoredUsers(ctx, db, routes, watchedVersion, filterPollInterval)
+
+	if pruneInterval > 0 {
+		policy, err := loadRetentionPolicy(nil)
+		if err != nil {
+			return err
+		}
+		if len(policy) == 0 {
+			fmt.Println("Warning: --prune-interval set but no retention is configured")
+		} else {
+			go runPruner(ctx, db, policy, pruneInterval)
+		}
 	}
 
-	if len(userIDs) > 0 {
-		fmt.Printf("Monitoring status changes for %d users\n", len(userIDs))
+	reload := func(cfg *config.MonitorConfig) {
+		// Resolve the config and request the state of new sources in one
+		// session, as the API calls need a running client
+		err := client.RunWithContext(ctx, func(ctx context.Context) error {
+			sourceIDs, targetIDs, userIDs, err := resolveMonitorConfig(ctx, client, cfg)
+			if err != nil {
+				return err
+			}
+			added := routes.Update(sourceIDs, targetIDs[0], userIDs)
+			fmt.Printf("Reloaded monitor config: %d sources, target %d, %d watched users\n", len(sourceIDs), targetIDs[0], len(userIDs))
+
+			// New sources need the same initial state request as at startup
+			// before Telegram sends their updates
+			if len(added) > 0 {
+				fmt.Printf("Getting initial state for %d new sources...\n", len(added))
+				client.GetInitialChannelStates(ctx, added)
+			}
+			return nil
+		})
+		if err != nil {
+			fmt.Printf("Warning: Keeping previous monitor config: %v\n", err)
+		}
 	}
 
+	go func() {
+		if err := config.WatchMonitorConfig(ctx, monitorCfgPath, reload); err != nil {
+			fmt.Printf("Warning: Monitor config changes will not be picked up: %v\n", err)
+		}
+	}()
+
 	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
 
 	go func() {
-		<-sigChan
-		fmt.Println("\nReceived shutdown signal. Gracefully shutting down...")
-		cancel()
+		for sig := range sigChan {
+			if sig == syscall.SIGHUP {
+				fmt.Println("\nReceived SIGHUP. Reloading filters and monitor config...")
+				if err := filterManager.LoadFilters(); err != nil {
+					fmt.Printf("Warning: Failed to reload message filters: %v\n", err)
+				}
+				if _, err := loadMonitoredUsers(db, routes); err != nil {
+					fmt.Printf("Warning: Failed to reload monitored users: %v\n", err)
+				}
+				cfg, err := config.LoadMonitorConfigFrom(monitorCfgPath)
+				if err != nil {
+					fmt.Printf("Warning: Failed to reload monitor config: %v\n", err)
+					continue
+				}
+				reload(cfg)
+				continue
+			}
+
+			fmt.Println("\nReceived shutdown signal. Gracefully shutting down...")
+			cancel()
+			return
+		}
 	}()
 
 	fmt.Printf("Starting teleslurp monitor...\n")
 	fmt.Printf("Monitoring %d sources and forwarding to %d target channels\n", len(sourceIDs), len(targetIDs))
 
-	// For now, use the first target channel. In the future, we could support multiple targets
-	targetChannelID := targetIDs[0]
+	return client.MonitorWithRoutes(ctx, routes, filterManager, writer)
+}
 
-	if len(userIDs) > 0 {
-		return client.MonitorAndForwardWithUsers(ctx, sourceIDs, targetChannelID, userIDs, db)
-	} else {
-		return client.MonitorAndForward(ctx, sourceIDs, targetChannelID, db)
+// resolveMonitorConfig resolves all sources, targets and watched users of a
+// monitor config. It must be called from a running client session.
+func resolveMonitorConfig(ctx context.Context, client *telegram.Client, monitorCfg *config.MonitorConfig) ([]int64, []int64, []int64, error) {
+	// Resolve usernames to IDs and combine sources
+	sourceIDs := resolveSources(ctx, client, monitorCfg.SourceChannels, monitorCfg.SourceGroups)
+	if len(sourceIDs) == 0 {
+		return nil, nil, nil, fmt.Errorf("no valid source channels or groups specified in monitor config")
+	}
+
+	// Resolve target channel usernames to IDs
+	targetIDs := resolveTargets(ctx, client, monitorCfg.TargetChannels)
+	if len(targetIDs) == 0 {
+		return nil, nil, nil, fmt.Errorf("no valid target channels specified in monitor config")
+	}
+
+	// Resolve users for status monitoring
+	userIDs := resolveUsers(ctx, client, monitorCfg.MonitorUsers)
+
+	return sourceIDs, targetIDs, userIDs, nil
+}
+
+// runPruner applies the retention policy every interval until ctx is done
+func runPruner(ctx context.Context, db *database.DB, policy map[string]time.Duration, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			results, err := db.Prune(policy, false)
+			if err != nil {
+				fmt.Printf("Warning: Failed to prune database: %v\n", err)
+			}
+			for _, r := range results {
+				if r.Deleted > 0 {
+					fmt.Printf("Pruned %d rows from %s\n", r.Deleted, r.Table)
+				}
+			}
+		}
+	}
+}
+
+// loadMonitoredUsers replaces the routes' stored users with the monitored_users
+// table and returns the change version it read
+func loadMonitoredUsers(db database.Store, routes *telegram.MonitorRoutes) (int64, error) {
+	version, err := db.GetChangeVersion("monitored_users")
+	if err != nil {
+		return 0, err
+	}
+
+	users, err := db.GetMonitoredUsers()
+	if err != nil {
+		return 0, err
+	}
+
+	ids := make([]int64, 0, len(users))
+	for _, u := range users {
+		ids = append(ids, u.UserID)
+	}
+	routes.SetStoredUsers(ids)
+	return version, nil
+}
+
+// watchMonitoredUsers reloads the stored users whenever the monitored_users
+// table changes, until ctx is done
+func watchMonitoredUsers(ctx context.Context, db database.Store, routes *telegram.MonitorRoutes, version int64, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			current, err := db.GetChangeVersion("monitored_users")
+			if err != nil {
+				fmt.Printf("Warning: Failed to check monitored users version: %v\n", err)
+				continue
+			}
+			if current == version {
+				continue
+			}
+
+			current, err = loadMonitoredUsers(db, routes)
+			if err != nil {
+				fmt.Printf("Warning: Failed to reload monitored users: %v\n", err)
+				continue
+			}
+			version = current
+			fmt.Printf("Reloaded monitored users (%d watched)\n", len(routes.Users()))
+		}
 	}
 }
@@ -0,0 +1,172 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gnomegl/teleslurp/internal/config"
+	"github.com/gnomegl/teleslurp/internal/database"
+	"github.com/gnomegl/teleslurp/internal/export"
+	"github.com/gnomegl/teleslurp/internal/timeutil"
+	"github.com/spf13/cobra"
+)
+
+var queryCmd = &cobra.Command{
+	Use:   "query <search expression>",
+	Short: "Full-text search over stored messages",
+	Long: `Search messages stored by the monitor using SQLite FTS5.
+
+Supports words, "exact phrases", prefix* matches and AND/OR/NOT, e.g.
+  teleslurp query '"cash out" OR cashout'
+  teleslurp query 'wallet* NOT scam' --channel somechannel --since 2024-01-01
+
+Requires teleslurp built with -tags sqlite_fts5.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runQuery,
+}
+
+func init() {
+	queryCmd.Flags().String("channel", "", "Only search messages from this channel ID or username")
+	queryCmd.Flags().String("since", "", "Only search messages on or after this date (YYYY-MM-DD)")
+	queryCmd.Flags().String("until", "", "Only search messages on or before this date (YYYY-MM-DD)")
+	queryCmd.Flags().Int("limit", 50, "Maximum number of results (0 for no limit)")
+	queryCmd.Flags().BoolP("json", "j", false, "Export results in JSON format")
+	queryCmd.Flags().BoolP("csv", "c", false, "Export results in CSV format")
+	queryCmd.Flags().StringP("output", "o", "", "Output file (default query_results.json or query_results.csv)")
+
+	rootCmd.AddCommand(queryCmd)
+}
+
+func runQuery(cmd *cobra.Command, args []string) error {
+	channel, _ := cmd.Flags().GetString("channel")
+	since, _ := cmd.Flags().GetString("since")
+	until, _ := cmd.Flags().GetString("until")
+	limit, _ := cmd.Flags().GetInt("limit")
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+	csvOutput, _ := cmd.Flags().GetBool("csv")
+	output, _ := cmd.Flags().GetString("output")
+
+	if jsonOutput && csvOutput {
+		return fmt.Errorf("cannot use both --json and --csv flags")
+	}
+
+	// Dates are days in the display zone
+	var sinceTime, untilTime string
+	if since != "" {
+		t, err := timeutil.ParseDate(since)
+		if err != nil {
+			return err
+		}
+		sinceTime = timeutil.Format(t)
+	}
+	if until != "" {
+		t, err := timeutil.ParseDate(until)
+		if err != nil {
+			return err
+		}
+		untilTime = timeutil.Format(t.AddDate(0, 0, 1))
+	}
+
+	// Initialize database
+	db, err := database.New(config.GetDatabaseDSN())
+	if err != nil {
+		return fmt.Errorf("error initializing database: %w", err)
+	}
+	defer db.Close()
+
+	q := database.MessageQuery{
+		Match:   strings.Join(args, " "),
+		Channel: channel,
+		Since:   sinceTime,
+		Until:   untilTime,
+		Limit:   limit,
+	}
+
+	// Terminal output highlights matches in bold, exports use plain brackets
+	if jsonOutput || csvOutput {
+		q.HighlightStart, q.HighlightEnd = "[", "]"
+	} else {
+		q.HighlightStart, q.HighlightEnd = "\033[1m", "\033[0m"
+	}
+
+	results, err := db.SearchMessages(q)
+	if err != nil {
+		if errors.Is(err, database.ErrFullTextUnavailable) {
+			return err
+		}
+		return fmt.Errorf("error running query: %w", err)
+	}
+
+	switch {
+	case jsonOutput:
+		file, err := createOutput(output, "query", "results", "json")
+		if err != nil {
+			return err
+		}
+		if results == nil {
+			results = []database.MessageSearchResult{}
+		}
+		return export.WriteJSON(results, file)
+	case csvOutput:
+		file, err := createOutput(output, "query", "results", "csv")
+		if err != nil {
+			return err
+		}
+		return exportQueryResultsCSV(results, file)
+	}
+
+	if len(results) == 0 {
+		fmt.Println("No messages found")
+		return nil
+	}
+
+	for _, r := range results {
+		channelName := r.ChannelTitle
+		if r.ChannelUsername != "" {
+			channelName += " (@" + r.ChannelUsername + ")"
+		}
+		fmt.Printf("📢 %s — %s\n", channelName, timeutil.Display(r.Date))
+		fmt.Printf("   %s\n", strings.ReplaceAll(r.Snippet, "\n", " "))
+		if r.URL != "" {
+			fmt.Printf("   🔗 %s\n", r.URL)
+		}
+		fmt.Println()
+	}
+	fmt.Printf("Found %d messages\n", len(results))
+	return nil
+}
+
+func exportQueryResultsCSV(results []database.MessageSearchResult, file *os.File) error {
+	writer := export.NewCSVWriter(file)
+	defer writer.Close()
+
+	headers := []string{"Channel ID", "Channel Title", "Channel Username", "Message ID", "Date", "Message", "Snippet", "URL"}
+	if err := writer.WriteHeader(headers); err != nil {
+		return err
+	}
+
+	for _, r := range results {
+		record := []string{
+			strconv.FormatInt(r.ChannelID, 10),
+			r.ChannelTitle,
+			r.ChannelUsername,
+			strconv.Itoa(r.MessageID),
+			r.Date,
+			r.Message,
+			r.Snippet,
+			r.URL,
+		}
+		if err := writer.WriteRecord(record); err != nil {
+			return err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("error writing CSV file: %w", err)
+	}
+	fmt.Printf("Query results exported to CSV file: %s\n", file.Name())
+	return nil
+}
@@ -1,15 +1,54 @@
 package commands
 
 import (
+	"runtime/debug"
+
+	"github.com/gnomegl/teleslurp/internal/config"
+	"github.com/gnomegl/teleslurp/internal/timeutil"
 	"github.com/spf13/cobra"
 )
 
+// Version is the teleslurp version. Release builds set it with
+// -ldflags "-X github.com/gnomegl/teleslurp/internal/commands.Version=v1.2.3";
+// otherwise the module version recorded by go install is used.
+var Version string
+
 var rootCmd = &cobra.Command{
 	Use:   "teleslurp",
 	Short: "Teleslurp is a tool for analyzing Telegram users and groups",
 	Long: `Teleslurp allows you to search and analyze Telegram users and their group participation,
 utilizing TGScan API for data gathering and providing detailed historical information.`,
 	SilenceErrors: true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		name := timezone
+		if name == "" {
+			name = config.GetTimezone()
+		}
+		loc, err := timeutil.LoadLocation(name)
+		if err != nil {
+			return err
+		}
+		timeutil.SetLocation(loc)
+		return nil
+	},
+}
+
+// timezone is the --timezone flag
+var timezone string
+
+func init() {
+	rootCmd.Version = version()
+	rootCmd.PersistentFlags().StringVar(&timezone, "timezone", "", "Time zone for times shown in the terminal and reports, e.g. UTC or Europe/Berlin (default local time)")
+}
+
+func version() string {
+	if Version != "" {
+		return Version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	return "dev"
 }
 
 func Execute() error {
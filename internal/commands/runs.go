@@ -0,0 +1,71 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gnomegl/teleslurp/internal/config"
+	"github.com/gnomegl/teleslurp/internal/database"
+	"github.com/gnomegl/teleslurp/internal/timeutil"
+	"github.com/spf13/cobra"
+)
+
+var runsLimit int
+
+func init() {
+	runsCmd := &cobra.Command{
+		Use:   "runs [username|user-id]",
+		Short: "List recorded search runs",
+		Long: `List the search runs recorded in the database, newest first.
+Pass a username or user ID to only show runs for that user.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runListRuns,
+	}
+
+	runsCmd.Flags().IntVar(&runsLimit, "limit", 20, "Maximum number of runs to show (0 for no limit)")
+
+	rootCmd.AddCommand(runsCmd)
+}
+
+func runListRuns(cmd *cobra.Command, args []string) error {
+	var target string
+	if len(args) > 0 {
+		target = strings.TrimPrefix(args[0], "@")
+	}
+
+	// Initialize database
+	db, err := database.New(config.GetDatabaseDSN())
+	if err != nil {
+		return fmt.Errorf("error initializing database: %w", err)
+	}
+	defer db.Close()
+
+	runs, err := db.ListSearchRuns(target, runsLimit)
+	if err != nil {
+		return fmt.Errorf("error listing search runs: %w", err)
+	}
+
+	if len(runs) == 0 {
+		fmt.Println("No search runs recorded")
+		return nil
+	}
+
+	fmt.Println("Search Runs:")
+	fmt.Println("============")
+	for _, r := range runs {
+		user := r.Query
+		if r.Username != "" {
+			user = "@" + r.Username
+		}
+		if r.UserID != 0 {
+			user = fmt.Sprintf("%s (ID: %d)", user, r.UserID)
+		}
+		fmt.Printf("Run #%d | %s | Started: %s | Status: %s | Channels: %d | Messages: %d | Credits: %d\n",
+			r.ID, user, timeutil.Display(r.StartedAt), r.Status, r.ChannelCount, r.MessageCount, r.Credits)
+		if r.Error != "" {
+			fmt.Printf("  Error: %s\n", r.Error)
+		}
+	}
+
+	return nil
+}
@@ -4,13 +4,19 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"crypto/ed25519"
+	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gnomegl/teleslurp/internal/config"
+	"github.com/gnomegl/teleslurp/internal/database"
+	"github.com/gnomegl/teleslurp/internal/evidence"
 	"github.com/gnomegl/teleslurp/internal/export"
 	"github.com/gnomegl/teleslurp/internal/telegram"
 	"github.com/gnomegl/teleslurp/internal/tgscan"
@@ -21,8 +27,12 @@ import (
 var (
 	exportJSON            bool
 	exportCSV             bool
+	exportFormat          string
 	exportChannelMetadata bool
 	inputFile             string
+	outputDir             string
+	evidenceMode          bool
+	signKeyPath           string
 )
 
 func init() {
@@ -49,8 +59,12 @@ func init() {
 	searchCmd.Flags().BoolVar(&noPrompt, "no-prompt", false, "Disable interactive prompts")
 	searchCmd.Flags().BoolVar(&exportJSON, "json", false, "Export results to JSON file")
 	searchCmd.Flags().BoolVar(&exportCSV, "csv", false, "Export results to CSV file")
+	searchCmd.Flags().StringVar(&exportFormat, "format", "", "Export format for found messages:\n"+export.Usage()+"(default json, or csv with --csv)")
 	searchCmd.Flags().BoolVar(&exportChannelMetadata, "metadata", false, "Export channel metadata")
 	searchCmd.Flags().StringVar(&inputFile, "input-file", "", "Input file containing Telegram channels/groups to search")
+	searchCmd.Flags().StringVar(&outputDir, "output-dir", "", "Write exports to a new folder per run in this directory, with a manifest.json of file hashes")
+	searchCmd.Flags().BoolVar(&evidenceMode, "evidence", false, "Also log the raw Telegram payload, retrieval time and account of every message (requires --output-dir)")
+	searchCmd.Flags().StringVar(&signKeyPath, "sign-key", "", "Sign the manifest with this ed25519 key, created if missing (requires --output-dir)")
 
 	rootCmd.AddCommand(searchCmd)
 }
@@ -75,7 +89,7 @@ func promptTGCredentials() (int, string) {
 	return apiID, apiHash
 }
 
-func runSearch(cmd *cobra.Command, args []string, apiKey string, apiID int, apiHash string, noPrompt bool) error {
+func runSearch(cmd *cobra.Command, args []string, apiKey string, apiID int, apiHash string, noPrompt bool) (err error) {
 	cfg, err := config.Load()
 	if err != nil {
 		return fmt.Errorf("error loading config: %w", err)
@@ -109,7 +123,79 @@ func runSearch(cmd *cobra.Command, args []string, apiKey string, apiID int, apiH
 		return fmt.Errorf("error saving config: %w", err)
 	}
 
+	format := exportFormat
+	if format == "" {
+		format = "json"
+		if exportCSV {
+			format = "csv"
+		}
+	}
+	exporter, err := export.Get(format)
+	if err != nil {
+		return err
+	}
+
+	if outputDir == "" && (evidenceMode || signKeyPath != "") {
+		return fmt.Errorf("--evidence and --sign-key require --output-dir")
+	}
+
+	var signKey ed25519.PrivateKey
+	if signKeyPath != "" {
+		var created bool
+		signKey, created, err = evidence.LoadOrCreateKey(signKeyPath)
+		if err != nil {
+			return err
+		}
+		if created {
+			fmt.Printf("🔑 Created signing key %s (public key %s.pub)\n", signKeyPath, signKeyPath)
+		}
+		fmt.Printf("🔑 Signing with key %s\n", evidence.Fingerprint(signKey.Public().(ed25519.PublicKey)))
+	}
+
 	query := args[0]
+
+	// Exports of this run go to its own folder, described by a manifest
+	// written once the run is over
+	var runDir string
+	var runID int64
+	if outputDir != "" {
+		startedAt := time.Now()
+		runDir, err = export.NewRunDir(outputDir, query, startedAt)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("📁 Output folder: %s\n", runDir)
+		defer func() {
+			manifest := &export.Manifest{
+				Tool:       "teleslurp",
+				Version:    version(),
+				RunID:      runID,
+				Query:      query,
+				Parameters: searchParameters(),
+				StartedAt:  startedAt,
+				FinishedAt: time.Now(),
+			}
+			if err != nil {
+				manifest.Error = err.Error()
+			}
+			filename, merr := export.WriteManifest(runDir, manifest)
+			if merr != nil {
+				fmt.Printf("Warning: Failed to write manifest: %v\n", merr)
+				return
+			}
+			fmt.Printf("✓ Manifest written to: %s\n", filename)
+
+			if signKey != nil {
+				sigFilename, serr := evidence.SignManifest(runDir, signKey)
+				if serr != nil {
+					fmt.Printf("Warning: Failed to sign manifest: %v\n", serr)
+					return
+				}
+				fmt.Printf("✓ Manifest signed: %s\n", sigFilename)
+			}
+		}()
+	}
+	basename := filepath.Join(runDir, export.SafeName(query))
 	var searchUser types.User
 	if id, err := strconv.ParseInt(query, 10, 64); err == nil {
 		searchUser = types.User{ID: id}
@@ -117,7 +203,19 @@ func runSearch(cmd *cobra.Command, args []string, apiKey string, apiID int, apiH
 		searchUser = types.User{Username: query}
 	}
 
+	// Record the run so repeated searches of the same user build up history
+	db, runID := startSearchRun(query, searchUser)
+	if db != nil {
+		defer db.Close()
+		defer func() {
+			if ferr := db.FinishSearchRun(runID, err); ferr != nil {
+				fmt.Printf("Warning: Failed to finish search run: %v\n", ferr)
+			}
+		}()
+	}
+
 	var groups []types.Group
+	var profile *types.TGScanResponse
 	if inputFile != "" {
 		channels, err := readChannelsFromFile(inputFile)
 		if err != nil {
@@ -139,6 +237,9 @@ func runSearch(cmd *cobra.Command, args []string, apiKey string, apiID int, apiH
 			// Check if it's a "user not found" error from TGScan
 			if strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "No user") {
 				fmt.Printf("❌ User '%s' not found in TGScan database\n", query)
+				if db != nil {
+					db.FinishSearchRun(runID, fmt.Errorf("user not found in TGScan"))
+				}
 				// Return nil instead of error to avoid printing usage
 				return nil
 			} else {
@@ -146,39 +247,99 @@ func runSearch(cmd *cobra.Command, args []string, apiKey string, apiID int, apiH
 			}
 		} else {
 			// User found in TGScan
-			if exportJSON {
-				if err := exportToJSON(tgScanResp, query); err != nil {
-					return fmt.Errorf("error exporting to JSON: %w", err)
+			printUserInfo(tgScanResp)
+
+			files, err := export.WriteProfile(exporter, tgScanResp, basename)
+			if err != nil {
+				return fmt.Errorf("error exporting TGScan profile: %w", err)
+			}
+			if len(files) > 0 {
+				fmt.Printf("✓ TGScan profile exported to:\n")
+				for _, file := range files {
+					fmt.Printf("  %s\n", file)
 				}
-			} else if exportCSV {
-				if err := exportToCSV(tgScanResp, query); err != nil {
-					return fmt.Errorf("error exporting to CSV: %w", err)
+			}
+
+			if db != nil {
+				if err := db.SaveSearchRunTGScan(runID, tgScanResp); err != nil {
+					fmt.Printf("Warning: Failed to save TGScan results to database: %v\n", err)
 				}
-			} else {
-				printUserInfo(tgScanResp)
 			}
 
 			groups = tgScanResp.Result.Groups
+			profile = tgScanResp
 		}
 	}
 
-	var format telegram.OutputFormat
-	if exportJSON {
-		format = telegram.FormatJSON
-	} else if exportCSV {
-		format = telegram.FormatCSV
-	} else {
-		format = telegram.FormatJSON
+	opts := telegram.SearchOptions{
+		Format:         format,
+		ExportMetadata: exportChannelMetadata,
+		OutputDir:      runDir,
+		Evidence:       evidenceMode,
+		Profile:        profile,
+		DB:             db,
+		RunID:          runID,
 	}
 
 	ctx := context.Background()
-	if err := telegram.RunClient(ctx, cfg, &searchUser, groups, format, exportChannelMetadata); err != nil {
+	if err := telegram.RunClient(ctx, cfg, &searchUser, groups, opts); err != nil {
 		return fmt.Errorf("error running Telegram client: %w", err)
 	}
 
+	if db != nil {
+		printChangesSincePreviousRun(db, runID)
+	}
+
 	return nil
 }
 
+// startSearchRun opens the database and records a new search run. The search
+// still works without a database, so failures are only reported.
+func startSearchRun(query string, searchUser types.User) (*database.DB, int64) {
+	db, err := database.New(config.GetDatabaseDSN())
+	if err != nil {
+		fmt.Printf("Warning: Failed to open database, search run will not be recorded: %v\n", err)
+		return nil, 0
+	}
+
+	source := "tgscan"
+	if inputFile != "" {
+		source = "input_file"
+	}
+
+	params, _ := json.Marshal(searchParameters())
+
+	runID, err := db.CreateSearchRun(database.SearchRun{
+		Query:      query,
+		Source:     source,
+		UserID:     searchUser.ID,
+		Username:   searchUser.Username,
+		Parameters: string(params),
+	})
+	if err != nil {
+		fmt.Printf("Warning: %v\n", err)
+		db.Close()
+		return nil, 0
+	}
+
+	fmt.Printf("Search run #%d\n", runID)
+	return db, runID
+}
+
+// searchParameters returns the flags of a search, recorded with the run
+func searchParameters() map[string]interface{} {
+	return map[string]interface{}{
+		"json":       exportJSON,
+		"csv":        exportCSV,
+		"format":     exportFormat,
+		"metadata":   exportChannelMetadata,
+		"input_file": inputFile,
+		"output_dir": outputDir,
+		"evidence":   evidenceMode,
+		"sign_key":   signKeyPath,
+	}
+}
+
 func printUserInfo(tgScanResp *types.TGScanResponse) {
 	// Check if user was found
 	if tgScanResp.Result.User.ID == 0 && tgScanResp.Result.User.Username == "" {
@@ -204,9 +365,17 @@ func printUserInfo(tgScanResp *types.TGScanResponse) {
 		}
 	}
 
+	// ID history
+	if len(tgScanResp.Result.IDHistory) > 0 {
+		fmt.Printf("\nPrevious IDs (%d):\n", len(tgScanResp.Result.IDHistory))
+		for _, history := range tgScanResp.Result.IDHistory {
+			fmt.Printf("  • %d (%s)\n", history.ID, history.Date)
+		}
+	}
+
 	// Groups
 	if tgScanResp.Result.Meta.NumGroups > 0 {
-		fmt.Printf("\nGroups (%d found):\n", tgScanResp.Result.Meta.NumGroups)
+		fmt.Printf("\nGroups (%d found, %d known):\n", tgScanResp.Result.Meta.NumGroups, tgScanResp.Result.Meta.KnownNumGroups)
 		for _, group := range tgScanResp.Result.Groups {
 			fmt.Printf("  • %s (@%s)\n", group.Title, group.Username)
 		}
@@ -221,92 +390,6 @@ func printUserInfo(tgScanResp *types.TGScanResponse) {
 	fmt.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
 }
 
-func exportToJSON(resp *types.TGScanResponse, username string) error {
-	filename := export.FormatFilename(username, "tgscan", "json")
-	return export.WriteJSON(resp, filename)
-}
-
-func exportToCSV(resp *types.TGScanResponse, username string) error {
-	if err := exportUsernameHistoryCSV(resp); err != nil {
-		return err
-	}
-
-	if err := exportGroupsCSV(resp, username); err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func exportUsernameHistoryCSV(resp *types.TGScanResponse) error {
-	historyFilename := export.FormatFilename(resp.Result.User.Username, "usernames_tgscan", "csv")
-	writer, err := export.NewCSVWriter(historyFilename)
-	if err != nil {
-		return err
-	}
-	defer writer.Close()
-
-	headers := []string{"User ID", "Current Username", "Previous Username", "Date Changed"}
-	if err := writer.WriteHeader(headers); err != nil {
-		return err
-	}
-
-	currentRecord := []string{
-		fmt.Sprintf("%d", resp.Result.User.ID),
-		resp.Result.User.Username,
-		"", // No previous username for current
-		"", // No date for current
-	}
-	if err := writer.WriteRecord(currentRecord); err != nil {
-		return err
-	}
-
-	for _, h := range resp.Result.UsernameHistory {
-		record := []string{
-			fmt.Sprintf("%d", resp.Result.User.ID),
-			resp.Result.User.Username,
-			h.Username,
-			h.Date,
-		}
-		if err := writer.WriteRecord(record); err != nil {
-			return err
-		}
-	}
-
-	fmt.Printf("Username history exported to CSV file: %s\n", historyFilename)
-	return nil
-}
-
-func exportGroupsCSV(resp *types.TGScanResponse, username string) error {
-	groupsFilename := export.FormatFilename(username, "groups_tgscan", "csv")
-	writer, err := export.NewCSVWriter(groupsFilename)
-	if err != nil {
-		return err
-	}
-	defer writer.Close()
-
-	headers := []string{"User ID", "User Username", "Group Title", "Group Username", "Date Updated"}
-	if err := writer.WriteHeader(headers); err != nil {
-		return err
-	}
-
-	for _, group := range resp.Result.Groups {
-		record := []string{
-			fmt.Sprintf("%d", resp.Result.User.ID),
-			resp.Result.User.Username,
-			group.Title,
-			group.Username,
-			group.DateUpdated,
-		}
-		if err := writer.WriteRecord(record); err != nil {
-			return err
-		}
-	}
-
-	fmt.Printf("Groups exported to CSV file: %s\n", groupsFilename)
-	return nil
-}
-
 func readChannelsFromFile(filename string) ([]types.Group, error) {
 	content, err := os.ReadFile(filename)
 	if err != nil {
@@ -0,0 +1,291 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gnomegl/teleslurp/internal/config"
+	"github.com/gnomegl/teleslurp/internal/database"
+	"github.com/gnomegl/teleslurp/internal/export"
+	"github.com/gnomegl/teleslurp/internal/presence"
+	"github.com/gnomegl/teleslurp/internal/timeutil"
+	"github.com/spf13/cobra"
+)
+
+var statusCmd = &cobra.Command{
+	Use:   "status <username|user-id>",
+	Short: "Report the recorded online status history of a watched user",
+	Long: `Report the status updates the monitor recorded for a watched user: recent
+history, online sessions per day, a weekday × hour heatmap of when the user is
+online and the longest absences.
+
+Times, days and the heatmap use the --timezone, local time by default.
+Export with --json for the full report or --csv for the sessions, e.g. to
+infer the user's time zone from their active hours.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runStatus,
+}
+
+func init() {
+	statusCmd.Flags().String("since", "", "Only use updates since this date (YYYY-MM-DD) or period (e.g. 30d, 2w)")
+	statusCmd.Flags().Int("history", 20, "Number of recent status updates to show (0 to hide)")
+	statusCmd.Flags().BoolP("json", "j", false, "Export the report in JSON format")
+	statusCmd.Flags().BoolP("csv", "c", false, "Export the online sessions in CSV format")
+	statusCmd.Flags().StringP("output", "o", "", "Output file (default <user>_status.json or <user>_sessions.csv)")
+
+	rootCmd.AddCommand(statusCmd)
+}
+
+func runStatus(cmd *cobra.Command, args []string) error {
+	since, _ := cmd.Flags().GetString("since")
+	historyLimit, _ := cmd.Flags().GetInt("history")
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+	csvOutput, _ := cmd.Flags().GetBool("csv")
+	output, _ := cmd.Flags().GetString("output")
+
+	if jsonOutput && csvOutput {
+		return fmt.Errorf("cannot use both --json and --csv flags")
+	}
+
+	sinceTime, err := parseSince(since)
+	if err != nil {
+		return err
+	}
+
+	// Initialize database
+	db, err := database.New(config.GetDatabaseDSN())
+	if err != nil {
+		return fmt.Errorf("error initializing database: %w", err)
+	}
+	defer db.Close()
+
+	userID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		userID, err = db.FindStatusUserID(args[0])
+		if err != nil {
+			return fmt.Errorf("error looking up user: %w", err)
+		}
+		if userID == 0 {
+			return fmt.Errorf("no status updates recorded for %s", args[0])
+		}
+	}
+
+	updates, err := db.GetUserStatusHistory(userID, sinceTime)
+	if err != nil {
+		return fmt.Errorf("error getting status history: %w", err)
+	}
+	if updates == nil {
+		updates = []database.UserStatusUpdate{}
+	}
+
+	sightings, err := db.GetUserSightings(userID, sinceTime)
+	if err != nil {
+		return fmt.Errorf("error getting user sightings: %w", err)
+	}
+
+	report := presence.Analyze(userID, updates, timeutil.Location())
+	if sightings != nil {
+		report.Sightings = sightings
+	}
+
+	name := report.Username
+	if name == "" {
+		name = strconv.FormatInt(userID, 10)
+	}
+
+	switch {
+	case jsonOutput:
+		file, err := createOutput(output, name, "status", "json")
+		if err != nil {
+			return err
+		}
+		return export.WriteJSON(report, file)
+	case csvOutput:
+		file, err := createOutput(output, name, "sessions", "csv")
+		if err != nil {
+			return err
+		}
+		return exportSessionsCSV(report.Sessions, file)
+	}
+
+	printStatusReport(report, historyLimit)
+	return nil
+}
+
+// parseSince converts a date in the display zone or a period to the stored
+// time format
+func parseSince(since string) (string, error) {
+	if since == "" {
+		return "", nil
+	}
+	if t, err := timeutil.ParseDate(since); err == nil {
+		return timeutil.Format(t), nil
+	}
+	period, err := config.ParseRetention(since)
+	if err != nil {
+		return "", fmt.Errorf("invalid --since %q, expected YYYY-MM-DD or a period like 30d", since)
+	}
+	return timeutil.Format(time.Now().Add(-period)), nil
+}
+
+func printStatusReport(r *presence.Report, historyLimit int) {
+	user := fmt.Sprintf("ID: %d", r.UserID)
+	if r.Username != "" {
+		user = fmt.Sprintf("@%s (ID: %d)", r.Username, r.UserID)
+	}
+	if r.Name != "" {
+		user = r.Name + " " + user
+	}
+
+	fmt.Printf("👤 %s\n", user)
+	printSightings(r.Sightings, historyLimit)
+	if r.Updates == 0 {
+		fmt.Println("No status updates recorded")
+		return
+	}
+
+	var online time.Duration
+	for _, s := range r.Sessions {
+		online += time.Duration(s.Duration)
+	}
+
+	fmt.Printf("📊 %d status updates from %s to %s\n", r.Updates, r.FirstUpdate.Format("2006-01-02 15:04"), r.LastUpdate.Format("2006-01-02 15:04"))
+	fmt.Printf("🟢 %d online sessions, %.1f per day, %s online in total\n", len(r.Sessions), r.SessionsPerDay, formatDuration(online))
+
+	if historyLimit > 0 {
+		fmt.Println("\nRecent Status Updates:")
+		fmt.Println("======================")
+		history := r.History
+		if len(history) > historyLimit {
+			history = history[len(history)-historyLimit:]
+		}
+		for i := len(history) - 1; i >= 0; i-- {
+			fmt.Printf("%s | %s\n", timeutil.Display(history[i].StatusTime), history[i].Status)
+		}
+	}
+
+	fmt.Println("\nDaily Sessions:")
+	fmt.Println("===============")
+	for _, d := range r.Days {
+		fmt.Printf("%s | Sessions: %d | Online: %s\n", d.Date, d.Sessions, formatDuration(time.Duration(d.Online)))
+	}
+
+	fmt.Println("\nActive Hours (minutes online per hour):")
+	fmt.Println("========================================")
+	printHeatmap(r.Heatmap)
+
+	if len(r.LongestAbsences) > 0 {
+		fmt.Println("\nLongest Absences:")
+		fmt.Println("=================")
+		for _, a := range r.LongestAbsences {
+			fmt.Printf("%s → %s | %s\n", a.From.Format("2006-01-02 15:04"), a.To.Format("2006-01-02 15:04"), formatDuration(time.Duration(a.Duration)))
+		}
+	}
+}
+
+// printSightings lists the most recent messages the user posted
+func printSightings(sightings []database.UserSighting, limit int) {
+	if len(sightings) == 0 || limit <= 0 {
+		return
+	}
+
+	fmt.Printf("🚨 %d messages seen from this user\n", len(sightings))
+	fmt.Println("\nRecent Sightings:")
+	fmt.Println("=================")
+	if len(sightings) > limit {
+		sightings = sightings[:limit]
+	}
+	for _, s := range sightings {
+		chat := s.ChatTitle
+		if s.ChatUsername != "" {
+			chat += " (@" + s.ChatUsername + ")"
+		}
+		fmt.Printf("%s | %s [%s] | %s\n", timeutil.Display(s.MessageDate), chat, s.ChatType, strings.ReplaceAll(s.Message, "\n", " "))
+		if s.URL != "" {
+			fmt.Printf("   🔗 %s\n", s.URL)
+		}
+	}
+	fmt.Println()
+}
+
+// printHeatmap draws the heatmap Monday first, shading each cell relative to
+// the busiest hour
+func printHeatmap(h presence.Heatmap) {
+	shades := []rune(" ·░▒▓█")
+
+	max := 0
+	for _, day := range h {
+		for _, minutes := range day {
+			if minutes > max {
+				max = minutes
+			}
+		}
+	}
+
+	fmt.Print("     ")
+	for hour := 0; hour < 24; hour++ {
+		fmt.Printf("%-3d", hour)
+	}
+	fmt.Println()
+
+	for i := 0; i < 7; i++ {
+		weekday := time.Weekday((i + 1) % 7)
+		fmt.Printf("%s  ", weekday.String()[:3])
+		for hour := 0; hour < 24; hour++ {
+			shade := shades[0]
+			if minutes := h[weekday][hour]; minutes > 0 {
+				shade = shades[(minutes*(len(shades)-1)+max-1)/max]
+			}
+			fmt.Printf("%c%c ", shade, shade)
+		}
+		fmt.Println()
+	}
+	fmt.Printf("     (█ = %d minutes)\n", max)
+}
+
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	hours := int(d / time.Hour)
+	minutes := int((d % time.Hour) / time.Minute)
+	if hours >= 24 {
+		return fmt.Sprintf("%dd %dh", hours/24, hours%24)
+	}
+	if hours > 0 {
+		return fmt.Sprintf("%dh %dm", hours, minutes)
+	}
+	return fmt.Sprintf("%dm", minutes)
+}
+
+func exportSessionsCSV(sessions []presence.Session, file *os.File) error {
+	writer := export.NewCSVWriter(file)
+	defer writer.Close()
+
+	headers := []string{"Start", "End", "Duration Seconds", "Weekday", "Hour", "Start Unix", "End Unix"}
+	if err := writer.WriteHeader(headers); err != nil {
+		return err
+	}
+
+	for _, s := range sessions {
+		record := []string{
+			timeutil.Format(s.Start),
+			timeutil.Format(s.End),
+			strconv.FormatInt(int64(time.Duration(s.Duration)/time.Second), 10),
+			s.Start.Weekday().String(),
+			strconv.Itoa(s.Start.Hour()),
+			strconv.FormatInt(s.Start.Unix(), 10),
+			strconv.FormatInt(s.End.Unix(), 10),
+		}
+		if err := writer.WriteRecord(record); err != nil {
+			return err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("error writing CSV file: %w", err)
+	}
+	fmt.Printf("Online sessions exported to CSV file: %s\n", file.Name())
+	return nil
+}
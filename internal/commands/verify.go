@@ -0,0 +1,122 @@
+package commands
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/gnomegl/teleslurp/internal/evidence"
+	"github.com/gnomegl/teleslurp/internal/export"
+	"github.com/gnomegl/teleslurp/internal/timeutil"
+	"github.com/spf13/cobra"
+)
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify <run-folder>",
+	Short: "Check the hashes, hash chain and signature of an export folder",
+	Long: `Check an export folder written with search --output-dir against its
+manifest.json: every file's SHA-256 and the hash chain over the files, that no
+files were added, the manifest signature if the folder is signed, and every
+record of the evidence log if there is one.
+
+Signatures carry their public key, so without --pubkey verify only shows which
+key signed the manifest. Pass the public key you trust to require that key.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runVerify,
+	// A failed check is a result, not a usage mistake
+	SilenceUsage: true,
+}
+
+func init() {
+	verifyCmd.Flags().String("pubkey", "", "Require the manifest to be signed with this ed25519 public key (PEM)")
+
+	rootCmd.AddCommand(verifyCmd)
+}
+
+func runVerify(cmd *cobra.Command, args []string) error {
+	pubkeyPath, _ := cmd.Flags().GetString("pubkey")
+	dir := args[0]
+
+	var trusted ed25519.PublicKey
+	if pubkeyPath != "" {
+		var err error
+		if trusted, err = evidence.LoadPublicKey(pubkeyPath); err != nil {
+			return err
+		}
+	}
+
+	manifest, manifestData, err := export.ReadManifest(dir)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("📁 %s\n", dir)
+	fmt.Printf("Run: %s | Query: %s | teleslurp %s\n", timeutil.DisplayTime(manifest.StartedAt), manifest.Query, manifest.Version)
+
+	failed := false
+	report := func(ok bool, format string, a ...interface{}) {
+		if ok {
+			fmt.Printf("✓ "+format+"\n", a...)
+		} else {
+			failed = true
+			fmt.Printf("❌ "+format+"\n", a...)
+		}
+	}
+
+	problems, err := export.VerifyManifest(dir, manifest, evidence.SignatureFilename)
+	if err != nil {
+		return err
+	}
+	if len(problems) == 0 {
+		report(true, "%d files match the manifest and hash chain", len(manifest.Files))
+	} else {
+		report(false, "%d problems with the %d files in the manifest:", len(problems), len(manifest.Files))
+	}
+	for _, p := range problems {
+		fmt.Printf("   %s\n", p)
+	}
+
+	_, err = os.Stat(filepath.Join(dir, evidence.SignatureFilename))
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		if trusted != nil {
+			report(false, "Manifest is not signed")
+		} else {
+			fmt.Println("ℹ️  Manifest is not signed")
+		}
+	case err != nil:
+		return fmt.Errorf("error checking signature: %w", err)
+	default:
+		pub, err := evidence.VerifySignature(dir, manifestData)
+		switch {
+		case err != nil:
+			report(false, "Manifest signature: %v", err)
+		case trusted != nil && !bytes.Equal(pub, trusted):
+			report(false, "Manifest is signed by %s, not the trusted key %s", evidence.Fingerprint(pub), evidence.Fingerprint(trusted))
+		case trusted != nil:
+			report(true, "Manifest signed by the trusted key %s", evidence.Fingerprint(pub))
+		default:
+			report(true, "Manifest signed by %s (pass --pubkey to require a key)", evidence.Fingerprint(pub))
+		}
+	}
+
+	count, problems, err := evidence.CheckLog(dir)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		report(len(problems) == 0, "%d of %d evidence records are intact", count-len(problems), count)
+		for _, p := range problems {
+			fmt.Printf("   %s\n", p)
+		}
+	}
+
+	if failed {
+		return fmt.Errorf("verification failed")
+	}
+	fmt.Println("✅ Export folder verified")
+	return nil
+}
@@ -0,0 +1,227 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gnomegl/teleslurp/internal/config"
+	"github.com/gnomegl/teleslurp/internal/database"
+	"github.com/gnomegl/teleslurp/internal/telegram"
+	"github.com/spf13/cobra"
+)
+
+var (
+	watchAPIID    int
+	watchAPIHash  string
+	watchNoPrompt bool
+)
+
+func init() {
+	watchCmd := &cobra.Command{
+		Use:   "watch",
+		Short: "Manage what the monitor watches",
+	}
+
+	userCmd := &cobra.Command{
+		Use:   "user",
+		Short: "Manage the users whose status the monitor watches",
+		Long: `Manage the users whose status the monitor watches, stored in the database.
+They are watched in addition to monitor_users in the monitor config, and a
+running monitor picks up changes within a few seconds.`,
+	}
+
+	addUserCmd := &cobra.Command{
+		Use:   "add [username|user-id...]",
+		Short: "Watch users",
+		Long: `Watch users. Usernames are resolved through Telegram to their user ID; numeric
+user IDs are stored as they are.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: runWatchUserAdd,
+	}
+
+	addUserCmd.Flags().IntVar(&watchAPIID, "api-id", 0, "Telegram API ID")
+	addUserCmd.Flags().StringVar(&watchAPIHash, "api-hash", "", "Telegram API Hash")
+	addUserCmd.Flags().BoolVar(&watchNoPrompt, "no-prompt", false, "Disable interactive prompts")
+
+	removeUserCmd := &cobra.Command{
+		Use:   "remove [username|user-id...]",
+		Short: "Stop watching users",
+		Args:  cobra.MinimumNArgs(1),
+		RunE:  runWatchUserRemove,
+	}
+
+	listUserCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List watched users",
+		RunE:  runWatchUserList,
+	}
+
+	userCmd.AddCommand(addUserCmd, removeUserCmd, listUserCmd)
+	watchCmd.AddCommand(userCmd)
+	rootCmd.AddCommand(watchCmd)
+}
+
+func runWatchUserAdd(cmd *cobra.Command, args []string) error {
+	var ids []int64
+	var usernames []string
+	for _, arg := range args {
+		if id, err := strconv.ParseInt(arg, 10, 64); err == nil {
+			ids = append(ids, id)
+		} else {
+			usernames = append(usernames, strings.TrimPrefix(arg, "@"))
+		}
+	}
+
+	// Initialize database
+	db, err := database.New(config.GetDatabaseDSN())
+	if err != nil {
+		return fmt.Errorf("error initializing database: %w", err)
+	}
+	defer db.Close()
+
+	for _, id := range ids {
+		if err := db.AddMonitoredUser(id, "", "", ""); err != nil {
+			return fmt.Errorf("error adding user %d: %w", id, err)
+		}
+		fmt.Printf("👁️  Watching user ID %d\n", id)
+	}
+
+	if len(usernames) == 0 {
+		return nil
+	}
+
+	client, err := newWatchClient()
+	if err != nil {
+		return err
+	}
+
+	return client.RunWithContext(context.Background(), func(ctx context.Context) error {
+		for _, username := range usernames {
+			user, err := client.LookupUser(ctx, username)
+			if err != nil {
+				fmt.Printf("Warning: Could not resolve user %s: %v\n", username, err)
+				continue
+			}
+			if err := db.AddMonitoredUser(user.ID, user.Username, user.FirstName, user.LastName); err != nil {
+				return fmt.Errorf("error adding user @%s: %w", user.Username, err)
+			}
+			fmt.Printf("👁️  Watching @%s (ID: %d)\n", user.Username, user.ID)
+		}
+		return nil
+	})
+}
+
+// newWatchClient creates a Telegram client for resolving usernames
+func newWatchClient() (*telegram.Client, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, fmt.Errorf("error loading config: %w", err)
+	}
+
+	if cfg == nil {
+		cfg = &config.Config{}
+	}
+
+	if watchAPIID != 0 {
+		cfg.TGAPIID = watchAPIID
+	}
+	if watchAPIHash != "" {
+		cfg.TGAPIHash = watchAPIHash
+	}
+
+	if !watchNoPrompt {
+		if cfg.TGAPIID == 0 || cfg.TGAPIHash == "" {
+			cfg.TGAPIID, cfg.TGAPIHash = promptTGCredentials()
+		}
+	}
+
+	if cfg.TGAPIID == 0 || cfg.TGAPIHash == "" {
+		return nil, fmt.Errorf("missing required Telegram credentials to resolve usernames. Use flags, enable prompts or pass user IDs")
+	}
+
+	return telegram.NewClient(cfg), nil
+}
+
+func runWatchUserRemove(cmd *cobra.Command, args []string) error {
+	// Initialize database
+	db, err := database.New(config.GetDatabaseDSN())
+	if err != nil {
+		return fmt.Errorf("error initializing database: %w", err)
+	}
+	defer db.Close()
+
+	users, err := db.GetMonitoredUsers()
+	if err != nil {
+		return fmt.Errorf("error getting watched users: %w", err)
+	}
+
+	for _, arg := range args {
+		user, ok := findMonitoredUser(users, arg)
+		if !ok {
+			fmt.Printf("Warning: %s is not being watched\n", arg)
+			continue
+		}
+		if err := db.RemoveMonitoredUser(user.UserID); err != nil {
+			return fmt.Errorf("error removing user %s: %w", arg, err)
+		}
+		fmt.Printf("🗑️  Stopped watching %s\n", formatMonitoredUser(user))
+	}
+
+	return nil
+}
+
+// findMonitoredUser finds a watched user by user ID or username
+func findMonitoredUser(users []database.MonitoredUser, query string) (database.MonitoredUser, bool) {
+	id, idErr := strconv.ParseInt(query, 10, 64)
+	username := strings.TrimPrefix(query, "@")
+
+	for _, u := range users {
+		if idErr == nil && u.UserID == id {
+			return u, true
+		}
+		if idErr != nil && u.Username != "" && strings.EqualFold(u.Username, username) {
+			return u, true
+		}
+	}
+	return database.MonitoredUser{}, false
+}
+
+func runWatchUserList(cmd *cobra.Command, args []string) error {
+	// Initialize database
+	db, err := database.New(config.GetDatabaseDSN())
+	if err != nil {
+		return fmt.Errorf("error initializing database: %w", err)
+	}
+	defer db.Close()
+
+	users, err := db.GetMonitoredUsers()
+	if err != nil {
+		return fmt.Errorf("error getting watched users: %w", err)
+	}
+
+	if len(users) == 0 {
+		fmt.Println("No users watched")
+		return nil
+	}
+
+	fmt.Println("Watched Users:")
+	fmt.Println("==============")
+	for _, u := range users {
+		name := strings.TrimSpace(u.FirstName + " " + u.LastName)
+		if name == "" {
+			name = "-"
+		}
+		fmt.Printf("%s | Name: %s | Added: %s\n", formatMonitoredUser(u), name, u.AddedAt)
+	}
+
+	return nil
+}
+
+func formatMonitoredUser(u database.MonitoredUser) string {
+	if u.Username == "" {
+		return fmt.Sprintf("ID: %d", u.UserID)
+	}
+	return fmt.Sprintf("@%s (ID: %d)", u.Username, u.UserID)
+}
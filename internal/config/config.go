@@ -1,12 +1,17 @@
 package config
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/fsnotify/fsnotify"
 	"gopkg.in/yaml.v3"
 )
 
@@ -15,6 +20,12 @@ type Config struct {
 	TGAPIID     int    `json:"tg_api_id,omitempty"`
 	TGAPIHash   string `json:"tg_api_hash,omitempty"`
 	PhoneNumber string `json:"phone_number,omitempty"`
+	DatabaseDSN string `json:"database_dsn,omitempty"`
+	// Retention maps table names to how long rows are kept, e.g. "30d"
+	Retention map[string]string `json:"retention,omitempty"`
+	// Timezone is the zone times are displayed in, e.g. "UTC" or
+	// "Europe/Berlin"; the machine's zone when empty
+	Timezone string `json:"timezone,omitempty"`
 }
 
 type MonitorSource struct {
@@ -58,6 +69,70 @@ func GetDatabasePath() string {
 	return filepath.Join(GetConfigDir(), "teleslurp.db")
 }
 
+// RetentionPolicy parses the configured retention periods
+func (c *Config) RetentionPolicy() (map[string]time.Duration, error) {
+	policy := make(map[string]time.Duration, len(c.Retention))
+	for table, value := range c.Retention {
+		d, err := ParseRetention(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid retention for %s: %w", table, err)
+		}
+		policy[table] = d
+	}
+	return policy, nil
+}
+
+// ParseRetention parses a retention period. Besides Go durations such as
+// "12h" it accepts whole days, weeks and years: "30d", "8w", "1y".
+func ParseRetention(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	units := map[byte]time.Duration{
+		'd': 24 * time.Hour,
+		'w': 7 * 24 * time.Hour,
+		'y': 365 * 24 * time.Hour,
+	}
+	if len(value) > 1 {
+		if unit, ok := units[value[len(value)-1]]; ok {
+			n, err := strconv.Atoi(value[:len(value)-1])
+			if err != nil || n < 0 {
+				return 0, fmt.Errorf("invalid period %q", value)
+			}
+			return time.Duration(n) * unit, nil
+		}
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid period %q", value)
+	}
+	return d, nil
+}
+
+// GetDatabaseDSN returns the database to use: TELESLURP_DATABASE_DSN when set,
+// then database_dsn from the config file, otherwise the SQLite database in the
+// config directory
+func GetDatabaseDSN() string {
+	if dsn := os.Getenv("TELESLURP_DATABASE_DSN"); dsn != "" {
+		return dsn
+	}
+	if cfg, err := Load(); err == nil && cfg != nil && cfg.DatabaseDSN != "" {
+		return cfg.DatabaseDSN
+	}
+	return GetDatabasePath()
+}
+
+// GetTimezone returns the display time zone: TELESLURP_TIMEZONE when set,
+// then timezone from the config file, otherwise "" for local time
+func GetTimezone() string {
+	if tz := os.Getenv("TELESLURP_TIMEZONE"); tz != "" {
+		return tz
+	}
+	if cfg, err := Load(); err == nil && cfg != nil {
+		return cfg.Timezone
+	}
+	return ""
+}
+
 func GetMonitorConfigPath() string {
 	return filepath.Join(GetConfigDir(), "monitor.config.yaml")
 }
@@ -99,8 +174,11 @@ func Save(config *Config) error {
 }
 
 func LoadMonitorConfig() (*MonitorConfig, error) {
-	configPath := GetMonitorConfigPath()
+	return LoadMonitorConfigFrom(GetMonitorConfigPath())
+}
 
+// LoadMonitorConfigFrom loads a monitor config from the given path
+func LoadMonitorConfigFrom(configPath string) (*MonitorConfig, error) {
 	if _, err := os.Stat(configPath); os.IsNotExist(err) {
 		return nil, fmt.Errorf("monitor config file not found: %s", configPath)
 	}
@@ -133,3 +211,54 @@ func SaveMonitorConfig(config *MonitorConfig) error {
 
 	return os.WriteFile(configPath, data, 0644)
 }
+
+// WatchMonitorConfig watches the monitor config file and calls onChange with
+// the freshly parsed config whenever it is modified. The parent directory is
+// watched so editors that replace the file on save are handled. It blocks
+// until the context is cancelled.
+func WatchMonitorConfig(ctx context.Context, configPath string, onChange func(*MonitorConfig)) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("error creating config watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	configPath = filepath.Clean(configPath)
+	if err := watcher.Add(filepath.Dir(configPath)); err != nil {
+		return fmt.Errorf("error watching config directory: %w", err)
+	}
+
+	// Editors often emit several events per save, so wait for them to settle
+	const debounce = 500 * time.Millisecond
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if filepath.Clean(event.Name) != configPath {
+				continue
+			}
+			if event.Has(fsnotify.Write) || event.Has(fsnotify.Create) || event.Has(fsnotify.Rename) {
+				timer.Reset(debounce)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			fmt.Printf("Warning: Config watcher error: %v\n", err)
+		case <-timer.C:
+			cfg, err := LoadMonitorConfigFrom(configPath)
+			if err != nil {
+				fmt.Printf("Warning: Ignoring invalid monitor config change: %v\n", err)
+				continue
+			}
+			onChange(cfg)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package database
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gnomegl/teleslurp/internal/indicators"
+)
+
+const (
+	// batchSize is the most writes a BatchWriter commits in one transaction
+	batchSize = 100
+	// batchDelay is how long a BatchWriter waits to fill a batch
+	batchDelay = 250 * time.Millisecond
+)
+
+type batchOp func(e execer) error
+
+// BatchWriter is a Store that queues the monitor's high-volume writes
+// (messages, indicators, status updates, sightings and filter matches) and commits them
+// from a single goroutine in batched transactions. Everything else goes
+// straight to the underlying DB.
+type BatchWriter struct {
+	*DB
+
+	ops  chan batchOp
+	done chan struct{}
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+var _ Store = (*BatchWriter)(nil)
+
+// NewBatchWriter starts a batch writer on db. Close must be called to flush
+// pending writes; it does not close db.
+func NewBatchWriter(db *DB) *BatchWriter {
+	w := &BatchWriter{
+		DB:   db,
+		ops:  make(chan batchOp, batchSize*4),
+		done: make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// SaveMessage queues a message write
+func (w *BatchWriter) SaveMessage(channelID int64, channelTitle, channelUsername string, messageID int, senderID int64, date, message, url string) error {
+	return w.enqueue(func(e execer) error {
+		return w.saveMessage(e, channelID, channelTitle, channelUsername, messageID, senderID, date, message, url)
+	})
+}
+
+// SaveIndicators queues the indicators of a message
+func (w *BatchWriter) SaveIndicators(channelID int64, messageID int, inds []indicators.Indicator) error {
+	return w.enqueue(func(e execer) error {
+		return w.saveIndicators(e, channelID, messageID, inds)
+	})
+}
+
+// SaveUserStatusUpdate queues a user status update
+func (w *BatchWriter) SaveUserStatusUpdate(u UserStatusUpdate) error {
+	return w.enqueue(func(e execer) error {
+		return w.saveUserStatusUpdate(e, u)
+	})
+}
+
+// SaveUserSighting queues a watched user sighting
+func (w *BatchWriter) SaveUserSighting(s UserSighting) error {
+	return w.enqueue(func(e execer) error {
+		return w.saveUserSighting(e, s)
+	})
+}
+
+// RecordFilterMatch queues a filter match count update
+func (w *BatchWriter) RecordFilterMatch(filterID int) error {
+	return w.enqueue(func(e execer) error {
+		return w.recordFilterMatch(e, filterID)
+	})
+}
+
+// SaveFilterAudit queues a filter audit entry
+func (w *BatchWriter) SaveFilterAudit(channelID int64, messageID int, userID int64, filterID int, filterName, action string) error {
+	return w.enqueue(func(e execer) error {
+		return w.saveFilterAudit(e, channelID, messageID, userID, filterID, filterName, action)
+	})
+}
+
+// enqueue hands op to the writer goroutine, or runs it directly once the
+// writer is closed. Errors of queued writes are reported by the writer.
+func (w *BatchWriter) enqueue(op batchOp) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.closed {
+		return op(w.DB.db)
+	}
+	w.ops <- op
+	return nil
+}
+
+// Close flushes pending writes and stops the writer goroutine
+func (w *BatchWriter) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	close(w.ops)
+	w.mu.Unlock()
+
+	<-w.done
+	return nil
+}
+
+func (w *BatchWriter) run() {
+	defer close(w.done)
+
+	batch := make([]batchOp, 0, batchSize)
+	timer := time.NewTimer(batchDelay)
+	timer.Stop()
+
+	for {
+		select {
+		case op, ok := <-w.ops:
+			if !ok {
+				w.flush(batch)
+				return
+			}
+			if len(batch) == 0 {
+				timer.Reset(batchDelay)
+			}
+			batch = append(batch, op)
+			if len(batch) < batchSize {
+				continue
+			}
+			timer.Stop()
+		case <-timer.C:
+		}
+
+		w.flush(batch)
+		batch = batch[:0]
+	}
+}
+
+// flush commits a batch in one transaction. If that fails the writes are
+// retried one by one so a single bad row doesn't drop the rest.
+func (w *BatchWriter) flush(batch []batchOp) {
+	if len(batch) == 0 {
+		return
+	}
+
+	err := w.commit(batch)
+	if err == nil {
+		return
+	}
+	fmt.Printf("Warning: Batched database write failed, retrying individually: %v\n", err)
+
+	for _, op := range batch {
+		if err := op(w.DB.db); err != nil {
+			fmt.Printf("Warning: Database write failed: %v\n", err)
+		}
+	}
+}
+
+func (w *BatchWriter) commit(batch []batchOp) error {
+	tx, err := w.DB.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, op := range batch {
+		if err := op(tx); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
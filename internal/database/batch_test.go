@@ -0,0 +1,177 @@
+package database
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// captureStdout returns what f printed, where BatchWriter reports failed
+// batches
+func captureStdout(t *testing.T, f func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	var out bytes.Buffer
+	copied := make(chan struct{})
+	go func() {
+		io.Copy(&out, r)
+		close(copied)
+	}()
+
+	f()
+	w.Close()
+	<-copied
+	return out.String()
+}
+
+// TestConcurrentWriters runs a monitor-like write load through two batch
+// writers on separate handles of one SQLite file, as a monitor and a
+// command in another process would, while readers query it
+func TestConcurrentWriters(t *testing.T) {
+	const (
+		goroutines  = 8
+		iterations  = 60
+		filterEvery = 10
+		readers     = 4
+	)
+
+	path := filepath.Join(t.TempDir(), "teleslurp.db")
+	var handles [2]*DB
+	for i := range handles {
+		d, err := New(path)
+		if err != nil {
+			t.Fatalf("New: %v", err)
+		}
+		defer d.Close()
+		handles[i] = d
+	}
+
+	var errs []error
+	var errMu sync.Mutex
+	report := func(err error) {
+		if err != nil {
+			errMu.Lock()
+			errs = append(errs, err)
+			errMu.Unlock()
+		}
+	}
+
+	output := captureStdout(t, func() {
+		writers := [2]*BatchWriter{NewBatchWriter(handles[0]), NewBatchWriter(handles[1])}
+
+		done := make(chan struct{})
+		var readersWG sync.WaitGroup
+		for r := 0; r < readers; r++ {
+			readersWG.Add(1)
+			go func(d *DB) {
+				defer readersWG.Done()
+				for {
+					select {
+					case <-done:
+						return
+					default:
+					}
+					_, err := d.GetActiveFilters()
+					report(err)
+					_, err = d.GetUserStatusHistory(1, "")
+					report(err)
+					report(d.ExportMessages(ExportQuery{}, func(StoredMessage) error { return nil }))
+				}
+			}(handles[r%2])
+		}
+
+		var writersWG sync.WaitGroup
+		for g := 0; g < goroutines; g++ {
+			writersWG.Add(1)
+			go func(g int, w *BatchWriter) {
+				defer writersWG.Done()
+				for i := 0; i < iterations; i++ {
+					report(w.SaveMessage(int64(g), "Chan", "chan", i, int64(g), "2024-05-01T14:03:22Z", "message", ""))
+					report(w.SaveUserStatusUpdate(UserStatusUpdate{
+						UserID:     int64(g),
+						Status:     "online",
+						State:      StatusOnline,
+						StatusTime: fmt.Sprintf("2024-05-01T14:%02d:%02dZ", i/60, i%60),
+					}))
+					if i%filterEvery == 0 {
+						// Goes straight to the database, competing with the
+						// other handle's batches for the write lock
+						report(w.AddMessageFilter(fmt.Sprintf("f%d-%d", g, i), "x", "keyword", "forward", 0))
+					}
+				}
+			}(g, writers[g%2])
+		}
+		writersWG.Wait()
+
+		// Close must commit everything still queued
+		for _, w := range writers {
+			report(w.Close())
+		}
+		close(done)
+		readersWG.Wait()
+	})
+
+	for _, err := range errs {
+		t.Error(err)
+	}
+	if strings.Contains(output, "locked") || strings.Contains(output, "Warning") {
+		t.Errorf("batch writes failed:\n%s", output)
+	}
+
+	d := handles[0]
+	for _, tc := range []struct {
+		table string
+		want  int
+	}{
+		{"messages", goroutines * iterations},
+		{"user_status_updates", goroutines * iterations},
+		{"message_filters", goroutines * iterations / filterEvery},
+	} {
+		if n := count(t, d, "SELECT COUNT(*) FROM "+tc.table); n != tc.want {
+			t.Errorf("%s has %d rows, want %d", tc.table, n, tc.want)
+		}
+	}
+}
+
+// TestBatchWriterRetriesIndividually checks that one failing write doesn't
+// lose the rest of its batch
+func TestBatchWriterRetriesIndividually(t *testing.T) {
+	d := newTestDB(t)
+	w := NewBatchWriter(d)
+
+	output := captureStdout(t, func() {
+		must(t, w.SaveMessage(1, "Chan", "chan", 1, 0, "2024-05-01T14:03:22Z", "first", ""))
+		// channel_title is NOT NULL, so this write fails the batch
+		must(t, w.enqueue(func(e execer) error {
+			_, err := d.execOn(e, "INSERT INTO messages (channel_id, channel_title, message_id, date) VALUES (?, NULL, ?, ?)", 1, 2, "2024-05-01T14:03:22Z")
+			return err
+		}))
+		must(t, w.SaveMessage(1, "Chan", "chan", 3, 0, "2024-05-01T14:03:22Z", "third", ""))
+		must(t, w.Close())
+	})
+
+	if !strings.Contains(output, "retrying individually") {
+		t.Errorf("failed batch not reported:\n%s", output)
+	}
+	if n := count(t, d, "SELECT COUNT(*) FROM messages"); n != 2 {
+		t.Errorf("got %d messages, want the 2 valid ones", n)
+	}
+
+	// Writes after Close go straight to the database
+	must(t, w.SaveMessage(1, "Chan", "chan", 4, 0, "2024-05-01T14:03:22Z", "fourth", ""))
+	if n := count(t, d, "SELECT COUNT(*) FROM messages"); n != 3 {
+		t.Errorf("write after Close not stored, got %d messages", n)
+	}
+}
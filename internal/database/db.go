@@ -3,221 +3,317 @@ package database
 import (
 	"database/sql"
 	"fmt"
+
+	"github.com/gnomegl/teleslurp/internal/indicators"
+	_ "github.com/lib/pq"
 	_ "github.com/mattn/go-sqlite3"
 )
 
+// DB is the SQLite or PostgreSQL database, depending on the DSN it was opened with
 type DB struct {
+	// db takes all writes. For SQLite it holds a single connection so
+	// writers queue in Go instead of fighting over the file lock.
 	db *sql.DB
+	// reader serves queries. For SQLite it is a separate pool of read-only
+	// connections that WAL lets run alongside the writer; for PostgreSQL it
+	// is the same pool as db.
+	reader  *sql.DB
+	dialect string
+	fts     bool
+}
+
+// execer is implemented by both *sql.DB and *sql.Tx
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
 }
 
-func New(dbPath string) (*DB, error) {
-	db, err := sql.Open("sqlite3", dbPath)
+// New opens the database and applies any pending schema migrations
+func New(dsn string) (*DB, error) {
+	d, err := Open(dsn)
 	if err != nil {
-		return nil, fmt.Errorf("error opening database: %w", err)
+		return nil, err
 	}
 
-	if err := db.Ping(); err != nil {
-		return nil, fmt.Errorf("error connecting to database: %w", err)
+	if _, err := d.Migrate(); err != nil {
+		d.Close()
+		return nil, fmt.Errorf("error migrating database: %w", err)
 	}
 
-	if err := createTables(db); err != nil {
-		return nil, fmt.Errorf("error creating tables: %w", err)
+	if err := d.ensureFullTextSearch(); err != nil {
+		d.Close()
+		return nil, fmt.Errorf("error setting up full-text search: %w", err)
 	}
 
-	return &DB{db: db}, nil
+	return d, nil
 }
 
-func createTables(db *sql.DB) error {
-	// Messages table
-	_, err := db.Exec(`
-		CREATE TABLE IF NOT EXISTS messages (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			channel_id INTEGER NOT NULL,
-			channel_title TEXT NOT NULL,
-			channel_username TEXT,
-			message_id INTEGER NOT NULL,
-			date DATETIME NOT NULL,
-			message TEXT,
-			url TEXT,
-			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-			UNIQUE(channel_id, message_id)
-		);
-	`)
-	if err != nil {
-		return err
-	}
-
-	// User status updates table
-	_, err = db.Exec(`
-		CREATE TABLE IF NOT EXISTS user_status_updates (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			user_id INTEGER NOT NULL,
-			username TEXT,
-			first_name TEXT,
-			last_name TEXT,
-			status TEXT NOT NULL,
-			status_time DATETIME NOT NULL,
-			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
-		);
-	`)
-	if err != nil {
-		return err
-	}
-
-	// Monitored users table
-	_, err = db.Exec(`
-		CREATE TABLE IF NOT EXISTS monitored_users (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			user_id INTEGER UNIQUE NOT NULL,
-			username TEXT,
-			first_name TEXT,
-			last_name TEXT,
-			added_at DATETIME DEFAULT CURRENT_TIMESTAMP
-		);
-	`)
+// Open opens the database without touching the schema. The DSN is either a
+// SQLite file path or a PostgreSQL connection string, see DialectFromDSN.
+func Open(dsn string) (*DB, error) {
+	dialect := DialectFromDSN(dsn)
+	if dialect == DialectPostgres {
+		db, err := openPool("postgres", dsn)
+		if err != nil {
+			return nil, err
+		}
+		return &DB{db: db, reader: db, dialect: dialect}, nil
+	}
+
+	writerDSN, readerDSN, err := sqliteDSNs(dsn)
 	if err != nil {
-		return err
-	}
-
-	// Channel metadata table
-	_, err = db.Exec(`
-		CREATE TABLE IF NOT EXISTS channel_metadata (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			channel_id INTEGER UNIQUE NOT NULL,
-			title TEXT NOT NULL,
-			username TEXT,
-			member_count INTEGER,
-			is_public BOOLEAN,
-			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
-		);
-	`)
+		return nil, fmt.Errorf("error preparing database: %w", err)
+	}
+
+	// The writer is opened first so it can switch the file to WAL
+	writer, err := openPool("sqlite3", writerDSN)
 	if err != nil {
-		return err
-	}
-
-	// Message filters table
-	_, err = db.Exec(`
-		CREATE TABLE IF NOT EXISTS message_filters (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			name TEXT NOT NULL,
-			pattern TEXT NOT NULL,
-			type TEXT NOT NULL, -- 'keyword', 'regex', 'user', 'channel'
-			action TEXT NOT NULL, -- 'forward', 'ignore', 'highlight'
-			priority INTEGER DEFAULT 0,
-			enabled BOOLEAN DEFAULT 1,
-			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
-		);
-	`)
+		return nil, err
+	}
+	writer.SetMaxOpenConns(1)
+
+	reader, err := openPool("sqlite3", readerDSN)
 	if err != nil {
-		return err
+		writer.Close()
+		return nil, err
 	}
+	reader.SetMaxOpenConns(sqliteReaders)
+
+	return &DB{db: writer, reader: reader, dialect: dialect}, nil
+}
 
-	// Create indices for better performance
-	indices := []string{
-		"CREATE INDEX IF NOT EXISTS idx_messages_channel_id ON messages(channel_id);",
-		"CREATE INDEX IF NOT EXISTS idx_messages_date ON messages(date);",
-		"CREATE INDEX IF NOT EXISTS idx_user_status_user_id ON user_status_updates(user_id);",
-		"CREATE INDEX IF NOT EXISTS idx_user_status_time ON user_status_updates(status_time);",
-		"CREATE INDEX IF NOT EXISTS idx_filters_type ON message_filters(type);",
-		"CREATE INDEX IF NOT EXISTS idx_filters_enabled ON message_filters(enabled);",
+func openPool(driver, source string) (*sql.DB, error) {
+	db, err := sql.Open(driver, source)
+	if err != nil {
+		return nil, fmt.Errorf("error opening database: %w", err)
 	}
 
-	for _, idx := range indices {
-		if _, err := db.Exec(idx); err != nil {
-			return err
-		}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error connecting to database: %w", err)
 	}
+	return db, nil
+}
 
-	return nil
+// Dialect returns the backend in use, DialectSQLite or DialectPostgres
+func (d *DB) Dialect() string {
+	return d.dialect
 }
 
-func (d *DB) SaveMessage(channelID int64, channelTitle, channelUsername string, messageID int, date, message, url string) error {
-	_, err := d.db.Exec(`
-		INSERT OR IGNORE INTO messages (
-			channel_id, channel_title, channel_username, message_id, date, message, url
-		) VALUES (?, ?, ?, ?, ?, ?, ?)
-	`, channelID, channelTitle, channelUsername, messageID, date, message, url)
-	return err
+func (d *DB) exec(query string, args ...interface{}) (sql.Result, error) {
+	return d.db.Exec(d.rebind(query), args...)
 }
 
-// SaveUserStatusUpdate saves a user status update to the database
-func (d *DB) SaveUserStatusUpdate(userID int64, username, firstName, lastName, status, statusTime string) error {
-	_, err := d.db.Exec(`
-		INSERT INTO user_status_updates (
-			user_id, username, first_name, last_name, status, status_time
-		) VALUES (?, ?, ?, ?, ?, ?)
-	`, userID, username, firstName, lastName, status, statusTime)
+func (d *DB) execOn(e execer, query string, args ...interface{}) (sql.Result, error) {
+	return e.Exec(d.rebind(query), args...)
+}
+
+func (d *DB) query(query string, args ...interface{}) (*sql.Rows, error) {
+	return d.reader.Query(d.rebind(query), args...)
+}
+
+func (d *DB) queryRow(query string, args ...interface{}) *sql.Row {
+	return d.reader.QueryRow(d.rebind(query), args...)
+}
+
+// SaveMessage stores a message, filling in its sender if it was saved before
+// without one
+func (d *DB) SaveMessage(channelID int64, channelTitle, channelUsername string, messageID int, senderID int64, date, message, url string) error {
+	return d.saveMessage(d.db, channelID, channelTitle, channelUsername, messageID, senderID, date, message, url)
+}
+
+func (d *DB) saveMessage(e execer, channelID int64, channelTitle, channelUsername string, messageID int, senderID int64, date, message, url string) error {
+	var sender interface{}
+	if senderID != 0 {
+		sender = senderID
+	}
+	_, err := d.execOn(e, `
+		INSERT INTO messages (
+			channel_id, channel_title, channel_username, message_id, sender_id, date, message, url, created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(channel_id, message_id) DO UPDATE SET
+			sender_id = COALESCE(messages.sender_id, excluded.sender_id)
+	`, channelID, channelTitle, channelUsername, messageID, sender, date, message, url, timestamp())
 	return err
 }
 
+// SaveIndicators saves the indicators extracted from a message
+func (d *DB) SaveIndicators(channelID int64, messageID int, inds []indicators.Indicator) error {
+	return d.saveIndicators(d.db, channelID, messageID, inds)
+}
+
+func (d *DB) saveIndicators(e execer, channelID int64, messageID int, inds []indicators.Indicator) error {
+	for _, ind := range inds {
+		if _, err := d.execOn(e, `
+			INSERT INTO indicators (
+				channel_id, message_id, type, value, created_at
+			) VALUES (?, ?, ?, ?, ?)
+			ON CONFLICT DO NOTHING
+		`, channelID, messageID, ind.Type, ind.Value, timestamp()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // AddMonitoredUser adds a user to the monitored users list
 func (d *DB) AddMonitoredUser(userID int64, username, firstName, lastName string) error {
-	_, err := d.db.Exec(`
-		INSERT OR REPLACE INTO monitored_users (
-			user_id, username, first_name, last_name
-		) VALUES (?, ?, ?, ?)
-	`, userID, username, firstName, lastName)
+	_, err := d.exec(`
+		INSERT INTO monitored_users (
+			user_id, username, first_name, last_name, added_at
+		) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET
+			username = excluded.username,
+			first_name = excluded.first_name,
+			last_name = excluded.last_name
+	`, userID, username, firstName, lastName, timestamp())
 	return err
 }
 
 // RemoveMonitoredUser removes a user from the monitored users list
 func (d *DB) RemoveMonitoredUser(userID int64) error {
-	_, err := d.db.Exec("DELETE FROM monitored_users WHERE user_id = ?", userID)
+	_, err := d.exec("DELETE FROM monitored_users WHERE user_id = ?", userID)
 	return err
 }
 
-// GetMonitoredUsers retrieves all monitored users
-func (d *DB) GetMonitoredUsers() ([]map[string]interface{}, error) {
-	rows, err := d.db.Query("SELECT user_id, username, first_name, last_name FROM monitored_users")
+// GetMonitoredUsers retrieves all monitored users, oldest first
+func (d *DB) GetMonitoredUsers() ([]MonitoredUser, error) {
+	rows, err := d.query(`
+		SELECT user_id, COALESCE(username, ''), COALESCE(first_name, ''),
+			COALESCE(last_name, ''), COALESCE(added_at, '')
+		FROM monitored_users
+		ORDER BY id
+	`)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var users []map[string]interface{}
+	var users []MonitoredUser
 	for rows.Next() {
-		var userID int64
-		var username, firstName, lastName sql.NullString
-		if err := rows.Scan(&userID, &username, &firstName, &lastName); err != nil {
+		var u MonitoredUser
+		if err := rows.Scan(&u.UserID, &u.Username, &u.FirstName, &u.LastName, &u.AddedAt); err != nil {
 			return nil, err
 		}
-		user := map[string]interface{}{
-			"user_id":    userID,
-			"username":   username.String,
-			"first_name": firstName.String,
-			"last_name":  lastName.String,
-		}
-		users = append(users, user)
+		users = append(users, u)
 	}
-	return users, nil
+	return users, rows.Err()
 }
 
 // SaveChannelMetadata saves or updates channel metadata
 func (d *DB) SaveChannelMetadata(channelID int64, title, username string, memberCount int, isPublic bool) error {
-	_, err := d.db.Exec(`
-		INSERT OR REPLACE INTO channel_metadata (
+	_, err := d.exec(`
+		INSERT INTO channel_metadata (
 			channel_id, title, username, member_count, is_public, updated_at
-		) VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
-	`, channelID, title, username, memberCount, isPublic)
+		) VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(channel_id) DO UPDATE SET
+			title = excluded.title,
+			username = excluded.username,
+			member_count = excluded.member_count,
+			is_public = excluded.is_public,
+			updated_at = excluded.updated_at
+	`, channelID, title, username, memberCount, isPublic, timestamp())
 	return err
 }
 
 // AddMessageFilter adds a new message filter
 func (d *DB) AddMessageFilter(name, pattern, filterType, action string, priority int) error {
-	_, err := d.db.Exec(`
-		INSERT INTO message_filters (
-			name, pattern, type, action, priority
-		) VALUES (?, ?, ?, ?, ?)
-	`, name, pattern, filterType, action, priority)
+	_, err := d.AddMessageFilterWithOptions(name, pattern, filterType, action, priority, nil)
 	return err
 }
 
+// AddMessageFilterWithOptions adds a new message filter along with its options
+// and returns the new filter ID
+func (d *DB) AddMessageFilterWithOptions(name, pattern, filterType, action string, priority int, options map[string]string) (int64, error) {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var filterID int64
+	if err := tx.QueryRow(d.rebind(`
+		INSERT INTO message_filters (
+			name, pattern, type, action, priority, created_at
+		) VALUES (?, ?, ?, ?, ?, ?)
+		RETURNING id
+	`), name, pattern, filterType, action, priority, timestamp()).Scan(&filterID); err != nil {
+		return 0, err
+	}
+
+	for key, value := range options {
+		if _, err := tx.Exec(d.rebind(`
+			INSERT INTO filter_options (filter_id, name, value) VALUES (?, ?, ?)
+		`), filterID, key, value); err != nil {
+			return 0, err
+		}
+	}
+
+	return filterID, tx.Commit()
+}
+
+// AddFilterKeywords adds terms to a keyword list filter, skipping duplicates
+func (d *DB) AddFilterKeywords(filterID int64, terms []string) (int, error) {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(d.rebind("INSERT INTO filter_keywords (filter_id, term, created_at) VALUES (?, ?, ?) ON CONFLICT DO NOTHING"))
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	added, now := 0, timestamp()
+	for _, term := range terms {
+		res, err := stmt.Exec(filterID, term, now)
+		if err != nil {
+			return 0, err
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			added++
+		}
+	}
+
+	return added, tx.Commit()
+}
+
+// RemoveFilterKeywords removes terms from a keyword list filter
+func (d *DB) RemoveFilterKeywords(filterID int64, terms []string) error {
+	for _, term := range terms {
+		if _, err := d.exec("DELETE FROM filter_keywords WHERE filter_id = ? AND term = ?", filterID, term); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetFilterKeywords retrieves the terms of a keyword list filter
+func (d *DB) GetFilterKeywords(filterID int64) ([]string, error) {
+	rows, err := d.query("SELECT term FROM filter_keywords WHERE filter_id = ? ORDER BY term", filterID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var terms []string
+	for rows.Next() {
+		var term string
+		if err := rows.Scan(&term); err != nil {
+			return nil, err
+		}
+		terms = append(terms, term)
+	}
+	return terms, rows.Err()
+}
+
 // GetActiveFilters retrieves all enabled filters
 func (d *DB) GetActiveFilters() ([]MessageFilter, error) {
-	rows, err := d.db.Query(`
+	rows, err := d.query(`
 		SELECT id, name, pattern, type, action, priority 
 		FROM message_filters 
-		WHERE enabled = 1
+		WHERE enabled = TRUE
 		ORDER BY priority DESC
 	`)
 	if err != nil {
@@ -233,51 +329,162 @@ func (d *DB) GetActiveFilters() ([]MessageFilter, error) {
 		}
 		filters = append(filters, filter)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	options, err := d.getFilterOptions()
+	if err != nil {
+		return nil, err
+	}
+	for i := range filters {
+		filters[i].Options = options[filters[i].ID]
+	}
 	return filters, nil
 }
 
+// getFilterOptions returns the options of all filters keyed by filter ID
+func (d *DB) getFilterOptions() (map[int]map[string]string, error) {
+	rows, err := d.query("SELECT filter_id, name, value FROM filter_options")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	options := make(map[int]map[string]string)
+	for rows.Next() {
+		var filterID int
+		var name, value string
+		if err := rows.Scan(&filterID, &name, &value); err != nil {
+			return nil, err
+		}
+		if options[filterID] == nil {
+			options[filterID] = make(map[string]string)
+		}
+		options[filterID][name] = value
+	}
+	return options, rows.Err()
+}
+
 // DisableFilter disables a message filter
 func (d *DB) DisableFilter(filterID int) error {
-	_, err := d.db.Exec("UPDATE message_filters SET enabled = 0 WHERE id = ?", filterID)
+	_, err := d.exec("UPDATE message_filters SET enabled = FALSE WHERE id = ?", filterID)
 	return err
 }
 
 // EnableFilter enables a message filter
 func (d *DB) EnableFilter(filterID int) error {
-	_, err := d.db.Exec("UPDATE message_filters SET enabled = 1 WHERE id = ?", filterID)
+	_, err := d.exec("UPDATE message_filters SET enabled = TRUE WHERE id = ?", filterID)
 	return err
 }
 
-// GetUserStatusHistory gets status history for a specific user
-func (d *DB) GetUserStatusHistory(userID int64, limit int) ([]map[string]interface{}, error) {
-	rows, err := d.db.Query(`
-		SELECT username, first_name, last_name, status, status_time 
-		FROM user_status_updates 
-		WHERE user_id = ?
-		ORDER BY status_time DESC
-		LIMIT ?
-	`, userID, limit)
+// RecordFilterMatch increments the match counter of a filter
+func (d *DB) RecordFilterMatch(filterID int) error {
+	return d.recordFilterMatch(d.db, filterID)
+}
+
+func (d *DB) recordFilterMatch(e execer, filterID int) error {
+	_, err := d.execOn(e, `
+		INSERT INTO filter_stats (filter_id, match_count, last_match_at)
+		VALUES (?, 1, ?)
+		ON CONFLICT(filter_id) DO UPDATE SET
+			match_count = filter_stats.match_count + 1,
+			last_match_at = excluded.last_match_at
+	`, filterID, timestamp())
+	return err
+}
+
+// SaveFilterAudit records which filter decided the fate of a message.
+// A filterID of 0 means no filter matched and the default action was used.
+func (d *DB) SaveFilterAudit(channelID int64, messageID int, userID int64, filterID int, filterName, action string) error {
+	return d.saveFilterAudit(d.db, channelID, messageID, userID, filterID, filterName, action)
+}
+
+func (d *DB) saveFilterAudit(e execer, channelID int64, messageID int, userID int64, filterID int, filterName, action string) error {
+	var id interface{}
+	if filterID != 0 {
+		id = filterID
+	}
+	_, err := d.execOn(e, `
+		INSERT INTO filter_audit (
+			channel_id, message_id, user_id, filter_id, filter_name, action, created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, channelID, messageID, userID, id, filterName, action, timestamp())
+	return err
+}
+
+// GetFilterStats retrieves match statistics for all filters, including disabled ones
+func (d *DB) GetFilterStats() ([]FilterStats, error) {
+	rows, err := d.query(`
+		SELECT f.id, f.name, f.type, f.action, f.enabled,
+			COALESCE(s.match_count, 0), s.last_match_at
+		FROM message_filters f
+		LEFT JOIN filter_stats s ON s.filter_id = f.id
+		ORDER BY f.priority DESC, f.id
+	`)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var history []map[string]interface{}
+	var stats []FilterStats
 	for rows.Next() {
-		var username, firstName, lastName, status, statusTime sql.NullString
-		if err := rows.Scan(&username, &firstName, &lastName, &status, &statusTime); err != nil {
+		var st FilterStats
+		var lastMatch sql.NullString
+		if err := rows.Scan(&st.ID, &st.Name, &st.Type, &st.Action, &st.Enabled, &st.MatchCount, &lastMatch); err != nil {
 			return nil, err
 		}
-		entry := map[string]interface{}{
-			"username":    username.String,
-			"first_name":  firstName.String,
-			"last_name":   lastName.String,
-			"status":      status.String,
-			"status_time": statusTime.String,
+		st.LastMatchAt = lastMatch.String
+		stats = append(stats, st)
+	}
+	return stats, rows.Err()
+}
+
+// GetFilterAudit retrieves audit rows, newest first. A zero channelID or
+// messageID matches any value.
+func (d *DB) GetFilterAudit(channelID int64, messageID int, limit int) ([]FilterAuditEntry, error) {
+	rows, err := d.query(`
+		SELECT channel_id, message_id, COALESCE(user_id, 0), COALESCE(filter_id, 0),
+			COALESCE(filter_name, ''), action, created_at
+		FROM filter_audit
+		WHERE (CAST(? AS BIGINT) = 0 OR channel_id = ?) AND (CAST(? AS BIGINT) = 0 OR message_id = ?)
+		ORDER BY id DESC
+		LIMIT ?
+	`, channelID, channelID, messageID, messageID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []FilterAuditEntry
+	for rows.Next() {
+		var e FilterAuditEntry
+		if err := rows.Scan(&e.ChannelID, &e.MessageID, &e.UserID, &e.FilterID, &e.FilterName, &e.Action, &e.CreatedAt); err != nil {
+			return nil, err
 		}
-		history = append(history, entry)
+		entries = append(entries, e)
 	}
-	return history, nil
+	return entries, rows.Err()
+}
+
+// GetChangeVersion returns the current change counter for a tracked table
+func (d *DB) GetChangeVersion(name string) (int64, error) {
+	var version int64
+	err := d.queryRow("SELECT version FROM change_versions WHERE name = ?", name).Scan(&version)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return version, err
+}
+
+// MonitoredUser is a user whose status the monitor watches in addition to
+// the monitor_users of the monitor config
+type MonitoredUser struct {
+	UserID    int64
+	Username  string
+	FirstName string
+	LastName  string
+	AddedAt   string
 }
 
 // MessageFilter represents a message filter
@@ -288,8 +495,34 @@ type MessageFilter struct {
 	Type     string
 	Action   string
 	Priority int
+	Options  map[string]string
+}
+
+// FilterStats holds match statistics for a filter
+type FilterStats struct {
+	ID          int
+	Name        string
+	Type        string
+	Action      string
+	Enabled     bool
+	MatchCount  int64
+	LastMatchAt string
+}
+
+// FilterAuditEntry records the filter decision for a single message
+type FilterAuditEntry struct {
+	ChannelID  int64
+	MessageID  int
+	UserID     int64
+	FilterID   int
+	FilterName string
+	Action     string
+	CreatedAt  string
 }
 
 func (d *DB) Close() error {
+	if d.reader != d.db {
+		d.reader.Close()
+	}
 	return d.db.Close()
 }
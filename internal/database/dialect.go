@@ -0,0 +1,105 @@
+package database
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gnomegl/teleslurp/internal/timeutil"
+)
+
+// Supported database dialects
+const (
+	DialectSQLite   = "sqlite"
+	DialectPostgres = "postgres"
+)
+
+// DialectFromDSN picks the backend for a DSN. postgres:// and postgresql://
+// URLs and libpq key=value strings select PostgreSQL; anything else is a
+// SQLite file path, optionally prefixed with sqlite://.
+func DialectFromDSN(dsn string) string {
+	lower := strings.ToLower(dsn)
+	if strings.HasPrefix(lower, "postgres://") || strings.HasPrefix(lower, "postgresql://") {
+		return DialectPostgres
+	}
+	if strings.Contains(lower, "host=") || strings.Contains(lower, "dbname=") {
+		return DialectPostgres
+	}
+	return DialectSQLite
+}
+
+// sqliteBusyTimeout is how long a connection waits for another process's
+// write lock before failing with "database is locked"
+const sqliteBusyTimeout = 10 * time.Second
+
+// sqliteReaders is the size of the read-only connection pool
+const sqliteReaders = 4
+
+var sqlitePathEscaper = strings.NewReplacer("%", "%25", "?", "%3f", "#", "%23")
+
+// sqliteDSNs builds the connection strings of the writer and reader pools for
+// a SQLite path. Both use WAL so readers never block the writer, wait on locks
+// instead of failing, and the writer takes the write lock when a transaction
+// begins so two processes can't deadlock upgrading read locks.
+func sqliteDSNs(dsn string) (writer, reader string, err error) {
+	path := strings.TrimPrefix(dsn, "sqlite://")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", "", err
+	}
+
+	base := "file:" + sqlitePathEscaper.Replace(path) +
+		"?_busy_timeout=" + strconv.FormatInt(sqliteBusyTimeout.Milliseconds(), 10)
+	writer = base + "&_journal_mode=WAL&_synchronous=NORMAL&_txlock=immediate"
+	reader = base + "&_query_only=true"
+	return writer, reader, nil
+}
+
+var dsnPasswordRegex = regexp.MustCompile(`(?i)(password\s*=\s*)('[^']*'|\S+)`)
+
+// RedactDSN hides the password in a DSN so it can be printed
+func RedactDSN(dsn string) string {
+	if DialectFromDSN(dsn) != DialectPostgres {
+		return dsn
+	}
+	if u, err := url.Parse(dsn); err == nil && u.Scheme != "" {
+		return u.Redacted()
+	}
+	return dsnPasswordRegex.ReplaceAllString(dsn, "${1}xxxxx")
+}
+
+// rebind rewrites ? placeholders into the dialect's placeholder syntax,
+// leaving quoted strings alone
+func (d *DB) rebind(query string) string {
+	if d.dialect != DialectPostgres {
+		return query
+	}
+
+	var b strings.Builder
+	b.Grow(len(query) + 16)
+	n := 0
+	inQuote := false
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == '\'':
+			inQuote = !inQuote
+		case c == '?' && !inQuote:
+			n++
+			b.WriteByte('$')
+			b.WriteString(strconv.Itoa(n))
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// timestamp returns the current time in the format stored in the database,
+// UTC RFC 3339. Times are written from Go so both dialects store the same text.
+func timestamp() string {
+	return timeutil.Now()
+}
@@ -0,0 +1,149 @@
+package database
+
+import "strings"
+
+// ExportQuery selects the stored messages and status updates to export
+type ExportQuery struct {
+	// Channel restricts messages to a channel ID or username
+	Channel string
+	// UserID restricts messages to a sender and status updates to a user
+	UserID int64
+	// Since and Until bound the date, UTC RFC 3339 like the stored times;
+	// Since is inclusive and Until exclusive
+	Since string
+	Until string
+	// Keywords restricts messages to those containing any of them, ignoring case
+	Keywords []string
+}
+
+// StoredMessage is a message stored by the monitor, with the channel's
+// member count when its metadata was recorded
+type StoredMessage struct {
+	ChannelID       int64
+	ChannelTitle    string
+	ChannelUsername string
+	MemberCount     int
+	MessageID       int
+	SenderID        int64
+	Date            string
+	Message         string
+	URL             string
+}
+
+// ExportMessages calls handler for every stored message matching q, one
+// channel after the other and in date order within a channel, without
+// loading them all into memory
+func (d *DB) ExportMessages(q ExportQuery, handler func(StoredMessage) error) error {
+	query := `
+		SELECT m.channel_id, m.channel_title, COALESCE(m.channel_username, ''),
+			COALESCE(c.member_count, 0), m.message_id, COALESCE(m.sender_id, 0),
+			COALESCE(m.date, ''), COALESCE(m.message, ''), COALESCE(m.url, '')
+		FROM messages m
+		LEFT JOIN channel_metadata c ON c.channel_id = m.channel_id
+		WHERE 1 = 1`
+	var args []interface{}
+
+	if q.Channel != "" {
+		query += " AND (CAST(m.channel_id AS TEXT) = ? OR LOWER(m.channel_username) = LOWER(?))"
+		args = append(args, q.Channel, strings.TrimPrefix(q.Channel, "@"))
+	}
+	if q.UserID != 0 {
+		query += " AND m.sender_id = ?"
+		args = append(args, q.UserID)
+	}
+	if q.Since != "" {
+		query += " AND m.date >= ?"
+		args = append(args, q.Since)
+	}
+	if q.Until != "" {
+		query += " AND m.date < ?"
+		args = append(args, q.Until)
+	}
+	query += " ORDER BY m.channel_id, m.date, m.message_id"
+
+	keywords := make([]string, len(q.Keywords))
+	for i, keyword := range q.Keywords {
+		keywords[i] = strings.ToLower(keyword)
+	}
+
+	rows, err := d.query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var m StoredMessage
+		if err := rows.Scan(&m.ChannelID, &m.ChannelTitle, &m.ChannelUsername, &m.MemberCount,
+			&m.MessageID, &m.SenderID, &m.Date, &m.Message, &m.URL); err != nil {
+			return err
+		}
+		if !containsKeyword(m.Message, keywords) {
+			continue
+		}
+		if err := handler(m); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// containsKeyword reports whether text contains any of the lowercased
+// keywords, or whether there are none. Case is folded here rather than
+// with SQL's LOWER, which only folds ASCII letters on SQLite.
+func containsKeyword(text string, keywords []string) bool {
+	if len(keywords) == 0 {
+		return true
+	}
+	text = strings.ToLower(text)
+	for _, keyword := range keywords {
+		if strings.Contains(text, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// ExportStatusUpdates calls handler for every status update matching the
+// user and date range of q, in chronological order
+func (d *DB) ExportStatusUpdates(q ExportQuery, handler func(UserStatusUpdate) error) error {
+	query := `
+		SELECT id, user_id, COALESCE(username, ''), COALESCE(first_name, ''),
+			COALESCE(last_name, ''), status, COALESCE(state, ''),
+			COALESCE(was_online, ''), COALESCE(expires, ''), COALESCE(status_time, '')
+		FROM user_status_updates
+		WHERE 1 = 1`
+	var args []interface{}
+
+	if q.UserID != 0 {
+		query += " AND user_id = ?"
+		args = append(args, q.UserID)
+	}
+	if q.Since != "" {
+		query += " AND status_time >= ?"
+		args = append(args, q.Since)
+	}
+	if q.Until != "" {
+		query += " AND status_time < ?"
+		args = append(args, q.Until)
+	}
+	query += " ORDER BY status_time, id"
+
+	rows, err := d.query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var u UserStatusUpdate
+		if err := rows.Scan(&u.ID, &u.UserID, &u.Username, &u.FirstName, &u.LastName,
+			&u.Status, &u.State, &u.WasOnline, &u.Expires, &u.StatusTime); err != nil {
+			return err
+		}
+		if err := handler(u); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
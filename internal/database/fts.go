@@ -0,0 +1,183 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrFullTextUnavailable is returned when SQLite was built without FTS5 or
+// the database is not SQLite
+var ErrFullTextUnavailable = errors.New("full-text search requires the SQLite backend with FTS5; rebuild teleslurp with -tags sqlite_fts5")
+
+// ftsTriggers are the triggers that keep messages_fts in sync with messages
+var ftsTriggers = []string{"trg_messages_fts_insert", "trg_messages_fts_delete", "trg_messages_fts_update"}
+
+// ensureFullTextSearch creates the messages_fts index and the triggers that
+// keep it in sync with messages. This lives outside the versioned migrations
+// because FTS5 is only compiled into go-sqlite3 with the sqlite_fts5 build
+// tag; without it the database must keep working, just without search.
+func (d *DB) ensureFullTextSearch() error {
+	if d.dialect != DialectSQLite {
+		return nil
+	}
+
+	var enabled bool
+	if err := d.queryRow("SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&enabled); err != nil {
+		return err
+	}
+	if !enabled {
+		return d.dropFullTextTriggers()
+	}
+
+	var exists, triggers int
+	if err := d.queryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'messages_fts'").Scan(&exists); err != nil {
+		return err
+	}
+	if err := d.queryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'trigger' AND name LIKE 'trg_messages_fts_%'").Scan(&triggers); err != nil {
+		return err
+	}
+
+	statements := []string{
+		`CREATE VIRTUAL TABLE IF NOT EXISTS messages_fts USING fts5(
+			message, channel_title, channel_username,
+			content = 'messages', content_rowid = 'id',
+			tokenize = 'unicode61 remove_diacritics 2'
+		);`,
+		`CREATE TRIGGER IF NOT EXISTS trg_messages_fts_insert AFTER INSERT ON messages
+		BEGIN
+			INSERT INTO messages_fts (rowid, message, channel_title, channel_username)
+			VALUES (new.id, new.message, new.channel_title, new.channel_username);
+		END;`,
+		`CREATE TRIGGER IF NOT EXISTS trg_messages_fts_delete AFTER DELETE ON messages
+		BEGIN
+			INSERT INTO messages_fts (messages_fts, rowid, message, channel_title, channel_username)
+			VALUES ('delete', old.id, old.message, old.channel_title, old.channel_username);
+		END;`,
+		`CREATE TRIGGER IF NOT EXISTS trg_messages_fts_update AFTER UPDATE ON messages
+		BEGIN
+			INSERT INTO messages_fts (messages_fts, rowid, message, channel_title, channel_username)
+			VALUES ('delete', old.id, old.message, old.channel_title, old.channel_username);
+			INSERT INTO messages_fts (rowid, message, channel_title, channel_username)
+			VALUES (new.id, new.message, new.channel_title, new.channel_username);
+		END;`,
+	}
+
+	for _, stmt := range statements {
+		if _, err := d.exec(stmt); err != nil {
+			return err
+		}
+	}
+
+	// Index messages stored before the FTS table existed, or while a build
+	// without FTS5 had dropped the triggers
+	if exists == 0 || triggers < len(ftsTriggers) {
+		if _, err := d.exec("INSERT INTO messages_fts (messages_fts) VALUES ('rebuild');"); err != nil {
+			return err
+		}
+	}
+
+	d.fts = true
+	return nil
+}
+
+// dropFullTextTriggers removes the messages_fts triggers left by a build
+// with FTS5, since every write to messages would otherwise fail with "no such
+// module: fts5". The messages_fts table itself can't be dropped without the
+// module; it is rebuilt when a build with FTS5 opens the database again.
+func (d *DB) dropFullTextTriggers() error {
+	for _, name := range ftsTriggers {
+		if _, err := d.exec("DROP TRIGGER IF EXISTS " + name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HasFullTextSearch reports whether the FTS5 index is available
+func (d *DB) HasFullTextSearch() bool {
+	return d.fts
+}
+
+// MessageQuery describes a full-text search over stored messages
+type MessageQuery struct {
+	// Match is an FTS5 query: words, "exact phrases", prefix* and AND/OR/NOT
+	Match string
+	// Channel restricts results to a channel ID or username
+	Channel string
+	// Since and Until bound the message date, UTC RFC 3339 like the stored
+	// times; Since is inclusive and Until exclusive
+	Since string
+	Until string
+	Limit int
+	// HighlightStart and HighlightEnd wrap matched terms in the snippet
+	HighlightStart string
+	HighlightEnd   string
+}
+
+// MessageSearchResult is a stored message matching a full-text query
+type MessageSearchResult struct {
+	ChannelID       int64   `json:"channel_id"`
+	ChannelTitle    string  `json:"channel_title"`
+	ChannelUsername string  `json:"channel_username"`
+	MessageID       int     `json:"message_id"`
+	Date            string  `json:"date"`
+	Message         string  `json:"message"`
+	URL             string  `json:"url"`
+	Snippet         string  `json:"snippet"`
+	Rank            float64 `json:"rank"`
+}
+
+// SearchMessages runs a full-text query over stored messages, best matches first
+func (d *DB) SearchMessages(q MessageQuery) ([]MessageSearchResult, error) {
+	if !d.fts {
+		return nil, ErrFullTextUnavailable
+	}
+
+	query := `
+		SELECT m.channel_id, m.channel_title, COALESCE(m.channel_username, ''), m.message_id,
+			m.date, COALESCE(m.message, ''), COALESCE(m.url, ''),
+			snippet(messages_fts, 0, ?, ?, '…', 16), messages_fts.rank
+		FROM messages_fts
+		JOIN messages m ON m.id = messages_fts.rowid
+		WHERE messages_fts MATCH ?`
+	args := []interface{}{q.HighlightStart, q.HighlightEnd, q.Match}
+
+	if q.Channel != "" {
+		query += " AND (CAST(m.channel_id AS TEXT) = ? OR m.channel_username = ?)"
+		username := strings.TrimPrefix(q.Channel, "@")
+		args = append(args, q.Channel, username)
+	}
+	if q.Since != "" {
+		query += " AND m.date >= ?"
+		args = append(args, q.Since)
+	}
+	if q.Until != "" {
+		query += " AND m.date < ?"
+		args = append(args, q.Until)
+	}
+
+	query += " ORDER BY messages_fts.rank LIMIT ?"
+	limit := q.Limit
+	if limit <= 0 {
+		limit = -1
+	}
+	args = append(args, limit)
+
+	rows, err := d.query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error searching messages: %w", err)
+	}
+	defer rows.Close()
+
+	var results []MessageSearchResult
+	for rows.Next() {
+		var r MessageSearchResult
+		if err := rows.Scan(&r.ChannelID, &r.ChannelTitle, &r.ChannelUsername, &r.MessageID,
+			&r.Date, &r.Message, &r.URL, &r.Snippet, &r.Rank); err != nil {
+			return nil, err
+		}
+		results = append(results, r)
+	}
+	return results, rows.Err()
+}
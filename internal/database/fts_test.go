@@ -0,0 +1,111 @@
+package database
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// ftsStepEnv and ftsDBEnv tell TestFullTextBuildStep which step of
+// TestFullTextAcrossBuilds to run on which database file
+const (
+	ftsStepEnv = "TELESLURP_TEST_FTS_STEP"
+	ftsDBEnv   = "TELESLURP_TEST_FTS_DB"
+)
+
+// TestFullTextAcrossBuilds opens one SQLite file alternately with a build
+// with FTS5 and one without, as happens when a database is shared between
+// teleslurp binaries built with and without -tags sqlite_fts5
+func TestFullTextAcrossBuilds(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds the package with and without sqlite_fts5")
+	}
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go command not found")
+	}
+
+	path := filepath.Join(t.TempDir(), "teleslurp.db")
+	steps := []struct {
+		step string
+		tags string
+	}{
+		{"index", "sqlite_fts5"},
+		{"plain", ""},
+		{"search", "sqlite_fts5"},
+	}
+	for _, s := range steps {
+		cmd := exec.Command(goBin, "test", "-count=1", "-tags="+s.tags, "-run=^TestFullTextBuildStep$", ".")
+		cmd.Env = append(os.Environ(), ftsStepEnv+"="+s.step, ftsDBEnv+"="+path)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("step %s with tags %q failed: %v\n%s", s.step, s.tags, err, out)
+		}
+	}
+}
+
+// TestFullTextBuildStep runs one step of TestFullTextAcrossBuilds
+func TestFullTextBuildStep(t *testing.T) {
+	step := os.Getenv(ftsStepEnv)
+	if step == "" {
+		t.Skip("run by TestFullTextAcrossBuilds")
+	}
+
+	d, err := New(os.Getenv(ftsDBEnv))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer d.Close()
+
+	if want := step != "plain"; d.HasFullTextSearch() != want {
+		t.Fatalf("HasFullTextSearch() = %v, want %v", d.HasFullTextSearch(), want)
+	}
+
+	switch step {
+	case "index":
+		must(t, d.SaveMessage(100, "Chan", "chan", 1, 0, "2024-05-01T14:03:22Z", "first message about apples", ""))
+		must(t, d.SaveMessage(100, "Chan", "chan", 2, 0, "2024-05-01T14:04:00Z", "second message about cherries", ""))
+
+	case "plain":
+		// Every kind of write to messages must work without FTS5
+		must(t, d.SaveMessage(100, "Chan", "chan", 3, 0, "2024-05-01T15:00:00Z", "third message about pears", ""))
+		_, err := d.exec("UPDATE messages SET message = ? WHERE message_id = ?", "first message about plums", 1)
+		must(t, err)
+		_, err = d.exec("DELETE FROM messages WHERE message_id = ?", 2)
+		must(t, err)
+
+	case "search":
+		// Writes made without FTS5 are indexed once it is back
+		for match, want := range map[string][]int{
+			"apples":   nil,
+			"cherries": nil,
+			"plums":    {1},
+			"pears":    {3},
+			"message":  {1, 3},
+		} {
+			results, err := d.SearchMessages(MessageQuery{Match: match})
+			must(t, err)
+			var got []int
+			for _, r := range results {
+				got = append(got, r.MessageID)
+			}
+			if len(got) == 2 && got[0] > got[1] {
+				got[0], got[1] = got[1], got[0]
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("search %q found messages %v, want %v", match, got, want)
+			}
+		}
+		// The triggers are back in place for new writes
+		must(t, d.SaveMessage(100, "Chan", "chan", 4, 0, "2024-05-01T16:00:00Z", "fourth message about figs", ""))
+		results, err := d.SearchMessages(MessageQuery{Match: "figs"})
+		must(t, err)
+		if len(results) != 1 {
+			t.Errorf("search figs found %d messages, want 1", len(results))
+		}
+
+	default:
+		t.Fatalf("unknown step %q", step)
+	}
+}
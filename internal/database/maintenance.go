@@ -0,0 +1,315 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/gnomegl/teleslurp/internal/timeutil"
+	"github.com/mattn/go-sqlite3"
+)
+
+// retentionColumns maps each table that supports retention to the column
+// holding the age of its rows. There is no media table: media is forwarded
+// from memory and never stored.
+var retentionColumns = map[string]string{
+	"messages":            "date",
+	"user_status_updates": "status_time",
+	"filter_audit":        "created_at",
+	"search_runs":         "started_at",
+	"user_sightings":      "message_date",
+}
+
+// pruneOrder prunes search runs before messages so messages only kept alive
+// by an expired run can go in the same pass
+var pruneOrder = []string{"search_runs", "messages", "user_status_updates", "user_sightings", "filter_audit"}
+
+// RetentionTables lists the tables a retention policy can be set for
+func RetentionTables() []string {
+	tables := make([]string, 0, len(retentionColumns))
+	for table := range retentionColumns {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+	return tables
+}
+
+// PruneResult is the number of rows removed from a table
+type PruneResult struct {
+	Table   string
+	Deleted int64
+}
+
+// Prune deletes rows older than the retention set for their table. Messages
+// linked to a search run are kept until the run itself expires. With dryRun
+// set, the deletes are rolled back so only the counts are reported.
+func (d *DB) Prune(policy map[string]time.Duration, dryRun bool) ([]PruneResult, error) {
+	for table := range policy {
+		if _, ok := retentionColumns[table]; !ok {
+			return nil, fmt.Errorf("retention is not supported for table %s", table)
+		}
+	}
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var results []PruneResult
+	deleteRows := func(table, where string, args ...interface{}) error {
+		res, err := tx.Exec(d.rebind("DELETE FROM "+table+" WHERE "+where), args...)
+		if err != nil {
+			return fmt.Errorf("error pruning %s: %w", table, err)
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		results = append(results, PruneResult{Table: table, Deleted: n})
+		return nil
+	}
+
+	for _, table := range pruneOrder {
+		maxAge, ok := policy[table]
+		if !ok || maxAge <= 0 {
+			continue
+		}
+		cutoff := timeutil.Format(time.Now().Add(-maxAge))
+
+		where := retentionColumns[table] + " < ?"
+		if table == "messages" {
+			where += ` AND NOT EXISTS (
+				SELECT 1 FROM search_run_messages l
+				WHERE l.channel_id = messages.channel_id AND l.message_id = messages.message_id)`
+		}
+		if err := deleteRows(table, where, cutoff); err != nil {
+			return nil, err
+		}
+
+		// Remove rows that belonged to the pruned parents
+		switch table {
+		case "search_runs":
+			for _, child := range []string{"search_run_usernames", "search_run_groups", "search_run_channels", "search_run_messages"} {
+				if err := deleteRows(child, "NOT EXISTS (SELECT 1 FROM search_runs r WHERE r.id = "+child+".run_id)"); err != nil {
+					return nil, err
+				}
+			}
+		case "messages":
+			if err := deleteRows("indicators", `NOT EXISTS (
+				SELECT 1 FROM messages m
+				WHERE m.channel_id = indicators.channel_id AND m.message_id = indicators.message_id)`); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if dryRun {
+		return results, nil
+	}
+	return results, tx.Commit()
+}
+
+// Vacuum reclaims the space left by deleted rows
+func (d *DB) Vacuum() error {
+	_, err := d.exec("VACUUM")
+	return err
+}
+
+// TableStats is the row count and, where the backend reports it, the size of a table
+type TableStats struct {
+	Name string
+	Rows int64
+	// Size in bytes, -1 when unknown
+	Size int64
+}
+
+// DatabaseStats summarizes the contents of the database
+type DatabaseStats struct {
+	Tables []TableStats
+	// Size of the whole database in bytes
+	Size int64
+}
+
+// Stats returns the row count and size of every table. SQLite only reports
+// per-table sizes when built with the DBSTAT virtual table.
+func (d *DB) Stats() (*DatabaseStats, error) {
+	tables, err := d.tableNames()
+	if err != nil {
+		return nil, fmt.Errorf("error listing tables: %w", err)
+	}
+
+	sizes, err := d.tableSizes()
+	if err != nil {
+		return nil, fmt.Errorf("error reading table sizes: %w", err)
+	}
+
+	stats := &DatabaseStats{}
+	for _, table := range tables {
+		ts := TableStats{Name: table, Size: -1}
+		if err := d.queryRow("SELECT COUNT(*) FROM " + quoteIdent(table)).Scan(&ts.Rows); err != nil {
+			return nil, fmt.Errorf("error counting rows of %s: %w", table, err)
+		}
+		if size, ok := sizes[table]; ok {
+			ts.Size = size
+		}
+		stats.Tables = append(stats.Tables, ts)
+	}
+
+	switch d.dialect {
+	case DialectPostgres:
+		err = d.queryRow("SELECT pg_database_size(current_database())").Scan(&stats.Size)
+	default:
+		err = d.queryRow("SELECT page_count * page_size FROM pragma_page_count(), pragma_page_size()").Scan(&stats.Size)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading database size: %w", err)
+	}
+	return stats, nil
+}
+
+func (d *DB) tableNames() ([]string, error) {
+	var rows *sql.Rows
+	var err error
+	switch d.dialect {
+	case DialectPostgres:
+		rows, err = d.query(`
+			SELECT table_name FROM information_schema.tables
+			WHERE table_schema = current_schema() AND table_type = 'BASE TABLE'
+			ORDER BY table_name
+		`)
+	default:
+		rows, err = d.query(`
+			SELECT name FROM sqlite_master
+			WHERE type = 'table' AND name NOT LIKE 'sqlite_%'
+			ORDER BY name
+		`)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		tables = append(tables, name)
+	}
+	return tables, rows.Err()
+}
+
+// tableSizes returns the on-disk size of each table including its indexes,
+// or an empty map when the backend can't report it
+func (d *DB) tableSizes() (map[string]int64, error) {
+	var rows *sql.Rows
+	var err error
+	switch d.dialect {
+	case DialectPostgres:
+		rows, err = d.query(`
+			SELECT table_name, pg_total_relation_size(quote_ident(table_name))
+			FROM information_schema.tables
+			WHERE table_schema = current_schema() AND table_type = 'BASE TABLE'
+		`)
+	default:
+		var enabled bool
+		if err := d.queryRow("SELECT sqlite_compileoption_used('ENABLE_DBSTAT_VTAB')").Scan(&enabled); err != nil || !enabled {
+			return map[string]int64{}, nil
+		}
+		rows, err = d.query(`
+			SELECT COALESCE(m.tbl_name, s.name), SUM(s.pgsize)
+			FROM dbstat s
+			LEFT JOIN sqlite_master m ON m.name = s.name
+			GROUP BY 1
+		`)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sizes := make(map[string]int64)
+	for rows.Next() {
+		var name string
+		var size int64
+		if err := rows.Scan(&name, &size); err != nil {
+			return nil, err
+		}
+		sizes[name] = size
+	}
+	return sizes, rows.Err()
+}
+
+func quoteIdent(name string) string {
+	return `"` + name + `"`
+}
+
+// backupStepPages is how many pages are copied per backup step. Copying in
+// small steps lets the monitor keep writing while a backup runs.
+const backupStepPages = 256
+
+// Backup copies the database to destPath with SQLite's online backup API,
+// which is safe while other connections are writing
+func (d *DB) Backup(ctx context.Context, destPath string) error {
+	if d.dialect != DialectSQLite {
+		return fmt.Errorf("backup is only supported for SQLite; use pg_dump for PostgreSQL")
+	}
+
+	dest, err := sql.Open("sqlite3", destPath)
+	if err != nil {
+		return fmt.Errorf("error opening backup file: %w", err)
+	}
+	defer dest.Close()
+
+	destConn, err := dest.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("error opening backup file: %w", err)
+	}
+	defer destConn.Close()
+
+	srcConn, err := d.reader.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer srcConn.Close()
+
+	return destConn.Raw(func(destRaw interface{}) error {
+		return srcConn.Raw(func(srcRaw interface{}) error {
+			destSQLite, ok := destRaw.(*sqlite3.SQLiteConn)
+			if !ok {
+				return fmt.Errorf("unexpected backup connection type %T", destRaw)
+			}
+			srcSQLite, ok := srcRaw.(*sqlite3.SQLiteConn)
+			if !ok {
+				return fmt.Errorf("unexpected database connection type %T", srcRaw)
+			}
+
+			backup, err := destSQLite.Backup("main", srcSQLite, "main")
+			if err != nil {
+				return fmt.Errorf("error starting backup: %w", err)
+			}
+
+			for {
+				done, err := backup.Step(backupStepPages)
+				if err != nil {
+					backup.Close()
+					return fmt.Errorf("error copying pages: %w", err)
+				}
+				if done {
+					break
+				}
+				select {
+				case <-ctx.Done():
+					backup.Close()
+					return ctx.Err()
+				case <-time.After(10 * time.Millisecond):
+				}
+			}
+			return backup.Finish()
+		})
+	})
+}
@@ -0,0 +1,182 @@
+package database
+
+import (
+	"embed"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed migrations/sqlite/*.sql migrations/postgres/*.sql
+var migrationFiles embed.FS
+
+// Migration is a single ordered schema change
+type Migration struct {
+	Version int
+	Name    string
+	SQL     string
+}
+
+// AppliedMigration is a migration recorded in the schema_version table
+type AppliedMigration struct {
+	Version   int
+	Name      string
+	AppliedAt string
+}
+
+// Migrations returns the embedded migrations of a dialect ordered by version.
+// Files are named migrations/<dialect>/NNNN_description.sql and both dialects
+// use the same version numbers for equivalent changes.
+func Migrations(dialect string) ([]Migration, error) {
+	dir := path.Join("migrations", dialect)
+	entries, err := migrationFiles.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("error reading migrations: %w", err)
+	}
+
+	var migrations []Migration
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasSuffix(name, ".sql") {
+			continue
+		}
+
+		prefix, desc, ok := strings.Cut(strings.TrimSuffix(name, ".sql"), "_")
+		if !ok {
+			return nil, fmt.Errorf("invalid migration file name: %s", name)
+		}
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s: %w", name, err)
+		}
+
+		content, err := migrationFiles.ReadFile(path.Join(dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("error reading migration %s: %w", name, err)
+		}
+
+		migrations = append(migrations, Migration{Version: version, Name: desc, SQL: string(content)})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].Version == migrations[i-1].Version {
+			return nil, fmt.Errorf("duplicate migration version %d", migrations[i].Version)
+		}
+	}
+
+	return migrations, nil
+}
+
+func (d *DB) ensureSchemaVersionTable() error {
+	_, err := d.exec(`
+		CREATE TABLE IF NOT EXISTS schema_version (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at TEXT
+		);
+	`)
+	return err
+}
+
+// AppliedMigrations returns the migrations recorded in schema_version
+func (d *DB) AppliedMigrations() ([]AppliedMigration, error) {
+	if err := d.ensureSchemaVersionTable(); err != nil {
+		return nil, err
+	}
+
+	rows, err := d.query("SELECT version, name, COALESCE(applied_at, '') FROM schema_version ORDER BY version")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var applied []AppliedMigration
+	for rows.Next() {
+		var m AppliedMigration
+		if err := rows.Scan(&m.Version, &m.Name, &m.AppliedAt); err != nil {
+			return nil, err
+		}
+		applied = append(applied, m)
+	}
+	return applied, rows.Err()
+}
+
+// PendingMigrations returns the embedded migrations not yet applied
+func (d *DB) PendingMigrations() ([]Migration, error) {
+	migrations, err := Migrations(d.dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := d.AppliedMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	done := make(map[int]bool, len(applied))
+	for _, m := range applied {
+		done[m.Version] = true
+	}
+
+	var pending []Migration
+	for _, m := range migrations {
+		if !done[m.Version] {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+// SchemaVersion returns the highest applied migration version
+func (d *DB) SchemaVersion() (int, error) {
+	applied, err := d.AppliedMigrations()
+	if err != nil {
+		return 0, err
+	}
+	if len(applied) == 0 {
+		return 0, nil
+	}
+	return applied[len(applied)-1].Version, nil
+}
+
+// Migrate applies all pending migrations in order, each in its own
+// transaction, and returns the migrations that were applied
+func (d *DB) Migrate() ([]Migration, error) {
+	pending, err := d.PendingMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+	for _, m := range pending {
+		if err := d.applyMigration(m); err != nil {
+			return applied, fmt.Errorf("migration %04d_%s failed: %w", m.Version, m.Name, err)
+		}
+		applied = append(applied, m)
+	}
+	return applied, nil
+}
+
+func (d *DB) applyMigration(m Migration) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(m.SQL); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(d.rebind("INSERT INTO schema_version (version, name, applied_at) VALUES (?, ?, ?)"), m.Version, m.Name, timestamp()); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,319 @@
+package database
+
+import (
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// legacyLayout is how times were stored before migration 0006
+const legacyLayout = "2006-01-02 15:04:05"
+
+// legacySchema is the schema createTables created before versioned
+// migrations existed
+var legacySchema = []string{
+	`CREATE TABLE IF NOT EXISTS messages (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		channel_id INTEGER NOT NULL,
+		channel_title TEXT NOT NULL,
+		channel_username TEXT,
+		message_id INTEGER NOT NULL,
+		date DATETIME NOT NULL,
+		message TEXT,
+		url TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(channel_id, message_id)
+	);`,
+	`CREATE TABLE IF NOT EXISTS user_status_updates (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		username TEXT,
+		first_name TEXT,
+		last_name TEXT,
+		status TEXT NOT NULL,
+		status_time DATETIME NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);`,
+	`CREATE TABLE IF NOT EXISTS monitored_users (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER UNIQUE NOT NULL,
+		username TEXT,
+		first_name TEXT,
+		last_name TEXT,
+		added_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);`,
+	`CREATE TABLE IF NOT EXISTS channel_metadata (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		channel_id INTEGER UNIQUE NOT NULL,
+		title TEXT NOT NULL,
+		username TEXT,
+		member_count INTEGER,
+		is_public BOOLEAN,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);`,
+	`CREATE TABLE IF NOT EXISTS message_filters (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		pattern TEXT NOT NULL,
+		type TEXT NOT NULL, -- 'keyword', 'regex', 'user', 'channel'
+		action TEXT NOT NULL, -- 'forward', 'ignore', 'highlight'
+		priority INTEGER DEFAULT 0,
+		enabled BOOLEAN DEFAULT 1,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);`,
+	"CREATE INDEX IF NOT EXISTS idx_messages_channel_id ON messages(channel_id);",
+	"CREATE INDEX IF NOT EXISTS idx_messages_date ON messages(date);",
+	"CREATE INDEX IF NOT EXISTS idx_user_status_user_id ON user_status_updates(user_id);",
+	"CREATE INDEX IF NOT EXISTS idx_user_status_time ON user_status_updates(status_time);",
+	"CREATE INDEX IF NOT EXISTS idx_filters_type ON message_filters(type);",
+	"CREATE INDEX IF NOT EXISTS idx_filters_enabled ON message_filters(enabled);",
+}
+
+// legacyRows are written the way versions before migrations wrote them
+var legacyRows = []string{
+	`INSERT INTO messages (channel_id, channel_title, channel_username, message_id, date, message, url)
+		VALUES (100, 'Chan', 'chan', 1, '2024-05-01 16:03:22', 'hello', 'https://t.me/chan/1')`,
+	`INSERT INTO user_status_updates (user_id, username, status, status_time)
+		VALUES (42, 'johndoe', 'online (expires: 2024-01-02 15:04:05 +0100 CET)', '2024-01-02 15:00:00')`,
+	`INSERT INTO user_status_updates (user_id, username, status, status_time)
+		VALUES (42, 'johndoe', 'offline (was online: 2024-01-02 16:59:00 +0100 CET)', '2024-01-02 17:00:00')`,
+	`INSERT INTO user_status_updates (user_id, username, status, status_time)
+		VALUES (42, 'johndoe', 'recently active', '2024-01-03 09:30:00')`,
+	`INSERT INTO monitored_users (user_id, username) VALUES (42, 'johndoe')`,
+	`INSERT INTO channel_metadata (channel_id, title, username, member_count, is_public)
+		VALUES (100, 'Chan', 'chan', 10, 1)`,
+	`INSERT INTO message_filters (name, pattern, type, action, priority)
+		VALUES ('spam', 'casino,bonus', 'keyword', 'ignore', 5)`,
+}
+
+// newLegacyDB creates a SQLite file as createTables left it before
+// versioned migrations, holding a few rows
+func newLegacyDB(t *testing.T) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "teleslurp.db")
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	for _, stmt := range legacySchema {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("error creating legacy schema: %v", err)
+		}
+	}
+	for _, row := range legacyRows {
+		if _, err := db.Exec(row); err != nil {
+			t.Fatalf("error inserting legacy row: %v", err)
+		}
+	}
+	return path
+}
+
+// utc converts a legacy local time to the UTC RFC 3339 format of 0006
+func utc(t *testing.T, local string) string {
+	t.Helper()
+	parsed, err := time.ParseInLocation(legacyLayout, local, time.Local)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed.UTC().Format(time.RFC3339)
+}
+
+func tableColumns(t *testing.T, d *DB, table string) map[string]bool {
+	t.Helper()
+	rows, err := d.query("SELECT name FROM pragma_table_info(?)", table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			t.Fatal(err)
+		}
+		columns[name] = true
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return columns
+}
+
+func TestMigrateFromLegacy(t *testing.T) {
+	path := newLegacyDB(t)
+
+	d, err := New(path)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer d.Close()
+
+	t.Run("schema version", func(t *testing.T) {
+		migrations, err := Migrations(DialectSQLite)
+		if err != nil {
+			t.Fatal(err)
+		}
+		version, err := d.SchemaVersion()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := migrations[len(migrations)-1].Version; version != want {
+			t.Errorf("SchemaVersion() = %d, want %d", version, want)
+		}
+		pending, err := d.PendingMigrations()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(pending) != 0 {
+			t.Errorf("%d migrations still pending", len(pending))
+		}
+	})
+
+	t.Run("tables and columns", func(t *testing.T) {
+		want := map[string][]string{
+			// 0001
+			"indicators":      {"channel_id", "message_id", "type", "value"},
+			"filter_options":  {"filter_id", "name", "value"},
+			"filter_keywords": {"filter_id", "term"},
+			"filter_stats":    {"filter_id", "match_count", "last_match_at"},
+			"filter_audit":    {"channel_id", "message_id", "user_id", "filter_id", "filter_name", "action"},
+			// 0002
+			"messages":             {"sender_id"},
+			"search_runs":          {"id", "query", "source", "user_id", "credits", "status", "started_at", "finished_at"},
+			"search_run_usernames": {"run_id", "username", "date"},
+			"search_run_groups":    {"run_id", "group_id", "username", "title", "date_updated"},
+			"search_run_channels":  {"run_id", "channel_id", "first_message_date", "message_count"},
+			"search_run_messages":  {"run_id", "channel_id", "message_id"},
+			// 0004
+			"user_status_updates": {"state", "was_online", "expires"},
+			// 0005
+			"user_sightings": {"user_id", "chat_type", "chat_id", "message_id", "message_date"},
+		}
+		for table, columns := range want {
+			got := tableColumns(t, d, table)
+			if len(got) == 0 {
+				t.Errorf("table %s is missing", table)
+				continue
+			}
+			for _, column := range columns {
+				if !got[column] {
+					t.Errorf("column %s.%s is missing", table, column)
+				}
+			}
+		}
+
+		// 0001 and 0003
+		for _, name := range []string{"message_filters", "monitored_users"} {
+			var count int
+			if err := d.queryRow("SELECT COUNT(*) FROM change_versions WHERE name = ?", name).Scan(&count); err != nil {
+				t.Fatal(err)
+			}
+			if count != 1 {
+				t.Errorf("change_versions has no %s row", name)
+			}
+		}
+	})
+
+	t.Run("legacy rows kept", func(t *testing.T) {
+		for table, want := range map[string]int{
+			"messages":            1,
+			"user_status_updates": 3,
+			"monitored_users":     1,
+			"channel_metadata":    1,
+			"message_filters":     1,
+		} {
+			var count int
+			if err := d.queryRow("SELECT COUNT(*) FROM " + table).Scan(&count); err != nil {
+				t.Fatal(err)
+			}
+			if count != want {
+				t.Errorf("%s has %d rows, want %d", table, count, want)
+			}
+		}
+
+		filters, err := d.GetActiveFilters()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(filters) != 1 || filters[0].Name != "spam" || filters[0].Action != "ignore" {
+			t.Errorf("active filters = %+v", filters)
+		}
+	})
+	t.Run("status fields backfilled", func(t *testing.T) {
+		rows, err := d.query(`
+			SELECT status_time || '', COALESCE(state, ''), COALESCE(was_online, ''), COALESCE(expires, '')
+			FROM user_status_updates ORDER BY id`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+
+		want := [][4]string{
+			{utc(t, "2024-01-02 15:00:00"), "online", "", utc(t, "2024-01-02 15:04:05")},
+			{utc(t, "2024-01-02 17:00:00"), "offline", utc(t, "2024-01-02 16:59:00"), ""},
+			{utc(t, "2024-01-03 09:30:00"), "recently", "", ""},
+		}
+		var got [][4]string
+		for rows.Next() {
+			var r [4]string
+			if err := rows.Scan(&r[0], &r[1], &r[2], &r[3]); err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, r)
+		}
+		if err := rows.Err(); err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != len(want) {
+			t.Fatalf("got %d status updates, want %d", len(got), len(want))
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("status update %d: got (status_time, state, was_online, expires) %q, want %q", i+1, got[i], want[i])
+			}
+		}
+	})
+	t.Run("message dates in UTC", func(t *testing.T) {
+		var date string
+		if err := d.queryRow("SELECT date || '' FROM messages WHERE message_id = 1").Scan(&date); err != nil {
+			t.Fatal(err)
+		}
+		if want := utc(t, "2024-05-01 16:03:22"); date != want {
+			t.Errorf("messages.date = %q, want %q", date, want)
+		}
+	})
+	t.Run("default times in RFC 3339", func(t *testing.T) {
+		// Rows written after migrating as well as the legacy ones
+		must(t, d.SaveMessage(100, "Chan", "chan", 2, 0, "2024-05-02T08:00:00Z", "later", ""))
+		must(t, d.SaveUserStatusUpdate(UserStatusUpdate{UserID: 43, Username: "janedoe", Status: "online", State: "online", StatusTime: "2024-05-02T08:00:00Z"}))
+		must(t, d.AddMonitoredUser(43, "janedoe", "Jane", "Doe"))
+		must(t, d.AddMessageFilter("later", "lottery", "keyword", "ignore", 1))
+		for _, c := range []struct{ table, column string }{
+			{"messages", "created_at"},
+			{"user_status_updates", "created_at"},
+			{"monitored_users", "added_at"},
+			{"message_filters", "created_at"},
+		} {
+			rows, err := d.query("SELECT " + c.column + " || '' FROM " + c.table)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for rows.Next() {
+				var value string
+				if err := rows.Scan(&value); err != nil {
+					t.Fatal(err)
+				}
+				if _, err := time.Parse(time.RFC3339, value); err != nil || !strings.HasSuffix(value, "Z") {
+					t.Errorf("%s.%s = %q, want UTC RFC 3339", c.table, c.column, value)
+				}
+			}
+			rows.Close()
+		}
+	})
+}
@@ -0,0 +1,150 @@
+-- Baseline schema, equivalent to migrations/sqlite/0001_baseline.sql.
+-- Timestamps are stored as 'YYYY-MM-DD HH:MM:SS' UTC text, the same format
+-- SQLite uses, so queries and callers don't depend on the backend.
+
+CREATE OR REPLACE FUNCTION teleslurp_now() RETURNS TEXT AS $$
+	SELECT to_char(now() AT TIME ZONE 'UTC', 'YYYY-MM-DD HH24:MI:SS')
+$$ LANGUAGE SQL STABLE;
+
+-- Messages table
+CREATE TABLE IF NOT EXISTS messages (
+	id BIGSERIAL PRIMARY KEY,
+	channel_id BIGINT NOT NULL,
+	channel_title TEXT NOT NULL,
+	channel_username TEXT,
+	message_id INTEGER NOT NULL,
+	date TEXT NOT NULL,
+	message TEXT,
+	url TEXT,
+	created_at TEXT DEFAULT teleslurp_now(),
+	UNIQUE(channel_id, message_id)
+);
+
+-- Indicators table, typed values extracted from stored messages
+CREATE TABLE IF NOT EXISTS indicators (
+	id BIGSERIAL PRIMARY KEY,
+	channel_id BIGINT NOT NULL,
+	message_id INTEGER NOT NULL,
+	type TEXT NOT NULL, -- 'url', 'telegram_link', 'mention', 'email', 'phone', 'btc', ...
+	value TEXT NOT NULL,
+	created_at TEXT DEFAULT teleslurp_now(),
+	UNIQUE(channel_id, message_id, type, value)
+);
+
+-- User status updates table
+CREATE TABLE IF NOT EXISTS user_status_updates (
+	id BIGSERIAL PRIMARY KEY,
+	user_id BIGINT NOT NULL,
+	username TEXT,
+	first_name TEXT,
+	last_name TEXT,
+	status TEXT NOT NULL,
+	status_time TEXT NOT NULL,
+	created_at TEXT DEFAULT teleslurp_now()
+);
+
+-- Monitored users table
+CREATE TABLE IF NOT EXISTS monitored_users (
+	id BIGSERIAL PRIMARY KEY,
+	user_id BIGINT UNIQUE NOT NULL,
+	username TEXT,
+	first_name TEXT,
+	last_name TEXT,
+	added_at TEXT DEFAULT teleslurp_now()
+);
+
+-- Channel metadata table
+CREATE TABLE IF NOT EXISTS channel_metadata (
+	id BIGSERIAL PRIMARY KEY,
+	channel_id BIGINT UNIQUE NOT NULL,
+	title TEXT NOT NULL,
+	username TEXT,
+	member_count INTEGER,
+	is_public BOOLEAN,
+	updated_at TEXT DEFAULT teleslurp_now()
+);
+
+-- Message filters table
+CREATE TABLE IF NOT EXISTS message_filters (
+	id SERIAL PRIMARY KEY,
+	name TEXT NOT NULL,
+	pattern TEXT NOT NULL,
+	type TEXT NOT NULL, -- 'keyword', 'keyword_list', 'indicator', 'regex', 'user', 'channel', 'length'
+	action TEXT NOT NULL, -- 'forward', 'ignore', 'highlight'
+	priority INTEGER DEFAULT 0,
+	enabled BOOLEAN DEFAULT TRUE,
+	created_at TEXT DEFAULT teleslurp_now()
+);
+
+-- Filter options table, per-filter settings such as matching modes
+CREATE TABLE IF NOT EXISTS filter_options (
+	filter_id INTEGER NOT NULL,
+	name TEXT NOT NULL,
+	value TEXT NOT NULL,
+	PRIMARY KEY (filter_id, name)
+);
+
+-- Filter keywords table, terms of keyword list filters
+CREATE TABLE IF NOT EXISTS filter_keywords (
+	id BIGSERIAL PRIMARY KEY,
+	filter_id INTEGER NOT NULL,
+	term TEXT NOT NULL,
+	created_at TEXT DEFAULT teleslurp_now(),
+	UNIQUE(filter_id, term)
+);
+
+-- Filter statistics table
+CREATE TABLE IF NOT EXISTS filter_stats (
+	filter_id INTEGER PRIMARY KEY,
+	match_count BIGINT NOT NULL DEFAULT 0,
+	last_match_at TEXT
+);
+
+-- Filter audit log, one row per evaluated message
+CREATE TABLE IF NOT EXISTS filter_audit (
+	id BIGSERIAL PRIMARY KEY,
+	channel_id BIGINT NOT NULL,
+	message_id INTEGER NOT NULL,
+	user_id BIGINT,
+	filter_id INTEGER, -- NULL when no filter matched
+	filter_name TEXT,
+	action TEXT NOT NULL, -- 'forward', 'ignored', 'highlight'
+	created_at TEXT DEFAULT teleslurp_now()
+);
+
+-- Change versions table, bumped by triggers so long-running processes
+-- can cheaply detect edits made from another process
+CREATE TABLE IF NOT EXISTS change_versions (
+	name TEXT PRIMARY KEY,
+	version BIGINT NOT NULL DEFAULT 0
+);
+
+INSERT INTO change_versions (name, version) VALUES ('message_filters', 0) ON CONFLICT DO NOTHING;
+
+-- Bumps the change version named by the trigger's first argument
+CREATE OR REPLACE FUNCTION bump_change_version() RETURNS trigger AS $$
+BEGIN
+	UPDATE change_versions SET version = version + 1 WHERE name = TG_ARGV[0];
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_message_filters_change ON message_filters;
+CREATE TRIGGER trg_message_filters_change AFTER INSERT OR UPDATE OR DELETE ON message_filters
+	FOR EACH STATEMENT EXECUTE FUNCTION bump_change_version('message_filters');
+
+DROP TRIGGER IF EXISTS trg_filter_keywords_change ON filter_keywords;
+CREATE TRIGGER trg_filter_keywords_change AFTER INSERT OR DELETE ON filter_keywords
+	FOR EACH STATEMENT EXECUTE FUNCTION bump_change_version('message_filters');
+
+-- Indices for better performance
+CREATE INDEX IF NOT EXISTS idx_messages_channel_id ON messages(channel_id);
+CREATE INDEX IF NOT EXISTS idx_messages_date ON messages(date);
+CREATE INDEX IF NOT EXISTS idx_indicators_message ON indicators(channel_id, message_id);
+CREATE INDEX IF NOT EXISTS idx_indicators_type_value ON indicators(type, value);
+CREATE INDEX IF NOT EXISTS idx_user_status_user_id ON user_status_updates(user_id);
+CREATE INDEX IF NOT EXISTS idx_user_status_time ON user_status_updates(status_time);
+CREATE INDEX IF NOT EXISTS idx_filters_type ON message_filters(type);
+CREATE INDEX IF NOT EXISTS idx_filters_enabled ON message_filters(enabled);
+CREATE INDEX IF NOT EXISTS idx_filter_audit_message ON filter_audit(channel_id, message_id);
+CREATE INDEX IF NOT EXISTS idx_filter_audit_filter_id ON filter_audit(filter_id);
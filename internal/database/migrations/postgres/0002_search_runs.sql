@@ -0,0 +1,67 @@
+-- Search runs: every `teleslurp search` is recorded so repeated investigations
+-- of the same user accumulate history that can be compared over time.
+
+-- Who sent a stored message, when known
+ALTER TABLE messages ADD COLUMN sender_id BIGINT;
+
+-- Search runs table
+CREATE TABLE search_runs (
+	id BIGSERIAL PRIMARY KEY,
+	query TEXT NOT NULL,
+	source TEXT NOT NULL, -- 'tgscan', 'input_file'
+	user_id BIGINT,
+	username TEXT,
+	first_name TEXT,
+	last_name TEXT,
+	parameters TEXT, -- JSON encoded search flags
+	credits INTEGER NOT NULL DEFAULT 0,
+	status TEXT NOT NULL DEFAULT 'running', -- 'running', 'completed', 'failed'
+	error TEXT,
+	started_at TEXT NOT NULL DEFAULT teleslurp_now(),
+	finished_at TEXT
+);
+
+-- Username history reported by TGScan for the run's target
+CREATE TABLE search_run_usernames (
+	run_id BIGINT NOT NULL,
+	username TEXT NOT NULL,
+	date TEXT NOT NULL DEFAULT '',
+	PRIMARY KEY (run_id, username, date)
+);
+
+-- Groups reported by TGScan for the run's target
+CREATE TABLE search_run_groups (
+	run_id BIGINT NOT NULL,
+	group_id BIGINT,
+	username TEXT,
+	title TEXT,
+	date_updated TEXT
+);
+
+-- Snapshot of every channel searched during a run
+CREATE TABLE search_run_channels (
+	run_id BIGINT NOT NULL,
+	channel_id BIGINT NOT NULL,
+	title TEXT,
+	username TEXT,
+	link TEXT,
+	admins TEXT,
+	is_admin BOOLEAN NOT NULL DEFAULT FALSE,
+	member_count INTEGER,
+	message_count INTEGER NOT NULL DEFAULT 0,
+	first_message_date TEXT,
+	PRIMARY KEY (run_id, channel_id)
+);
+
+-- Messages of the target found during a run
+CREATE TABLE search_run_messages (
+	run_id BIGINT NOT NULL,
+	channel_id BIGINT NOT NULL,
+	message_id INTEGER NOT NULL,
+	PRIMARY KEY (run_id, channel_id, message_id)
+);
+
+CREATE INDEX idx_messages_sender_id ON messages(sender_id);
+CREATE INDEX idx_search_runs_user_id ON search_runs(user_id);
+CREATE INDEX idx_search_runs_username ON search_runs(username);
+CREATE INDEX idx_search_run_groups_run_id ON search_run_groups(run_id);
@@ -0,0 +1,6 @@
+-- Let a running monitor notice users added with 'teleslurp watch user'
+INSERT INTO change_versions (name, version) VALUES ('monitored_users', 0) ON CONFLICT DO NOTHING;
+
+DROP TRIGGER IF EXISTS trg_monitored_users_change ON monitored_users;
+CREATE TRIGGER trg_monitored_users_change AFTER INSERT OR UPDATE OR DELETE ON monitored_users
+	FOR EACH STATEMENT EXECUTE FUNCTION bump_change_version('monitored_users');
@@ -0,0 +1,24 @@
+-- Structured status fields. was_online and expires use the same local time
+-- format as status_time; the free text status column is kept for display.
+ALTER TABLE user_status_updates ADD COLUMN state TEXT;
+ALTER TABLE user_status_updates ADD COLUMN was_online TEXT;
+ALTER TABLE user_status_updates ADD COLUMN expires TEXT;
+
+-- Backfill from the text written by earlier versions, e.g.
+-- "online (expires: 2024-01-02 15:04:05 +0100 CET)"
+UPDATE user_status_updates SET state = CASE
+	WHEN status LIKE 'online (expires: %' THEN 'online'
+	WHEN status LIKE 'offline (was online: %' THEN 'offline'
+	WHEN status = 'recently active' THEN 'recently'
+	WHEN status = 'last seen within a week' THEN 'last_week'
+	WHEN status = 'last seen within a month' THEN 'last_month'
+	ELSE 'unknown'
+END;
+
+UPDATE user_status_updates SET expires = substr(status, 18, 19)
+WHERE status LIKE 'online (expires: %';
+
+UPDATE user_status_updates SET was_online = substr(status, 22, 19)
+WHERE status LIKE 'offline (was online: %';
+
+CREATE INDEX IF NOT EXISTS idx_user_status_user_time ON user_status_updates(user_id, status_time);
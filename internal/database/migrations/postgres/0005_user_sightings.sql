@@ -0,0 +1,20 @@
+-- Messages posted by watched users anywhere the account can see
+CREATE TABLE IF NOT EXISTS user_sightings (
+	id BIGSERIAL PRIMARY KEY,
+	user_id BIGINT NOT NULL,
+	username TEXT,
+	first_name TEXT,
+	last_name TEXT,
+	chat_type TEXT NOT NULL, -- 'channel', 'supergroup', 'group', 'private'
+	chat_id BIGINT NOT NULL,
+	chat_title TEXT,
+	chat_username TEXT,
+	message_id BIGINT NOT NULL,
+	message_date TEXT NOT NULL,
+	message TEXT,
+	url TEXT,
+	created_at TEXT DEFAULT teleslurp_now(),
+	UNIQUE(chat_type, chat_id, message_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_sightings_user ON user_sightings(user_id, message_date);
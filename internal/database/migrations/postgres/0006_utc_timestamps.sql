@@ -0,0 +1,75 @@
+-- Store every time as UTC RFC 3339 ("2024-01-02T15:04:05Z"), equivalent to
+-- migrations/sqlite/0006_utc_timestamps.sql. Message and status times were
+-- written in the local time of the monitor as "YYYY-MM-DD HH:MM:SS" and are
+-- converted from the session's TimeZone, so connect with the monitor's zone
+-- (e.g. options=-ctimezone=Europe/Berlin in the DSN) if the server's differs.
+-- Times teleslurp wrote in UTC only change format, as do the created_at and
+-- added_at columns teleslurp_now() filled in.
+
+-- Searches recorded channels without messages with Go's zero time
+UPDATE search_run_channels SET first_message_date = NULL
+WHERE first_message_date LIKE '0001-01-01%';
+
+-- Local times
+UPDATE messages SET date = to_char(date::timestamptz AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"')
+WHERE date ~ '^\d{4}-\d{2}-\d{2}[ T]\d{2}:\d{2}:\d{2}' AND date NOT LIKE '%Z';
+
+UPDATE user_status_updates SET status_time = to_char(status_time::timestamptz AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"')
+WHERE status_time ~ '^\d{4}-\d{2}-\d{2}[ T]\d{2}:\d{2}:\d{2}' AND status_time NOT LIKE '%Z';
+
+UPDATE user_status_updates SET was_online = to_char(was_online::timestamptz AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"')
+WHERE was_online ~ '^\d{4}-\d{2}-\d{2}[ T]\d{2}:\d{2}:\d{2}' AND was_online NOT LIKE '%Z';
+
+UPDATE user_status_updates SET expires = to_char(expires::timestamptz AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"')
+WHERE expires ~ '^\d{4}-\d{2}-\d{2}[ T]\d{2}:\d{2}:\d{2}' AND expires NOT LIKE '%Z';
+
+UPDATE user_sightings SET message_date = to_char(message_date::timestamptz AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"')
+WHERE message_date ~ '^\d{4}-\d{2}-\d{2}[ T]\d{2}:\d{2}:\d{2}' AND message_date NOT LIKE '%Z';
+
+UPDATE search_run_channels SET first_message_date = to_char(first_message_date::timestamptz AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"')
+WHERE first_message_date ~ '^\d{4}-\d{2}-\d{2}[ T]\d{2}:\d{2}:\d{2}' AND first_message_date NOT LIKE '%Z';
+
+-- UTC times
+UPDATE search_runs SET started_at = to_char(started_at::timestamp, 'YYYY-MM-DD"T"HH24:MI:SS"Z"')
+WHERE started_at ~ '^\d{4}-\d{2}-\d{2}[ T]\d{2}:\d{2}:\d{2}' AND started_at NOT LIKE '%Z';
+
+UPDATE search_runs SET finished_at = to_char(finished_at::timestamp, 'YYYY-MM-DD"T"HH24:MI:SS"Z"')
+WHERE finished_at ~ '^\d{4}-\d{2}-\d{2}[ T]\d{2}:\d{2}:\d{2}' AND finished_at NOT LIKE '%Z';
+
+UPDATE channel_metadata SET updated_at = to_char(updated_at::timestamp, 'YYYY-MM-DD"T"HH24:MI:SS"Z"')
+WHERE updated_at ~ '^\d{4}-\d{2}-\d{2}[ T]\d{2}:\d{2}:\d{2}' AND updated_at NOT LIKE '%Z';
+
+UPDATE filter_stats SET last_match_at = to_char(last_match_at::timestamp, 'YYYY-MM-DD"T"HH24:MI:SS"Z"')
+WHERE last_match_at ~ '^\d{4}-\d{2}-\d{2}[ T]\d{2}:\d{2}:\d{2}' AND last_match_at NOT LIKE '%Z';
+
+UPDATE filter_audit SET created_at = to_char(created_at::timestamp, 'YYYY-MM-DD"T"HH24:MI:SS"Z"')
+WHERE created_at ~ '^\d{4}-\d{2}-\d{2}[ T]\d{2}:\d{2}:\d{2}' AND created_at NOT LIKE '%Z';
+
+UPDATE schema_version SET applied_at = to_char(applied_at::timestamp, 'YYYY-MM-DD"T"HH24:MI:SS"Z"')
+WHERE applied_at ~ '^\d{4}-\d{2}-\d{2}[ T]\d{2}:\d{2}:\d{2}' AND applied_at NOT LIKE '%Z';
+
+UPDATE messages SET created_at = to_char(created_at::timestamp, 'YYYY-MM-DD"T"HH24:MI:SS"Z"')
+WHERE created_at ~ '^\d{4}-\d{2}-\d{2}[ T]\d{2}:\d{2}:\d{2}' AND created_at NOT LIKE '%Z';
+
+UPDATE indicators SET created_at = to_char(created_at::timestamp, 'YYYY-MM-DD"T"HH24:MI:SS"Z"')
+WHERE created_at ~ '^\d{4}-\d{2}-\d{2}[ T]\d{2}:\d{2}:\d{2}' AND created_at NOT LIKE '%Z';
+
+UPDATE user_status_updates SET created_at = to_char(created_at::timestamp, 'YYYY-MM-DD"T"HH24:MI:SS"Z"')
+WHERE created_at ~ '^\d{4}-\d{2}-\d{2}[ T]\d{2}:\d{2}:\d{2}' AND created_at NOT LIKE '%Z';
+
+UPDATE monitored_users SET added_at = to_char(added_at::timestamp, 'YYYY-MM-DD"T"HH24:MI:SS"Z"')
+WHERE added_at ~ '^\d{4}-\d{2}-\d{2}[ T]\d{2}:\d{2}:\d{2}' AND added_at NOT LIKE '%Z';
+
+UPDATE message_filters SET created_at = to_char(created_at::timestamp, 'YYYY-MM-DD"T"HH24:MI:SS"Z"')
+WHERE created_at ~ '^\d{4}-\d{2}-\d{2}[ T]\d{2}:\d{2}:\d{2}' AND created_at NOT LIKE '%Z';
+
+UPDATE filter_keywords SET created_at = to_char(created_at::timestamp, 'YYYY-MM-DD"T"HH24:MI:SS"Z"')
+WHERE created_at ~ '^\d{4}-\d{2}-\d{2}[ T]\d{2}:\d{2}:\d{2}' AND created_at NOT LIKE '%Z';
+
+UPDATE user_sightings SET created_at = to_char(created_at::timestamp, 'YYYY-MM-DD"T"HH24:MI:SS"Z"')
+WHERE created_at ~ '^\d{4}-\d{2}-\d{2}[ T]\d{2}:\d{2}:\d{2}' AND created_at NOT LIKE '%Z';
+
+-- Defaults of created_at and the like
+CREATE OR REPLACE FUNCTION teleslurp_now() RETURNS TEXT AS $$
+	SELECT to_char(now() AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"')
+$$ LANGUAGE SQL STABLE;
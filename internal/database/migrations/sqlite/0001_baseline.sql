@@ -0,0 +1,154 @@
+-- Baseline schema. Every statement is idempotent so databases created before
+-- versioned migrations existed are adopted without changes.
+
+-- Messages table
+CREATE TABLE IF NOT EXISTS messages (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	channel_id INTEGER NOT NULL,
+	channel_title TEXT NOT NULL,
+	channel_username TEXT,
+	message_id INTEGER NOT NULL,
+	date DATETIME NOT NULL,
+	message TEXT,
+	url TEXT,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE(channel_id, message_id)
+);
+
+-- Indicators table, typed values extracted from stored messages
+CREATE TABLE IF NOT EXISTS indicators (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	channel_id INTEGER NOT NULL,
+	message_id INTEGER NOT NULL,
+	type TEXT NOT NULL, -- 'url', 'telegram_link', 'mention', 'email', 'phone', 'btc', ...
+	value TEXT NOT NULL,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE(channel_id, message_id, type, value)
+);
+
+-- User status updates table
+CREATE TABLE IF NOT EXISTS user_status_updates (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL,
+	username TEXT,
+	first_name TEXT,
+	last_name TEXT,
+	status TEXT NOT NULL,
+	status_time DATETIME NOT NULL,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Monitored users table
+CREATE TABLE IF NOT EXISTS monitored_users (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER UNIQUE NOT NULL,
+	username TEXT,
+	first_name TEXT,
+	last_name TEXT,
+	added_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Channel metadata table
+CREATE TABLE IF NOT EXISTS channel_metadata (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	channel_id INTEGER UNIQUE NOT NULL,
+	title TEXT NOT NULL,
+	username TEXT,
+	member_count INTEGER,
+	is_public BOOLEAN,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Message filters table
+CREATE TABLE IF NOT EXISTS message_filters (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL,
+	pattern TEXT NOT NULL,
+	type TEXT NOT NULL, -- 'keyword', 'keyword_list', 'indicator', 'regex', 'user', 'channel', 'length'
+	action TEXT NOT NULL, -- 'forward', 'ignore', 'highlight'
+	priority INTEGER DEFAULT 0,
+	enabled BOOLEAN DEFAULT 1,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Filter options table, per-filter settings such as matching modes
+CREATE TABLE IF NOT EXISTS filter_options (
+	filter_id INTEGER NOT NULL,
+	name TEXT NOT NULL,
+	value TEXT NOT NULL,
+	PRIMARY KEY (filter_id, name)
+);
+
+-- Filter keywords table, terms of keyword list filters
+CREATE TABLE IF NOT EXISTS filter_keywords (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	filter_id INTEGER NOT NULL,
+	term TEXT NOT NULL,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE(filter_id, term)
+);
+
+-- Filter statistics table
+CREATE TABLE IF NOT EXISTS filter_stats (
+	filter_id INTEGER PRIMARY KEY,
+	match_count INTEGER NOT NULL DEFAULT 0,
+	last_match_at DATETIME
+);
+
+-- Filter audit log, one row per evaluated message
+CREATE TABLE IF NOT EXISTS filter_audit (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	channel_id INTEGER NOT NULL,
+	message_id INTEGER NOT NULL,
+	user_id INTEGER,
+	filter_id INTEGER, -- NULL when no filter matched
+	filter_name TEXT,
+	action TEXT NOT NULL, -- 'forward', 'ignored', 'highlight'
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Change versions table, bumped by triggers so long-running processes
+-- can cheaply detect edits made from another process
+CREATE TABLE IF NOT EXISTS change_versions (
+	name TEXT PRIMARY KEY,
+	version INTEGER NOT NULL DEFAULT 0
+);
+
+INSERT OR IGNORE INTO change_versions (name, version) VALUES ('message_filters', 0);
+
+CREATE TRIGGER IF NOT EXISTS trg_message_filters_insert AFTER INSERT ON message_filters
+BEGIN
+	UPDATE change_versions SET version = version + 1 WHERE name = 'message_filters';
+END;
+
+CREATE TRIGGER IF NOT EXISTS trg_message_filters_update AFTER UPDATE ON message_filters
+BEGIN
+	UPDATE change_versions SET version = version + 1 WHERE name = 'message_filters';
+END;
+
+CREATE TRIGGER IF NOT EXISTS trg_message_filters_delete AFTER DELETE ON message_filters
+BEGIN
+	UPDATE change_versions SET version = version + 1 WHERE name = 'message_filters';
+END;
+
+CREATE TRIGGER IF NOT EXISTS trg_filter_keywords_insert AFTER INSERT ON filter_keywords
+BEGIN
+	UPDATE change_versions SET version = version + 1 WHERE name = 'message_filters';
+END;
+
+CREATE TRIGGER IF NOT EXISTS trg_filter_keywords_delete AFTER DELETE ON filter_keywords
+BEGIN
+	UPDATE change_versions SET version = version + 1 WHERE name = 'message_filters';
+END;
+
+-- Indices for better performance
+CREATE INDEX IF NOT EXISTS idx_messages_channel_id ON messages(channel_id);
+CREATE INDEX IF NOT EXISTS idx_messages_date ON messages(date);
+CREATE INDEX IF NOT EXISTS idx_indicators_message ON indicators(channel_id, message_id);
+CREATE INDEX IF NOT EXISTS idx_indicators_type_value ON indicators(type, value);
+CREATE INDEX IF NOT EXISTS idx_user_status_user_id ON user_status_updates(user_id);
+CREATE INDEX IF NOT EXISTS idx_user_status_time ON user_status_updates(status_time);
+CREATE INDEX IF NOT EXISTS idx_filters_type ON message_filters(type);
+CREATE INDEX IF NOT EXISTS idx_filters_enabled ON message_filters(enabled);
+CREATE INDEX IF NOT EXISTS idx_filter_audit_message ON filter_audit(channel_id, message_id);
+CREATE INDEX IF NOT EXISTS idx_filter_audit_filter_id ON filter_audit(filter_id);
@@ -0,0 +1,17 @@
+-- Let a running monitor notice users added with 'teleslurp watch user'
+INSERT OR IGNORE INTO change_versions (name, version) VALUES ('monitored_users', 0);
+
+CREATE TRIGGER IF NOT EXISTS trg_monitored_users_insert AFTER INSERT ON monitored_users
+BEGIN
+	UPDATE change_versions SET version = version + 1 WHERE name = 'monitored_users';
+END;
+
+CREATE TRIGGER IF NOT EXISTS trg_monitored_users_update AFTER UPDATE ON monitored_users
+BEGIN
+	UPDATE change_versions SET version = version + 1 WHERE name = 'monitored_users';
+END;
+
+CREATE TRIGGER IF NOT EXISTS trg_monitored_users_delete AFTER DELETE ON monitored_users
+BEGIN
+	UPDATE change_versions SET version = version + 1 WHERE name = 'monitored_users';
+END;
@@ -0,0 +1,71 @@
+-- Store every time as UTC RFC 3339 ("2024-01-02T15:04:05Z"), so times sort
+-- and compare the same whatever the zone of the machine that wrote them.
+-- Message and status times were written in the local time of this machine
+-- as "YYYY-MM-DD HH:MM:SS"; SQLite's 'utc' modifier converts them from the
+-- local zone. Times teleslurp wrote in UTC only change format, as do the
+-- created_at and added_at columns CURRENT_TIMESTAMP filled in. SQLite can't
+-- change a column default in place, so teleslurp now writes those columns
+-- itself.
+
+-- Searches recorded channels without messages with Go's zero time
+UPDATE search_run_channels SET first_message_date = NULL
+WHERE first_message_date LIKE '0001-01-01%';
+
+-- Local times
+UPDATE messages SET date = strftime('%Y-%m-%dT%H:%M:%SZ', date, 'utc')
+WHERE date NOT LIKE '%Z' AND strftime('%Y-%m-%dT%H:%M:%SZ', date, 'utc') IS NOT NULL;
+
+UPDATE user_status_updates SET status_time = strftime('%Y-%m-%dT%H:%M:%SZ', status_time, 'utc')
+WHERE status_time NOT LIKE '%Z' AND strftime('%Y-%m-%dT%H:%M:%SZ', status_time, 'utc') IS NOT NULL;
+
+UPDATE user_status_updates SET was_online = strftime('%Y-%m-%dT%H:%M:%SZ', was_online, 'utc')
+WHERE was_online NOT LIKE '%Z' AND strftime('%Y-%m-%dT%H:%M:%SZ', was_online, 'utc') IS NOT NULL;
+
+UPDATE user_status_updates SET expires = strftime('%Y-%m-%dT%H:%M:%SZ', expires, 'utc')
+WHERE expires NOT LIKE '%Z' AND strftime('%Y-%m-%dT%H:%M:%SZ', expires, 'utc') IS NOT NULL;
+
+UPDATE user_sightings SET message_date = strftime('%Y-%m-%dT%H:%M:%SZ', message_date, 'utc')
+WHERE message_date NOT LIKE '%Z' AND strftime('%Y-%m-%dT%H:%M:%SZ', message_date, 'utc') IS NOT NULL;
+
+UPDATE search_run_channels SET first_message_date = strftime('%Y-%m-%dT%H:%M:%SZ', first_message_date, 'utc')
+WHERE first_message_date NOT LIKE '%Z' AND strftime('%Y-%m-%dT%H:%M:%SZ', first_message_date, 'utc') IS NOT NULL;
+
+-- UTC times
+UPDATE search_runs SET started_at = strftime('%Y-%m-%dT%H:%M:%SZ', started_at)
+WHERE started_at NOT LIKE '%Z' AND strftime('%Y-%m-%dT%H:%M:%SZ', started_at) IS NOT NULL;
+
+UPDATE search_runs SET finished_at = strftime('%Y-%m-%dT%H:%M:%SZ', finished_at)
+WHERE finished_at NOT LIKE '%Z' AND strftime('%Y-%m-%dT%H:%M:%SZ', finished_at) IS NOT NULL;
+
+UPDATE channel_metadata SET updated_at = strftime('%Y-%m-%dT%H:%M:%SZ', updated_at)
+WHERE updated_at NOT LIKE '%Z' AND strftime('%Y-%m-%dT%H:%M:%SZ', updated_at) IS NOT NULL;
+
+UPDATE filter_stats SET last_match_at = strftime('%Y-%m-%dT%H:%M:%SZ', last_match_at)
+WHERE last_match_at NOT LIKE '%Z' AND strftime('%Y-%m-%dT%H:%M:%SZ', last_match_at) IS NOT NULL;
+
+UPDATE filter_audit SET created_at = strftime('%Y-%m-%dT%H:%M:%SZ', created_at)
+WHERE created_at NOT LIKE '%Z' AND strftime('%Y-%m-%dT%H:%M:%SZ', created_at) IS NOT NULL;
+
+UPDATE schema_version SET applied_at = strftime('%Y-%m-%dT%H:%M:%SZ', applied_at)
+WHERE applied_at NOT LIKE '%Z' AND strftime('%Y-%m-%dT%H:%M:%SZ', applied_at) IS NOT NULL;
+
+UPDATE messages SET created_at = strftime('%Y-%m-%dT%H:%M:%SZ', created_at)
+WHERE created_at NOT LIKE '%Z' AND strftime('%Y-%m-%dT%H:%M:%SZ', created_at) IS NOT NULL;
+
+UPDATE indicators SET created_at = strftime('%Y-%m-%dT%H:%M:%SZ', created_at)
+WHERE created_at NOT LIKE '%Z' AND strftime('%Y-%m-%dT%H:%M:%SZ', created_at) IS NOT NULL;
+
+UPDATE user_status_updates SET created_at = strftime('%Y-%m-%dT%H:%M:%SZ', created_at)
+WHERE created_at NOT LIKE '%Z' AND strftime('%Y-%m-%dT%H:%M:%SZ', created_at) IS NOT NULL;
+
+UPDATE monitored_users SET added_at = strftime('%Y-%m-%dT%H:%M:%SZ', added_at)
+WHERE added_at NOT LIKE '%Z' AND strftime('%Y-%m-%dT%H:%M:%SZ', added_at) IS NOT NULL;
+
+UPDATE message_filters SET created_at = strftime('%Y-%m-%dT%H:%M:%SZ', created_at)
+WHERE created_at NOT LIKE '%Z' AND strftime('%Y-%m-%dT%H:%M:%SZ', created_at) IS NOT NULL;
+
+UPDATE filter_keywords SET created_at = strftime('%Y-%m-%dT%H:%M:%SZ', created_at)
+WHERE created_at NOT LIKE '%Z' AND strftime('%Y-%m-%dT%H:%M:%SZ', created_at) IS NOT NULL;
+
+UPDATE user_sightings SET created_at = strftime('%Y-%m-%dT%H:%M:%SZ', created_at)
+WHERE created_at NOT LIKE '%Z' AND strftime('%Y-%m-%dT%H:%M:%SZ', created_at) IS NOT NULL;
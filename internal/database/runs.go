@@ -0,0 +1,361 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/gnomegl/teleslurp/internal/types"
+)
+
+// Search run statuses
+const (
+	RunStatusRunning   = "running"
+	RunStatusCompleted = "completed"
+	RunStatusFailed    = "failed"
+)
+
+// SearchRun is a single `teleslurp search` invocation
+type SearchRun struct {
+	ID           int64  `json:"id"`
+	Query        string `json:"query"`
+	Source       string `json:"source"` // 'tgscan', 'input_file'
+	UserID       int64  `json:"user_id"`
+	Username     string `json:"username"`
+	FirstName    string `json:"first_name"`
+	LastName     string `json:"last_name"`
+	Parameters   string `json:"parameters"`
+	Credits      int    `json:"credits"`
+	Status       string `json:"status"`
+	Error        string `json:"error,omitempty"`
+	StartedAt    string `json:"started_at"`
+	FinishedAt   string `json:"finished_at,omitempty"`
+	ChannelCount int    `json:"channel_count"`
+	MessageCount int    `json:"message_count"`
+}
+
+// SearchRunChannel is the state of a channel as seen during a search run
+type SearchRunChannel struct {
+	ChannelID        int64  `json:"channel_id"`
+	Title            string `json:"title"`
+	Username         string `json:"username"`
+	Link             string `json:"link"`
+	Admins           string `json:"admins"`
+	IsAdmin          bool   `json:"is_admin"`
+	MemberCount      int    `json:"member_count"`
+	MessageCount     int    `json:"message_count"`
+	FirstMessageDate string `json:"first_message_date"`
+}
+
+// CreateSearchRun records the start of a search run and returns its ID
+func (d *DB) CreateSearchRun(run SearchRun) (int64, error) {
+	var userID interface{}
+	if run.UserID != 0 {
+		userID = run.UserID
+	}
+	var runID int64
+	if err := d.db.QueryRow(d.rebind(`
+		INSERT INTO search_runs (
+			query, source, user_id, username, first_name, last_name, parameters, status, started_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id
+	`), run.Query, run.Source, userID, run.Username, run.FirstName, run.LastName, run.Parameters,
+		RunStatusRunning, timestamp()).Scan(&runID); err != nil {
+		return 0, fmt.Errorf("error creating search run: %w", err)
+	}
+	return runID, nil
+}
+
+// UpdateSearchRunTarget fills in the target user once it has been resolved,
+// keeping previously known values for empty fields
+func (d *DB) UpdateSearchRunTarget(runID, userID int64, username, firstName, lastName string) error {
+	_, err := d.exec(`
+		UPDATE search_runs SET
+			user_id = COALESCE(NULLIF(CAST(? AS BIGINT), 0), user_id),
+			username = COALESCE(NULLIF(?, ''), username),
+			first_name = COALESCE(NULLIF(?, ''), first_name),
+			last_name = COALESCE(NULLIF(?, ''), last_name)
+		WHERE id = ?
+	`, userID, username, firstName, lastName, runID)
+	return err
+}
+
+// SaveSearchRunTGScan records the TGScan profile of the run's target: the
+// user, credits spent, username history and groups
+func (d *DB) SaveSearchRunTGScan(runID int64, resp *types.TGScanResponse) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	user := resp.Result.User
+	if _, err := tx.Exec(d.rebind(`
+		UPDATE search_runs SET
+			user_id = COALESCE(NULLIF(CAST(? AS BIGINT), 0), user_id),
+			username = COALESCE(NULLIF(?, ''), username),
+			first_name = ?,
+			last_name = ?,
+			credits = ?
+		WHERE id = ?
+	`), user.ID, user.Username, user.FirstName, user.LastName, resp.Result.Meta.OpCost, runID); err != nil {
+		return fmt.Errorf("error updating search run: %w", err)
+	}
+
+	for _, h := range resp.Result.UsernameHistory {
+		if _, err := tx.Exec(d.rebind(`
+			INSERT INTO search_run_usernames (run_id, username, date) VALUES (?, ?, ?)
+			ON CONFLICT DO NOTHING
+		`), runID, h.Username, h.Date); err != nil {
+			return fmt.Errorf("error saving username history: %w", err)
+		}
+	}
+
+	for _, g := range resp.Result.Groups {
+		if _, err := tx.Exec(d.rebind(`
+			INSERT INTO search_run_groups (run_id, group_id, username, title, date_updated)
+			VALUES (?, ?, ?, ?, ?)
+		`), runID, g.ID, g.Username, g.Title, g.DateUpdated); err != nil {
+			return fmt.Errorf("error saving group: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// SaveSearchRunChannel records the state of a channel searched during a run
+func (d *DB) SaveSearchRunChannel(runID int64, ch SearchRunChannel) error {
+	_, err := d.exec(`
+		INSERT INTO search_run_channels (
+			run_id, channel_id, title, username, link, admins, is_admin,
+			member_count, message_count, first_message_date
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(run_id, channel_id) DO UPDATE SET
+			title = excluded.title,
+			username = excluded.username,
+			link = excluded.link,
+			admins = excluded.admins,
+			is_admin = excluded.is_admin,
+			member_count = excluded.member_count,
+			message_count = excluded.message_count,
+			first_message_date = excluded.first_message_date
+	`, runID, ch.ChannelID, ch.Title, ch.Username, ch.Link, ch.Admins, ch.IsAdmin,
+		ch.MemberCount, ch.MessageCount, ch.FirstMessageDate)
+	return err
+}
+
+// SaveSearchRunMessage links a stored message to the run that found it
+func (d *DB) SaveSearchRunMessage(runID, channelID int64, messageID int) error {
+	_, err := d.exec(`
+		INSERT INTO search_run_messages (run_id, channel_id, message_id) VALUES (?, ?, ?)
+		ON CONFLICT DO NOTHING
+	`, runID, channelID, messageID)
+	return err
+}
+
+// FinishSearchRun marks a running search run as completed, or failed when
+// runErr is set. Runs that already finished are left untouched.
+func (d *DB) FinishSearchRun(runID int64, runErr error) error {
+	status := RunStatusCompleted
+	var errMsg interface{}
+	if runErr != nil {
+		status = RunStatusFailed
+		errMsg = runErr.Error()
+	}
+	_, err := d.exec(`
+		UPDATE search_runs SET status = ?, error = ?, finished_at = ?
+		WHERE id = ? AND status = ?
+	`, status, errMsg, timestamp(), runID, RunStatusRunning)
+	return err
+}
+
+const searchRunColumns = `
+	r.id, r.query, r.source, COALESCE(r.user_id, 0), COALESCE(r.username, ''),
+	COALESCE(r.first_name, ''), COALESCE(r.last_name, ''), COALESCE(r.parameters, ''),
+	r.credits, r.status, COALESCE(r.error, ''), COALESCE(r.started_at, ''), COALESCE(r.finished_at, ''),
+	(SELECT COUNT(*) FROM search_run_channels c WHERE c.run_id = r.id),
+	(SELECT COUNT(*) FROM search_run_messages m WHERE m.run_id = r.id)`
+
+func scanSearchRun(scanner interface{ Scan(...interface{}) error }) (SearchRun, error) {
+	var r SearchRun
+	err := scanner.Scan(&r.ID, &r.Query, &r.Source, &r.UserID, &r.Username,
+		&r.FirstName, &r.LastName, &r.Parameters,
+		&r.Credits, &r.Status, &r.Error, &r.StartedAt, &r.FinishedAt,
+		&r.ChannelCount, &r.MessageCount)
+	return r, err
+}
+
+// GetSearchRun retrieves a search run by ID
+func (d *DB) GetSearchRun(runID int64) (*SearchRun, error) {
+	row := d.queryRow(`SELECT `+searchRunColumns+` FROM search_runs r WHERE r.id = ?`, runID)
+	r, err := scanSearchRun(row)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("search run %d not found", runID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// ListSearchRuns lists search runs, newest first. A non-empty target
+// restricts the list to runs for that username or user ID.
+func (d *DB) ListSearchRuns(target string, limit int) ([]SearchRun, error) {
+	query := `SELECT ` + searchRunColumns + ` FROM search_runs r`
+	var args []interface{}
+	if target != "" {
+		query += ` WHERE r.query = ? OR r.username = ? OR CAST(r.user_id AS TEXT) = ?`
+		args = append(args, target, target, target)
+	}
+	query += ` ORDER BY r.id DESC`
+	if limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, limit)
+	}
+
+	rows, err := d.query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []SearchRun
+	for rows.Next() {
+		r, err := scanSearchRun(rows)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, r)
+	}
+	return runs, rows.Err()
+}
+
+// SearchRunMessage is a message found during a search run
+type SearchRunMessage struct {
+	ChannelID       int64  `json:"channel_id"`
+	ChannelTitle    string `json:"channel_title"`
+	ChannelUsername string `json:"channel_username"`
+	MessageID       int    `json:"message_id"`
+	Date            string `json:"date"`
+	Message         string `json:"message"`
+	URL             string `json:"url"`
+}
+
+// GetPreviousSearchRun returns the latest completed run for the same user
+// that started before runID, or nil if there is none. Runs are matched by
+// user ID, or by username when either run has no ID.
+func (d *DB) GetPreviousSearchRun(runID int64) (*SearchRun, error) {
+	row := d.queryRow(`
+		SELECT `+searchRunColumns+`
+		FROM search_runs r, search_runs cur
+		WHERE cur.id = ? AND r.id < cur.id AND r.status = ?
+			AND CASE
+				WHEN r.user_id IS NOT NULL AND cur.user_id IS NOT NULL THEN r.user_id = cur.user_id
+				ELSE cur.username <> '' AND LOWER(r.username) = LOWER(cur.username)
+			END
+		ORDER BY r.id DESC
+		LIMIT 1
+	`, runID, RunStatusCompleted)
+	r, err := scanSearchRun(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// GetSearchRunUsernames retrieves the TGScan username history recorded for a run
+func (d *DB) GetSearchRunUsernames(runID int64) ([]types.UsernameHistory, error) {
+	rows, err := d.query(`
+		SELECT username, COALESCE(date, '') FROM search_run_usernames WHERE run_id = ? ORDER BY date
+	`, runID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var history []types.UsernameHistory
+	for rows.Next() {
+		var h types.UsernameHistory
+		if err := rows.Scan(&h.Username, &h.Date); err != nil {
+			return nil, err
+		}
+		history = append(history, h)
+	}
+	return history, rows.Err()
+}
+
+// GetSearchRunGroups retrieves the TGScan groups recorded for a run
+func (d *DB) GetSearchRunGroups(runID int64) ([]types.Group, error) {
+	rows, err := d.query(`
+		SELECT COALESCE(group_id, 0), COALESCE(username, ''), COALESCE(title, ''), COALESCE(date_updated, '')
+		FROM search_run_groups WHERE run_id = ? ORDER BY title
+	`, runID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var groups []types.Group
+	for rows.Next() {
+		var g types.Group
+		if err := rows.Scan(&g.ID, &g.Username, &g.Title, &g.DateUpdated); err != nil {
+			return nil, err
+		}
+		groups = append(groups, g)
+	}
+	return groups, rows.Err()
+}
+
+// GetSearchRunChannels retrieves the channel snapshots recorded for a run
+func (d *DB) GetSearchRunChannels(runID int64) ([]SearchRunChannel, error) {
+	rows, err := d.query(`
+		SELECT channel_id, COALESCE(title, ''), COALESCE(username, ''), COALESCE(link, ''),
+			COALESCE(admins, ''), is_admin, COALESCE(member_count, 0), message_count,
+			COALESCE(first_message_date, '')
+		FROM search_run_channels WHERE run_id = ? ORDER BY title
+	`, runID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var channels []SearchRunChannel
+	for rows.Next() {
+		var c SearchRunChannel
+		if err := rows.Scan(&c.ChannelID, &c.Title, &c.Username, &c.Link, &c.Admins, &c.IsAdmin,
+			&c.MemberCount, &c.MessageCount, &c.FirstMessageDate); err != nil {
+			return nil, err
+		}
+		channels = append(channels, c)
+	}
+	return channels, rows.Err()
+}
+
+// GetSearchRunMessages retrieves the messages found during a run
+func (d *DB) GetSearchRunMessages(runID int64) ([]SearchRunMessage, error) {
+	rows, err := d.query(`
+		SELECT l.channel_id, COALESCE(m.channel_title, ''), COALESCE(m.channel_username, ''), l.message_id,
+			COALESCE(m.date, ''), COALESCE(m.message, ''), COALESCE(m.url, '')
+		FROM search_run_messages l
+		LEFT JOIN messages m ON m.channel_id = l.channel_id AND m.message_id = l.message_id
+		WHERE l.run_id = ?
+		ORDER BY m.date, l.channel_id, l.message_id
+	`, runID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []SearchRunMessage
+	for rows.Next() {
+		var m SearchRunMessage
+		if err := rows.Scan(&m.ChannelID, &m.ChannelTitle, &m.ChannelUsername, &m.MessageID,
+			&m.Date, &m.Message, &m.URL); err != nil {
+			return nil, err
+		}
+		messages = append(messages, m)
+	}
+	return messages, rows.Err()
+}
@@ -0,0 +1,166 @@
+package database
+
+import (
+	"database/sql"
+	"strings"
+)
+
+// User status states, from the Telegram UserStatus types
+const (
+	StatusOnline    = "online"
+	StatusOffline   = "offline"
+	StatusRecently  = "recently"
+	StatusLastWeek  = "last_week"
+	StatusLastMonth = "last_month"
+	StatusUnknown   = "unknown"
+)
+
+// UserStatusUpdate is a status change of a watched user. StatusTime, WasOnline
+// and Expires are UTC RFC 3339; WasOnline is only set for offline updates and
+// Expires only for online ones.
+type UserStatusUpdate struct {
+	ID         int64  `json:"id"`
+	UserID     int64  `json:"user_id"`
+	Username   string `json:"username,omitempty"`
+	FirstName  string `json:"first_name,omitempty"`
+	LastName   string `json:"last_name,omitempty"`
+	Status     string `json:"status"`
+	State      string `json:"state"`
+	WasOnline  string `json:"was_online,omitempty"`
+	Expires    string `json:"expires,omitempty"`
+	StatusTime string `json:"status_time"`
+}
+
+// SaveUserStatusUpdate saves a user status update to the database
+func (d *DB) SaveUserStatusUpdate(u UserStatusUpdate) error {
+	return d.saveUserStatusUpdate(d.db, u)
+}
+
+func (d *DB) saveUserStatusUpdate(e execer, u UserStatusUpdate) error {
+	_, err := d.execOn(e, `
+		INSERT INTO user_status_updates (
+			user_id, username, first_name, last_name, status, state,
+			was_online, expires, status_time, created_at
+		) VALUES (?, ?, ?, ?, ?, ?, NULLIF(?, ''), NULLIF(?, ''), ?, ?)
+	`, u.UserID, u.Username, u.FirstName, u.LastName, u.Status, u.State,
+		u.WasOnline, u.Expires, u.StatusTime, timestamp())
+	return err
+}
+
+// GetUserStatusHistory returns the status updates of a user in chronological
+// order, optionally only those at or after since
+func (d *DB) GetUserStatusHistory(userID int64, since string) ([]UserStatusUpdate, error) {
+	rows, err := d.query(`
+		SELECT id, user_id, COALESCE(username, ''), COALESCE(first_name, ''),
+			COALESCE(last_name, ''), status, COALESCE(state, ''),
+			COALESCE(was_online, ''), COALESCE(expires, ''), COALESCE(status_time, '')
+		FROM user_status_updates
+		WHERE user_id = ? AND (? = '' OR status_time >= ?)
+		ORDER BY status_time, id
+	`, userID, since, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var history []UserStatusUpdate
+	for rows.Next() {
+		var u UserStatusUpdate
+		if err := rows.Scan(&u.ID, &u.UserID, &u.Username, &u.FirstName, &u.LastName,
+			&u.Status, &u.State, &u.WasOnline, &u.Expires, &u.StatusTime); err != nil {
+			return nil, err
+		}
+		history = append(history, u)
+	}
+	return history, rows.Err()
+}
+
+// FindStatusUserID returns the user ID of the most recent status update or
+// monitored user with the given username
+func (d *DB) FindStatusUserID(username string) (int64, error) {
+	username = strings.TrimPrefix(username, "@")
+
+	var userID int64
+	err := d.queryRow(`
+		SELECT user_id FROM user_status_updates
+		WHERE LOWER(username) = LOWER(?)
+		ORDER BY id DESC
+		LIMIT 1
+	`, username).Scan(&userID)
+	if err != sql.ErrNoRows {
+		return userID, err
+	}
+
+	err = d.queryRow(`
+		SELECT user_id FROM monitored_users
+		WHERE LOWER(username) = LOWER(?)
+	`, username).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return userID, err
+}
+
+// UserSighting is a message a watched user posted in a chat the monitoring
+// account can see. MessageDate is UTC RFC 3339 like StatusTime.
+type UserSighting struct {
+	UserID       int64  `json:"user_id"`
+	Username     string `json:"username,omitempty"`
+	FirstName    string `json:"first_name,omitempty"`
+	LastName     string `json:"last_name,omitempty"`
+	ChatType     string `json:"chat_type"`
+	ChatID       int64  `json:"chat_id"`
+	ChatTitle    string `json:"chat_title,omitempty"`
+	ChatUsername string `json:"chat_username,omitempty"`
+	MessageID    int    `json:"message_id"`
+	MessageDate  string `json:"message_date"`
+	Message      string `json:"message"`
+	URL          string `json:"url,omitempty"`
+}
+
+// SaveUserSighting records a message posted by a watched user
+func (d *DB) SaveUserSighting(s UserSighting) error {
+	return d.saveUserSighting(d.db, s)
+}
+
+func (d *DB) saveUserSighting(e execer, s UserSighting) error {
+	_, err := d.execOn(e, `
+		INSERT INTO user_sightings (
+			user_id, username, first_name, last_name, chat_type, chat_id,
+			chat_title, chat_username, message_id, message_date, message, url, created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT DO NOTHING
+	`, s.UserID, s.Username, s.FirstName, s.LastName, s.ChatType, s.ChatID,
+		s.ChatTitle, s.ChatUsername, s.MessageID, s.MessageDate, s.Message, s.URL, timestamp())
+	return err
+}
+
+// GetUserSightings returns the sightings of a user, newest first, optionally
+// only those at or after since
+func (d *DB) GetUserSightings(userID int64, since string) ([]UserSighting, error) {
+	rows, err := d.query(`
+		SELECT user_id, COALESCE(username, ''), COALESCE(first_name, ''),
+			COALESCE(last_name, ''), chat_type, chat_id, COALESCE(chat_title, ''),
+			COALESCE(chat_username, ''), message_id, COALESCE(message_date, ''),
+			COALESCE(message, ''), COALESCE(url, '')
+		FROM user_sightings
+		WHERE user_id = ? AND (? = '' OR message_date >= ?)
+		ORDER BY message_date DESC, id DESC
+	`, userID, since, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sightings []UserSighting
+	for rows.Next() {
+		var s UserSighting
+		if err := rows.Scan(&s.UserID, &s.Username, &s.FirstName, &s.LastName,
+			&s.ChatType, &s.ChatID, &s.ChatTitle, &s.ChatUsername, &s.MessageID,
+			&s.MessageDate, &s.Message, &s.URL); err != nil {
+			return nil, err
+		}
+		sightings = append(sightings, s)
+	}
+	return sightings, rows.Err()
+}
@@ -0,0 +1,44 @@
+package database
+
+import "github.com/gnomegl/teleslurp/internal/indicators"
+
+// Store is the storage used by the monitor and the message filters. DB
+// implements it on top of SQLite and PostgreSQL; the backend is chosen by the
+// DSN passed to New.
+type Store interface {
+	// Messages
+	SaveMessage(channelID int64, channelTitle, channelUsername string, messageID int, senderID int64, date, message, url string) error
+	SaveIndicators(channelID int64, messageID int, inds []indicators.Indicator) error
+
+	// User status
+	SaveUserStatusUpdate(u UserStatusUpdate) error
+	GetUserStatusHistory(userID int64, since string) ([]UserStatusUpdate, error)
+	SaveUserSighting(s UserSighting) error
+
+	// Monitored users
+	AddMonitoredUser(userID int64, username, firstName, lastName string) error
+	RemoveMonitoredUser(userID int64) error
+	GetMonitoredUsers() ([]MonitoredUser, error)
+
+	// Channel metadata
+	SaveChannelMetadata(channelID int64, title, username string, memberCount int, isPublic bool) error
+
+	// Filters
+	AddMessageFilter(name, pattern, filterType, action string, priority int) error
+	AddMessageFilterWithOptions(name, pattern, filterType, action string, priority int, options map[string]string) (int64, error)
+	AddFilterKeywords(filterID int64, terms []string) (int, error)
+	RemoveFilterKeywords(filterID int64, terms []string) error
+	GetFilterKeywords(filterID int64) ([]string, error)
+	GetActiveFilters() ([]MessageFilter, error)
+	DisableFilter(filterID int) error
+	EnableFilter(filterID int) error
+	RecordFilterMatch(filterID int) error
+	SaveFilterAudit(channelID int64, messageID int, userID int64, filterID int, filterName, action string) error
+	GetFilterStats() ([]FilterStats, error)
+	GetFilterAudit(channelID int64, messageID int, limit int) ([]FilterAuditEntry, error)
+	GetChangeVersion(name string) (int64, error)
+
+	Close() error
+}
+
+var _ Store = (*DB)(nil)
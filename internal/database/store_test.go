@@ -0,0 +1,465 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gnomegl/teleslurp/internal/indicators"
+)
+
+// postgresTestDSN names the environment variable with a PostgreSQL DSN to
+// run the store tests against. Every test gets a schema of its own there.
+const postgresTestDSN = "TELESLURP_TEST_POSTGRES_DSN"
+
+// newTestDB opens a migrated database in a temporary SQLite file
+func newTestDB(t *testing.T) *DB {
+	t.Helper()
+	d, err := New(filepath.Join(t.TempDir(), "teleslurp.db"))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	t.Cleanup(func() { d.Close() })
+	return d
+}
+
+// newPostgresTestDB opens a migrated database in a new schema of the
+// PostgreSQL database named by postgresTestDSN, or skips the test
+func newPostgresTestDB(t *testing.T) *DB {
+	t.Helper()
+	dsn := os.Getenv(postgresTestDSN)
+	if dsn == "" {
+		t.Skipf("%s not set", postgresTestDSN)
+	}
+
+	admin, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { admin.Close() })
+
+	schema := fmt.Sprintf("teleslurp_test_%d", time.Now().UnixNano())
+	if _, err := admin.Exec("CREATE SCHEMA " + schema); err != nil {
+		t.Fatalf("error creating schema: %v", err)
+	}
+	t.Cleanup(func() { admin.Exec("DROP SCHEMA " + schema + " CASCADE") })
+
+	if u, err := url.Parse(dsn); err == nil && u.Scheme != "" {
+		q := u.Query()
+		q.Set("search_path", schema)
+		u.RawQuery = q.Encode()
+		dsn = u.String()
+	} else {
+		dsn += " search_path=" + schema
+	}
+
+	d, err := New(dsn)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	t.Cleanup(func() { d.Close() })
+	return d
+}
+
+// batchStore is a BatchWriter whose queued writes can be flushed mid-test
+// by replacing it with a new one
+type batchStore struct {
+	*BatchWriter
+}
+
+func (s *batchStore) flush() {
+	old := s.BatchWriter
+	s.BatchWriter = NewBatchWriter(old.DB)
+	old.Close()
+}
+
+// storeCase is run against every backend and Store implementation. flush
+// makes queued writes visible before they are read back.
+type storeCase struct {
+	name string
+	run  func(t *testing.T, s Store, d *DB, flush func())
+}
+
+func TestStore(t *testing.T) {
+	backends := []struct {
+		name string
+		open func(t *testing.T) *DB
+	}{
+		{DialectSQLite, newTestDB},
+		{DialectPostgres, newPostgresTestDB},
+	}
+	stores := []struct {
+		name string
+		open func(t *testing.T, d *DB) (Store, func())
+	}{
+		{"DB", func(t *testing.T, d *DB) (Store, func()) {
+			return d, func() {}
+		}},
+		{"BatchWriter", func(t *testing.T, d *DB) (Store, func()) {
+			s := &batchStore{NewBatchWriter(d)}
+			t.Cleanup(func() { s.Close() })
+			return s, s.flush
+		}},
+	}
+
+	for _, backend := range backends {
+		for _, store := range stores {
+			for _, tc := range storeCases {
+				t.Run(backend.name+"/"+store.name+"/"+tc.name, func(t *testing.T) {
+					d := backend.open(t)
+					s, flush := store.open(t, d)
+					tc.run(t, s, d, flush)
+				})
+			}
+		}
+	}
+}
+
+func count(t *testing.T, d *DB, query string, args ...interface{}) int {
+	t.Helper()
+	var n int
+	if err := d.queryRow(query, args...).Scan(&n); err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+	return n
+}
+
+func must(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+var storeCases = []storeCase{
+	{"messages", func(t *testing.T, s Store, d *DB, flush func()) {
+		must(t, s.SaveMessage(100, "Chan", "chan", 1, 0, "2024-05-01T14:03:22Z", "hello", "https://t.me/chan/1"))
+		// A later save fills in the unknown sender but never replaces it
+		must(t, s.SaveMessage(100, "Chan", "chan", 1, 42, "2024-05-01T14:03:22Z", "hello", "https://t.me/chan/1"))
+		must(t, s.SaveMessage(100, "Chan", "chan", 1, 43, "2024-05-01T14:03:22Z", "hello", "https://t.me/chan/1"))
+		must(t, s.SaveMessage(100, "Chan", "chan", 2, 0, "2024-05-01T15:00:00Z", "no sender", "https://t.me/chan/2"))
+		inds := []indicators.Indicator{{Type: "email", Value: "a@example.com"}, {Type: "mention", Value: "@bob"}}
+		must(t, s.SaveIndicators(100, 1, inds))
+		must(t, s.SaveIndicators(100, 1, inds))
+		flush()
+
+		var got []StoredMessage
+		must(t, d.ExportMessages(ExportQuery{}, func(m StoredMessage) error {
+			got = append(got, m)
+			return nil
+		}))
+		if len(got) != 2 {
+			t.Fatalf("got %d messages, want 2", len(got))
+		}
+		if got[0].SenderID != 42 || got[1].SenderID != 0 {
+			t.Errorf("senders = %d, %d, want 42, 0", got[0].SenderID, got[1].SenderID)
+		}
+		if got[0].Date != "2024-05-01T14:03:22Z" {
+			t.Errorf("date = %q", got[0].Date)
+		}
+		if n := count(t, d, "SELECT COUNT(*) FROM indicators WHERE channel_id = ? AND message_id = ?", 100, 1); n != 2 {
+			t.Errorf("got %d indicators, want 2", n)
+		}
+	}},
+
+	{"export keywords", func(t *testing.T, s Store, d *DB, flush func()) {
+		must(t, s.SaveMessage(100, "Chan", "chan", 1, 0, "2024-05-01T14:03:22Z", "Привет from ÜBER", ""))
+		must(t, s.SaveMessage(100, "Chan", "chan", 2, 0, "2024-05-01T15:00:00Z", "100% off_today", ""))
+		must(t, s.SaveMessage(100, "Chan", "chan", 3, 0, "2024-05-01T16:00:00Z", "nothing here", ""))
+		flush()
+
+		// Case is folded beyond ASCII and LIKE wildcards match literally
+		for _, tc := range []struct {
+			keywords []string
+			want     []int
+		}{
+			{[]string{"привет"}, []int{1}},
+			{[]string{"über"}, []int{1}},
+			{[]string{"ПРИВЕТ", "100%"}, []int{1, 2}},
+			{[]string{"0%_"}, nil},
+			{[]string{"of_"}, nil},
+		} {
+			var got []int
+			must(t, d.ExportMessages(ExportQuery{Keywords: tc.keywords}, func(m StoredMessage) error {
+				got = append(got, m.MessageID)
+				return nil
+			}))
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("keywords %q exported messages %v, want %v", tc.keywords, got, tc.want)
+			}
+		}
+	}},
+
+	{"status updates", func(t *testing.T, s Store, d *DB, flush func()) {
+		updates := []UserStatusUpdate{
+			{UserID: 42, Username: "johndoe", Status: "online", State: StatusOnline, Expires: "2024-01-02T14:05:00Z", StatusTime: "2024-01-02T14:00:00Z"},
+			{UserID: 42, Username: "johndoe", Status: "offline", State: StatusOffline, WasOnline: "2024-01-02T15:59:00Z", StatusTime: "2024-01-02T16:00:00Z"},
+			{UserID: 43, Username: "other", Status: "recently", State: StatusRecently, StatusTime: "2024-01-02T16:30:00Z"},
+			{UserID: 42, Username: "johndoe", Status: "recently", State: StatusRecently, StatusTime: "2024-01-03T09:00:00Z"},
+		}
+		for _, u := range updates {
+			must(t, s.SaveUserStatusUpdate(u))
+		}
+		flush()
+
+		history, err := s.GetUserStatusHistory(42, "")
+		must(t, err)
+		if len(history) != 3 {
+			t.Fatalf("got %d status updates, want 3", len(history))
+		}
+		for i, want := range []UserStatusUpdate{updates[0], updates[1], updates[3]} {
+			want.ID = history[i].ID
+			if history[i] != want {
+				t.Errorf("status update %d = %+v, want %+v", i, history[i], want)
+			}
+		}
+
+		history, err = s.GetUserStatusHistory(42, "2024-01-02T16:00:00Z")
+		must(t, err)
+		if len(history) != 2 || history[0].State != StatusOffline {
+			t.Errorf("since filter returned %+v", history)
+		}
+	}},
+
+	{"sightings", func(t *testing.T, s Store, d *DB, flush func()) {
+		sighting := UserSighting{UserID: 42, Username: "johndoe", ChatType: "channel", ChatID: 100,
+			ChatTitle: "Chan", ChatUsername: "chan", MessageID: 1, MessageDate: "2024-01-02T14:00:00Z",
+			Message: "hello", URL: "https://t.me/chan/1"}
+		must(t, s.SaveUserSighting(sighting))
+		must(t, s.SaveUserSighting(sighting))
+		later := sighting
+		later.MessageID, later.MessageDate = 2, "2024-01-03T14:00:00Z"
+		must(t, s.SaveUserSighting(later))
+		flush()
+
+		sightings, err := d.GetUserSightings(42, "")
+		must(t, err)
+		if len(sightings) != 2 {
+			t.Fatalf("got %d sightings, want 2", len(sightings))
+		}
+		if sightings[0] != later || sightings[1] != sighting {
+			t.Errorf("sightings = %+v, want newest first", sightings)
+		}
+		sightings, err = d.GetUserSightings(42, "2024-01-03T00:00:00Z")
+		must(t, err)
+		if len(sightings) != 1 {
+			t.Errorf("since filter returned %d sightings, want 1", len(sightings))
+		}
+	}},
+
+	{"monitored users", func(t *testing.T, s Store, d *DB, flush func()) {
+		before, err := s.GetChangeVersion("monitored_users")
+		must(t, err)
+
+		must(t, s.AddMonitoredUser(42, "johndoe", "John", ""))
+		must(t, s.AddMonitoredUser(43, "jane", "Jane", "Doe"))
+		must(t, s.AddMonitoredUser(42, "john_new", "John", "Doe"))
+		users, err := s.GetMonitoredUsers()
+		must(t, err)
+		if len(users) != 2 {
+			t.Fatalf("got %d users, want 2", len(users))
+		}
+		if users[0].UserID != 42 || users[0].Username != "john_new" || users[0].LastName != "Doe" {
+			t.Errorf("upserted user = %+v", users[0])
+		}
+		if users[0].AddedAt == "" {
+			t.Error("added_at not set")
+		}
+
+		must(t, s.RemoveMonitoredUser(42))
+		users, err = s.GetMonitoredUsers()
+		must(t, err)
+		if len(users) != 1 || users[0].UserID != 43 {
+			t.Errorf("after remove = %+v", users)
+		}
+
+		after, err := s.GetChangeVersion("monitored_users")
+		must(t, err)
+		if after <= before {
+			t.Errorf("change version %d not bumped from %d", after, before)
+		}
+		if v, err := s.GetChangeVersion("no_such_table"); err != nil || v != 0 {
+			t.Errorf("unknown change version = %d, %v", v, err)
+		}
+	}},
+
+	{"channel metadata", func(t *testing.T, s Store, d *DB, flush func()) {
+		must(t, s.SaveChannelMetadata(100, "Chan", "chan", 10, true))
+		must(t, s.SaveChannelMetadata(100, "Chan renamed", "chan", 25, false))
+		must(t, s.SaveMessage(100, "Chan", "chan", 1, 42, "2024-05-01T14:03:22Z", "hello", ""))
+		flush()
+
+		if n := count(t, d, "SELECT COUNT(*) FROM channel_metadata WHERE channel_id = ? AND title = ? AND member_count = ?", 100, "Chan renamed", 25); n != 1 {
+			t.Error("channel metadata not updated")
+		}
+		must(t, d.ExportMessages(ExportQuery{Channel: "@CHAN"}, func(m StoredMessage) error {
+			if m.MemberCount != 25 {
+				t.Errorf("member count = %d, want 25", m.MemberCount)
+			}
+			return nil
+		}))
+	}},
+
+	{"filters", func(t *testing.T, s Store, d *DB, flush func()) {
+		before, err := s.GetChangeVersion("message_filters")
+		must(t, err)
+
+		must(t, s.AddMessageFilter("low", "spam", "keyword", "ignore", 1))
+		id, err := s.AddMessageFilterWithOptions("high", "", "keyword_list", "highlight", 10,
+			map[string]string{"match": "word", "case": "sensitive"})
+		must(t, err)
+
+		added, err := s.AddFilterKeywords(id, []string{"wallet", "btc", "wallet"})
+		must(t, err)
+		if added != 2 {
+			t.Errorf("added %d keywords, want 2", added)
+		}
+		added, err = s.AddFilterKeywords(id, []string{"btc", "eth"})
+		must(t, err)
+		if added != 1 {
+			t.Errorf("added %d keywords, want 1", added)
+		}
+		must(t, s.RemoveFilterKeywords(id, []string{"btc", "missing"}))
+		terms, err := s.GetFilterKeywords(id)
+		must(t, err)
+		if !reflect.DeepEqual(terms, []string{"eth", "wallet"}) {
+			t.Errorf("keywords = %v", terms)
+		}
+
+		filters, err := s.GetActiveFilters()
+		must(t, err)
+		if len(filters) != 2 || filters[0].Name != "high" || filters[1].Name != "low" {
+			t.Fatalf("active filters = %+v, want by priority", filters)
+		}
+		if filters[0].Options["match"] != "word" || filters[0].Options["case"] != "sensitive" || filters[1].Options != nil {
+			t.Errorf("options = %v, %v", filters[0].Options, filters[1].Options)
+		}
+
+		must(t, s.DisableFilter(filters[0].ID))
+		active, err := s.GetActiveFilters()
+		must(t, err)
+		if len(active) != 1 || active[0].Name != "low" {
+			t.Errorf("after disable = %+v", active)
+		}
+		must(t, s.EnableFilter(filters[0].ID))
+		active, err = s.GetActiveFilters()
+		must(t, err)
+		if len(active) != 2 {
+			t.Errorf("after enable got %d filters, want 2", len(active))
+		}
+
+		after, err := s.GetChangeVersion("message_filters")
+		must(t, err)
+		if after <= before {
+			t.Errorf("change version %d not bumped from %d", after, before)
+		}
+		if v, err := s.GetChangeVersion("no_such_table"); err != nil || v != 0 {
+			t.Errorf("unknown change version = %d, %v", v, err)
+		}
+	}},
+
+	{"filter stats", func(t *testing.T, s Store, d *DB, flush func()) {
+		id, err := s.AddMessageFilterWithOptions("f", "x", "keyword", "forward", 0, nil)
+		must(t, err)
+		must(t, s.AddMessageFilter("unused", "y", "keyword", "forward", 0))
+		must(t, s.RecordFilterMatch(int(id)))
+		must(t, s.RecordFilterMatch(int(id)))
+		must(t, s.DisableFilter(int(id)))
+		flush()
+
+		stats, err := s.GetFilterStats()
+		must(t, err)
+		if len(stats) != 2 {
+			t.Fatalf("got %d filter stats, want 2 including disabled", len(stats))
+		}
+		if stats[0].MatchCount != 2 || stats[0].Enabled || !strings.HasSuffix(stats[0].LastMatchAt, "Z") {
+			t.Errorf("stats = %+v", stats[0])
+		}
+		if stats[1].MatchCount != 0 || stats[1].LastMatchAt != "" {
+			t.Errorf("unused filter stats = %+v", stats[1])
+		}
+	}},
+
+	{"filter audit", func(t *testing.T, s Store, d *DB, flush func()) {
+		must(t, s.SaveFilterAudit(100, 1, 42, 0, "", "forward"))
+		must(t, s.SaveFilterAudit(100, 2, 42, 7, "spam", "ignored"))
+		must(t, s.SaveFilterAudit(200, 1, 43, 8, "wallets", "highlight"))
+		flush()
+
+		all, err := s.GetFilterAudit(0, 0, 10)
+		must(t, err)
+		if len(all) != 3 || all[0].ChannelID != 200 || all[2].FilterID != 0 {
+			t.Fatalf("audit = %+v, want newest first", all)
+		}
+		if !strings.HasSuffix(all[0].CreatedAt, "Z") {
+			t.Errorf("created_at = %q, want UTC RFC 3339", all[0].CreatedAt)
+		}
+
+		for _, tc := range []struct {
+			channelID int64
+			messageID int
+			limit     int
+			want      int
+		}{
+			{100, 0, 10, 2},
+			{0, 1, 10, 2},
+			{100, 2, 10, 1},
+			{300, 0, 10, 0},
+			{0, 0, 1, 1},
+		} {
+			entries, err := s.GetFilterAudit(tc.channelID, tc.messageID, tc.limit)
+			must(t, err)
+			if len(entries) != tc.want {
+				t.Errorf("GetFilterAudit(%d, %d, %d) returned %d entries, want %d",
+					tc.channelID, tc.messageID, tc.limit, len(entries), tc.want)
+			}
+		}
+	}},
+}
+
+// TestRebind checks the PostgreSQL placeholders of the query shapes the
+// store uses, which run without a server
+func TestRebind(t *testing.T) {
+	pg := &DB{dialect: DialectPostgres}
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{
+			"WHERE (CAST(? AS BIGINT) = 0 OR channel_id = ?) AND (CAST(? AS BIGINT) = 0 OR message_id = ?) LIMIT ?",
+			"WHERE (CAST($1 AS BIGINT) = 0 OR channel_id = $2) AND (CAST($3 AS BIGINT) = 0 OR message_id = $4) LIMIT $5",
+		},
+		{
+			"WHERE user_id = ? AND (? = '' OR status_time >= ?)",
+			"WHERE user_id = $1 AND ($2 = '' OR status_time >= $3)",
+		},
+		{
+			`LOWER(m.message) LIKE ? ESCAPE '\' OR x = '?' OR y = ?`,
+			`LOWER(m.message) LIKE $1 ESCAPE '\' OR x = '?' OR y = $2`,
+		},
+		{
+			"UPDATE t SET a = '?' WHERE b = ?",
+			"UPDATE t SET a = '?' WHERE b = $1",
+		},
+		{
+			"INSERT INTO t (a, b) VALUES (?, ?) ON CONFLICT(a) DO UPDATE SET b = excluded.b",
+			"INSERT INTO t (a, b) VALUES ($1, $2) ON CONFLICT(a) DO UPDATE SET b = excluded.b",
+		},
+	}
+	for _, tt := range tests {
+		if got := pg.rebind(tt.query); got != tt.want {
+			t.Errorf("rebind(%q)\n got %q\nwant %q", tt.query, got, tt.want)
+		}
+	}
+
+	sqlite := &DB{dialect: DialectSQLite}
+	if got := sqlite.rebind(tests[0].query); got != tests[0].query {
+		t.Errorf("SQLite rebind changed the query: %q", got)
+	}
+}
@@ -0,0 +1,188 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gnomegl/teleslurp/internal/database"
+	"github.com/gnomegl/teleslurp/internal/types"
+)
+
+// Snapshot is everything recorded for a single search run
+type Snapshot struct {
+	Run       database.SearchRun
+	Usernames []types.UsernameHistory
+	Groups    []types.Group
+	Channels  []database.SearchRunChannel
+	Messages  []database.SearchRunMessage
+}
+
+// LoadSnapshot reads a search run and its results from the database
+func LoadSnapshot(db *database.DB, runID int64) (*Snapshot, error) {
+	run, err := db.GetSearchRun(runID)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Snapshot{Run: *run}
+	if s.Usernames, err = db.GetSearchRunUsernames(runID); err != nil {
+		return nil, fmt.Errorf("error loading username history: %w", err)
+	}
+	if s.Groups, err = db.GetSearchRunGroups(runID); err != nil {
+		return nil, fmt.Errorf("error loading groups: %w", err)
+	}
+	if s.Channels, err = db.GetSearchRunChannels(runID); err != nil {
+		return nil, fmt.Errorf("error loading channels: %w", err)
+	}
+	if s.Messages, err = db.GetSearchRunMessages(runID); err != nil {
+		return nil, fmt.Errorf("error loading messages: %w", err)
+	}
+	return s, nil
+}
+
+// Change is a field that differs between two runs
+type Change struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// AdminChange records a channel where the target gained or lost admin rights
+type AdminChange struct {
+	ChannelID int64  `json:"channel_id"`
+	Title     string `json:"title"`
+	Username  string `json:"username"`
+	WasAdmin  bool   `json:"was_admin"`
+	IsAdmin   bool   `json:"is_admin"`
+}
+
+// Report lists what changed between an older run A and a newer run B
+type Report struct {
+	RunA database.SearchRun `json:"run_a"`
+	RunB database.SearchRun `json:"run_b"`
+
+	Username  *Change                 `json:"username,omitempty"`
+	Name      *Change                 `json:"name,omitempty"`
+	Usernames []types.UsernameHistory `json:"new_username_history"`
+
+	GroupsJoined []types.Group `json:"groups_joined"`
+	GroupsLeft   []types.Group `json:"groups_left"`
+
+	MessagesNew     []database.SearchRunMessage `json:"messages_new"`
+	MessagesDeleted []database.SearchRunMessage `json:"messages_deleted"`
+
+	AdminChanges []AdminChange `json:"admin_changes"`
+}
+
+// Empty reports whether nothing changed between the runs
+func (r *Report) Empty() bool {
+	return r.Username == nil && r.Name == nil &&
+		len(r.Usernames) == 0 && len(r.GroupsJoined) == 0 && len(r.GroupsLeft) == 0 &&
+		len(r.MessagesNew) == 0 && len(r.MessagesDeleted) == 0 && len(r.AdminChanges) == 0
+}
+
+// Compare reports the changes from snapshot a to snapshot b
+func Compare(a, b *Snapshot) *Report {
+	r := &Report{RunA: a.Run, RunB: b.Run}
+
+	if a.Run.Username != b.Run.Username && b.Run.Username != "" {
+		r.Username = &Change{From: a.Run.Username, To: b.Run.Username}
+	}
+	nameA := strings.TrimSpace(a.Run.FirstName + " " + a.Run.LastName)
+	nameB := strings.TrimSpace(b.Run.FirstName + " " + b.Run.LastName)
+	if nameA != nameB && nameB != "" {
+		r.Name = &Change{From: nameA, To: nameB}
+	}
+
+	seenUsernames := make(map[types.UsernameHistory]bool)
+	for _, h := range a.Usernames {
+		seenUsernames[h] = true
+	}
+	for _, h := range b.Usernames {
+		if !seenUsernames[h] {
+			r.Usernames = append(r.Usernames, h)
+		}
+	}
+
+	// Groups only come from TGScan, so skip them when either run used an input file
+	if a.Run.Source == b.Run.Source {
+		groupsA := groupSet(a.Groups)
+		groupsB := groupSet(b.Groups)
+		for _, g := range b.Groups {
+			if !groupsA[groupKey(g)] {
+				r.GroupsJoined = append(r.GroupsJoined, g)
+			}
+		}
+		for _, g := range a.Groups {
+			if !groupsB[groupKey(g)] {
+				r.GroupsLeft = append(r.GroupsLeft, g)
+			}
+		}
+	}
+
+	messagesA := messageSet(a.Messages)
+	messagesB := messageSet(b.Messages)
+	for _, m := range b.Messages {
+		if !messagesA[messageKey{m.ChannelID, m.MessageID}] {
+			r.MessagesNew = append(r.MessagesNew, m)
+		}
+	}
+
+	// A message missing from B only counts as deleted if B searched its channel
+	channelsB := make(map[int64]database.SearchRunChannel)
+	for _, c := range b.Channels {
+		channelsB[c.ChannelID] = c
+	}
+	for _, m := range a.Messages {
+		if _, searched := channelsB[m.ChannelID]; searched && !messagesB[messageKey{m.ChannelID, m.MessageID}] {
+			r.MessagesDeleted = append(r.MessagesDeleted, m)
+		}
+	}
+
+	for _, ca := range a.Channels {
+		cb, ok := channelsB[ca.ChannelID]
+		if !ok || ca.IsAdmin == cb.IsAdmin {
+			continue
+		}
+		r.AdminChanges = append(r.AdminChanges, AdminChange{
+			ChannelID: cb.ChannelID,
+			Title:     cb.Title,
+			Username:  cb.Username,
+			WasAdmin:  ca.IsAdmin,
+			IsAdmin:   cb.IsAdmin,
+		})
+	}
+
+	return r
+}
+
+type messageKey struct {
+	channelID int64
+	messageID int
+}
+
+func messageSet(messages []database.SearchRunMessage) map[messageKey]bool {
+	set := make(map[messageKey]bool, len(messages))
+	for _, m := range messages {
+		set[messageKey{m.ChannelID, m.MessageID}] = true
+	}
+	return set
+}
+
+// groupKey identifies a group by ID when TGScan knows it, otherwise by username or title
+func groupKey(g types.Group) string {
+	if g.ID != 0 {
+		return fmt.Sprint(g.ID)
+	}
+	if g.Username != "" {
+		return "@" + strings.ToLower(g.Username)
+	}
+	return "title:" + g.Title
+}
+
+func groupSet(groups []types.Group) map[string]bool {
+	set := make(map[string]bool, len(groups))
+	for _, g := range groups {
+		set[groupKey(g)] = true
+	}
+	return set
+}
@@ -0,0 +1,205 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/gnomegl/teleslurp/internal/database"
+	"github.com/gnomegl/teleslurp/internal/timeutil"
+	"github.com/gnomegl/teleslurp/internal/types"
+)
+
+// Output formats
+const (
+	FormatText     = "text"
+	FormatJSON     = "json"
+	FormatMarkdown = "markdown"
+)
+
+// Write renders the report in the given format
+func Write(w io.Writer, r *Report, format string) error {
+	switch format {
+	case FormatText, "":
+		return WriteText(w, r)
+	case FormatJSON:
+		return WriteJSON(w, r)
+	case FormatMarkdown, "md":
+		return WriteMarkdown(w, r)
+	default:
+		return fmt.Errorf("unsupported diff format: %s", format)
+	}
+}
+
+// WriteJSON renders the report as indented JSON
+func WriteJSON(w io.Writer, r *Report) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// WriteText renders the report for the terminal
+func WriteText(w io.Writer, r *Report) error {
+	fmt.Fprintf(w, "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
+	fmt.Fprintf(w, "CHANGES: run #%d (%s) → run #%d (%s)\n", r.RunA.ID, timeutil.Display(r.RunA.StartedAt), r.RunB.ID, timeutil.Display(r.RunB.StartedAt))
+	fmt.Fprintf(w, "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
+
+	if r.Empty() {
+		fmt.Fprintf(w, "No changes\n")
+		return nil
+	}
+
+	if r.Username != nil {
+		fmt.Fprintf(w, "\nUsername: @%s → @%s\n", r.Username.From, r.Username.To)
+	}
+	if r.Name != nil {
+		fmt.Fprintf(w, "\nName: %s → %s\n", r.Name.From, r.Name.To)
+	}
+	if len(r.Usernames) > 0 {
+		fmt.Fprintf(w, "\nNew Username History (%d):\n", len(r.Usernames))
+		for _, h := range r.Usernames {
+			fmt.Fprintf(w, "  • @%s (%s)\n", h.Username, h.Date)
+		}
+	}
+
+	writeGroupsText(w, "Groups Joined", "+", r.GroupsJoined)
+	writeGroupsText(w, "Groups Left", "-", r.GroupsLeft)
+	writeMessagesText(w, "New Messages", "+", r.MessagesNew)
+	writeMessagesText(w, "Deleted Messages", "-", r.MessagesDeleted)
+
+	if len(r.AdminChanges) > 0 {
+		fmt.Fprintf(w, "\nAdmin Changes (%d):\n", len(r.AdminChanges))
+		for _, c := range r.AdminChanges {
+			status := "👑 became admin"
+			if !c.IsAdmin {
+				status = "no longer admin"
+			}
+			fmt.Fprintf(w, "  • %s: %s\n", channelName(c.Title, c.Username), status)
+		}
+	}
+	return nil
+}
+
+func writeGroupsText(w io.Writer, heading, marker string, groups []types.Group) {
+	if len(groups) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\n%s (%d):\n", heading, len(groups))
+	for _, g := range groups {
+		fmt.Fprintf(w, "  %s %s\n", marker, channelName(g.Title, g.Username))
+	}
+}
+
+func writeMessagesText(w io.Writer, heading, marker string, messages []database.SearchRunMessage) {
+	if len(messages) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\n%s (%d):\n", heading, len(messages))
+	for _, m := range messages {
+		fmt.Fprintf(w, "  %s [%s] %s: %s\n", marker, timeutil.Display(m.Date), channelName(m.ChannelTitle, m.ChannelUsername), preview(m.Message, 80))
+		if m.URL != "" {
+			fmt.Fprintf(w, "    %s\n", m.URL)
+		}
+	}
+}
+
+// WriteMarkdown renders the report as a Markdown document
+func WriteMarkdown(w io.Writer, r *Report) error {
+	fmt.Fprintf(w, "# Changes: run #%d → run #%d\n\n", r.RunA.ID, r.RunB.ID)
+	fmt.Fprintf(w, "| | Run A | Run B |\n|---|---|---|\n")
+	fmt.Fprintf(w, "| Run | #%d | #%d |\n", r.RunA.ID, r.RunB.ID)
+	fmt.Fprintf(w, "| Started | %s | %s |\n", timeutil.Display(r.RunA.StartedAt), timeutil.Display(r.RunB.StartedAt))
+	fmt.Fprintf(w, "| Username | %s | %s |\n", markdownEscape(r.RunA.Username), markdownEscape(r.RunB.Username))
+	fmt.Fprintf(w, "| Channels | %d | %d |\n", r.RunA.ChannelCount, r.RunB.ChannelCount)
+	fmt.Fprintf(w, "| Messages | %d | %d |\n\n", r.RunA.MessageCount, r.RunB.MessageCount)
+
+	if r.Empty() {
+		fmt.Fprintf(w, "No changes.\n")
+		return nil
+	}
+
+	if r.Username != nil || r.Name != nil || len(r.Usernames) > 0 {
+		fmt.Fprintf(w, "## Identity\n\n")
+		if r.Username != nil {
+			fmt.Fprintf(w, "- Username: `@%s` → `@%s`\n", r.Username.From, r.Username.To)
+		}
+		if r.Name != nil {
+			fmt.Fprintf(w, "- Name: %s → %s\n", markdownEscape(r.Name.From), markdownEscape(r.Name.To))
+		}
+		for _, h := range r.Usernames {
+			fmt.Fprintf(w, "- New username history: `@%s` (%s)\n", h.Username, h.Date)
+		}
+		fmt.Fprintln(w)
+	}
+
+	writeGroupsMarkdown(w, "Groups Joined", r.GroupsJoined)
+	writeGroupsMarkdown(w, "Groups Left", r.GroupsLeft)
+	writeMessagesMarkdown(w, "New Messages", r.MessagesNew)
+	writeMessagesMarkdown(w, "Deleted Messages", r.MessagesDeleted)
+
+	if len(r.AdminChanges) > 0 {
+		fmt.Fprintf(w, "## Admin Changes\n\n")
+		for _, c := range r.AdminChanges {
+			status := "became admin"
+			if !c.IsAdmin {
+				status = "no longer admin"
+			}
+			fmt.Fprintf(w, "- %s: %s\n", markdownEscape(channelName(c.Title, c.Username)), status)
+		}
+		fmt.Fprintln(w)
+	}
+	return nil
+}
+
+func writeGroupsMarkdown(w io.Writer, heading string, groups []types.Group) {
+	if len(groups) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "## %s (%d)\n\n", heading, len(groups))
+	for _, g := range groups {
+		fmt.Fprintf(w, "- %s\n", markdownEscape(channelName(g.Title, g.Username)))
+	}
+	fmt.Fprintln(w)
+}
+
+func writeMessagesMarkdown(w io.Writer, heading string, messages []database.SearchRunMessage) {
+	if len(messages) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "## %s (%d)\n\n", heading, len(messages))
+	fmt.Fprintf(w, "| Date | Channel | Message | Link |\n|---|---|---|---|\n")
+	for _, m := range messages {
+		fmt.Fprintf(w, "| %s | %s | %s | %s |\n", timeutil.Display(m.Date),
+			markdownEscape(channelName(m.ChannelTitle, m.ChannelUsername)),
+			markdownEscape(preview(m.Message, 200)), m.URL)
+	}
+	fmt.Fprintln(w)
+}
+
+func channelName(title, username string) string {
+	switch {
+	case title != "" && username != "":
+		return fmt.Sprintf("%s (@%s)", title, username)
+	case username != "":
+		return "@" + username
+	default:
+		return title
+	}
+}
+
+// preview flattens a message onto one line and truncates it
+func preview(text string, max int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	runes := []rune(text)
+	if len(runes) > max {
+		return string(runes[:max]) + "…"
+	}
+	return text
+}
+
+var markdownReplacer = strings.NewReplacer("|", "\\|", "*", "\\*", "_", "\\_", "`", "\\`", "[", "\\[", "]", "\\]")
+
+func markdownEscape(text string) string {
+	return markdownReplacer.Replace(text)
+}
@@ -0,0 +1,148 @@
+// Package evidence records how exported messages were obtained, so an export
+// folder can back findings in reports: the raw Telegram payload of every
+// message, when and by which account it was retrieved, and a signature over
+// the folder manifest.
+package evidence
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gnomegl/teleslurp/internal/export"
+	"github.com/gotd/td/bin"
+	"github.com/gotd/td/tg"
+)
+
+// Filename is the name of the evidence log in a run folder
+const Filename = "evidence.ndjson"
+
+// Account is the Telegram account a message was retrieved with
+type Account struct {
+	ID        int64  `json:"id"`
+	Username  string `json:"username,omitempty"`
+	FirstName string `json:"first_name,omitempty"`
+	LastName  string `json:"last_name,omitempty"`
+}
+
+// NewAccount describes the logged in user
+func NewAccount(self *tg.User) Account {
+	return Account{
+		ID:        self.ID,
+		Username:  self.Username,
+		FirstName: self.FirstName,
+		LastName:  self.LastName,
+	}
+}
+
+// Record is the evidence for one exported message. Payload is the message
+// object from the Telegram response serialized back to TL in schema layer
+// Layer, base64 encoded; it decodes with tg.Message.Decode.
+type Record struct {
+	ChannelID       int64     `json:"channel_id"`
+	ChannelUsername string    `json:"channel_username,omitempty"`
+	MessageID       int       `json:"message_id"`
+	URL             string    `json:"url"`
+	RetrievedAt     time.Time `json:"retrieved_at"`
+	Account         Account   `json:"account"`
+	Layer           int       `json:"layer"`
+	TLType          string    `json:"tl_type"`
+	Payload         string    `json:"payload"`
+	PayloadSHA256   string    `json:"payload_sha256"`
+}
+
+// NewRecord serializes a retrieved message
+func NewRecord(channelID int64, channelUsername, url string, msg *tg.Message, retrievedAt time.Time, account Account) (Record, error) {
+	var buf bin.Buffer
+	if err := msg.Encode(&buf); err != nil {
+		return Record{}, fmt.Errorf("error serializing message %d: %w", msg.ID, err)
+	}
+	sum := sha256.Sum256(buf.Raw())
+
+	return Record{
+		ChannelID:       channelID,
+		ChannelUsername: channelUsername,
+		MessageID:       msg.ID,
+		URL:             url,
+		RetrievedAt:     retrievedAt.UTC(),
+		Account:         account,
+		Layer:           tg.Layer,
+		TLType:          fmt.Sprintf("%s#%x", msg.TypeName(), msg.TypeID()),
+		Payload:         base64.StdEncoding.EncodeToString(buf.Raw()),
+		PayloadSHA256:   hex.EncodeToString(sum[:]),
+	}, nil
+}
+
+// Check decodes the payload and compares it with the recorded hash and
+// message ID
+func (r Record) Check() error {
+	payload, err := base64.StdEncoding.DecodeString(r.Payload)
+	if err != nil {
+		return fmt.Errorf("payload is not valid base64: %w", err)
+	}
+	sum := sha256.Sum256(payload)
+	if hex.EncodeToString(sum[:]) != r.PayloadSHA256 {
+		return fmt.Errorf("payload SHA-256 does not match")
+	}
+
+	var msg tg.Message
+	if err := msg.Decode(&bin.Buffer{Buf: payload}); err != nil {
+		return fmt.Errorf("payload is not a layer %d message: %w", r.Layer, err)
+	}
+	if msg.ID != r.MessageID {
+		return fmt.Errorf("payload is message %d", msg.ID)
+	}
+	return nil
+}
+
+// Writer appends records to the evidence log of a run folder, flushing after
+// every channel like the streaming exporters
+type Writer struct {
+	account Account
+	out     *export.NDJSONWriter
+}
+
+// NewWriter creates the evidence log in dir
+func NewWriter(dir string, account Account) (*Writer, error) {
+	file, err := os.Create(filepath.Join(dir, Filename))
+	if err != nil {
+		return nil, fmt.Errorf("error creating evidence log: %w", err)
+	}
+	return &Writer{account: account, out: export.NewNDJSONWriter(file)}, nil
+}
+
+// WriteChannel records the messages retrieved from a channel
+func (w *Writer) WriteChannel(channelID int64, channelUsername string, messages []Retrieved) error {
+	for _, m := range messages {
+		record, err := NewRecord(channelID, channelUsername, m.URL, m.Message, m.RetrievedAt, w.account)
+		if err != nil {
+			return err
+		}
+		if err := w.out.Write(record); err != nil {
+			return err
+		}
+	}
+	return w.out.Flush()
+}
+
+// Close flushes and closes the evidence log. Calling it again does nothing,
+// so it can be deferred as well as checked.
+func (w *Writer) Close() error {
+	if w.out == nil {
+		return nil
+	}
+	out := w.out
+	w.out = nil
+	return out.Close()
+}
+
+// Retrieved is a message, its link and when it was received from Telegram
+type Retrieved struct {
+	Message     *tg.Message
+	URL         string
+	RetrievedAt time.Time
+}
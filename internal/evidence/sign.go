@@ -0,0 +1,167 @@
+package evidence
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/gnomegl/teleslurp/internal/export"
+)
+
+// SignatureFilename is the name of the manifest signature in a run folder
+const SignatureFilename = "manifest.sig"
+
+// Signature is an ed25519 signature over the exact bytes of manifest.json
+type Signature struct {
+	Algorithm string `json:"algorithm"`
+	PublicKey string `json:"public_key"`
+	Signature string `json:"signature"`
+}
+
+// LoadOrCreateKey reads a PEM encoded ed25519 private key. When the file
+// doesn't exist a new key is generated and saved there, with its public key
+// next to it in path + ".pub"; created reports whether that happened.
+func LoadOrCreateKey(path string) (key ed25519.PrivateKey, created bool, err error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		key, err := createKey(path)
+		return key, err == nil, err
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("error reading signing key: %w", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, false, fmt.Errorf("signing key %s is not PEM encoded", path)
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, false, fmt.Errorf("error parsing signing key: %w", err)
+	}
+	key, ok := parsed.(ed25519.PrivateKey)
+	if !ok {
+		return nil, false, fmt.Errorf("signing key %s is not an ed25519 key", path)
+	}
+	return key, false, nil
+}
+
+func createKey(path string) (ed25519.PrivateKey, error) {
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("error generating signing key: %w", err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding signing key: %w", err)
+	}
+	pubDER, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding public key: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("error creating key directory: %w", err)
+	}
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		return nil, fmt.Errorf("error saving signing key: %w", err)
+	}
+	if err := os.WriteFile(path+".pub", pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}), 0644); err != nil {
+		return nil, fmt.Errorf("error saving public key: %w", err)
+	}
+	return key, nil
+}
+
+// LoadPublicKey reads a PEM encoded ed25519 public key, as written next to
+// a generated signing key
+func LoadPublicKey(path string) (ed25519.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading public key: %w", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("public key %s is not PEM encoded", path)
+	}
+	parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing public key: %w", err)
+	}
+	pub, ok := parsed.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("public key %s is not an ed25519 key", path)
+	}
+	return pub, nil
+}
+
+// Fingerprint identifies a public key, in the SHA256:<base64> form ssh uses
+func Fingerprint(pub ed25519.PublicKey) string {
+	sum := sha256.Sum256(pub)
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:])
+}
+
+// SignManifest signs the manifest of a run folder and writes the signature
+// next to it. It returns the path of the signature.
+func SignManifest(dir string, key ed25519.PrivateKey) (string, error) {
+	data, err := os.ReadFile(filepath.Join(dir, export.ManifestFilename))
+	if err != nil {
+		return "", fmt.Errorf("error reading manifest: %w", err)
+	}
+
+	sig := Signature{
+		Algorithm: "ed25519",
+		PublicKey: base64.StdEncoding.EncodeToString(key.Public().(ed25519.PublicKey)),
+		Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(key, data)),
+	}
+	out, err := json.MarshalIndent(sig, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("error encoding signature: %w", err)
+	}
+
+	filename := filepath.Join(dir, SignatureFilename)
+	if err := os.WriteFile(filename, append(out, '\n'), 0644); err != nil {
+		return "", fmt.Errorf("error writing signature: %w", err)
+	}
+	return filename, nil
+}
+
+// VerifySignature checks the signature of a manifest and returns the key
+// that made it. The key comes from the signature file itself, so callers
+// should compare it with a key they trust.
+func VerifySignature(dir string, manifest []byte) (ed25519.PublicKey, error) {
+	data, err := os.ReadFile(filepath.Join(dir, SignatureFilename))
+	if err != nil {
+		return nil, fmt.Errorf("error reading signature: %w", err)
+	}
+
+	var sig Signature
+	if err := json.Unmarshal(data, &sig); err != nil {
+		return nil, fmt.Errorf("error parsing signature: %w", err)
+	}
+	if sig.Algorithm != "ed25519" {
+		return nil, fmt.Errorf("unsupported signature algorithm: %s", sig.Algorithm)
+	}
+
+	pub, err := base64.StdEncoding.DecodeString(sig.PublicKey)
+	if err != nil || len(pub) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid public key in signature")
+	}
+	signature, err := base64.StdEncoding.DecodeString(sig.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature encoding: %w", err)
+	}
+	if !ed25519.Verify(pub, manifest, signature) {
+		return ed25519.PublicKey(pub), fmt.Errorf("signature does not match the manifest")
+	}
+	return ed25519.PublicKey(pub), nil
+}
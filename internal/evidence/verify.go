@@ -0,0 +1,48 @@
+package evidence
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// CheckLog checks every record of the evidence log in dir. It returns the
+// number of records and one problem per bad record; a folder without an
+// evidence log has no records.
+func CheckLog(dir string) (int, []string, error) {
+	file, err := os.Open(filepath.Join(dir, Filename))
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil, nil
+	}
+	if err != nil {
+		return 0, nil, fmt.Errorf("error opening evidence log: %w", err)
+	}
+	defer file.Close()
+
+	var problems []string
+	count := 0
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		count++
+
+		var r Record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			problems = append(problems, fmt.Sprintf("%s line %d: %v", Filename, line, err))
+			continue
+		}
+		if err := r.Check(); err != nil {
+			problems = append(problems, fmt.Sprintf("%s line %d (message %d): %v", Filename, line, r.MessageID, err))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return count, problems, fmt.Errorf("error reading evidence log: %w", err)
+	}
+	return count, problems, nil
+}
@@ -0,0 +1,143 @@
+package export
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/gnomegl/teleslurp/internal/indicators"
+	"github.com/gnomegl/teleslurp/internal/types"
+)
+
+func init() {
+	Register(csvExporter{})
+}
+
+// csvExporter writes the messages, and optionally the channel metadata, as
+// CSV files with a header row
+type csvExporter struct{}
+
+func (csvExporter) Name() string { return "csv" }
+
+func (csvExporter) Description() string {
+	return "Messages (and channel metadata with --metadata) as CSV files"
+}
+
+func (csvExporter) NewWriter(search *Search, basename string) (Writer, error) {
+	w := &csvWriter{}
+	var err error
+
+	if w.messages, w.filename, err = newHeaderCSVWriter(basename, "messages", messageCSVHeaders); err != nil {
+		return nil, err
+	}
+
+	if search.IncludeMetadata {
+		if w.channels, w.metaFilename, err = newHeaderCSVWriter(basename, "channel_metadata", channelMetadataCSVHeaders); err != nil {
+			w.messages.Close()
+			return nil, err
+		}
+	}
+	return w, nil
+}
+
+// csvWriter flushes the CSV files after every channel
+type csvWriter struct {
+	filename, metaFilename string
+	messages, channels     *CSVWriter
+}
+
+func (w *csvWriter) WriteChannel(channel types.ChannelMetadata, messages []types.MessageData) error {
+	for _, msg := range messages {
+		if err := w.messages.WriteRecord(messageCSVRecord(msg)); err != nil {
+			return err
+		}
+	}
+	if err := w.messages.Flush(); err != nil {
+		return err
+	}
+
+	if w.channels == nil {
+		return nil
+	}
+	if err := w.channels.WriteRecord(channelMetadataCSVRecord(channel)); err != nil {
+		return err
+	}
+	return w.channels.Flush()
+}
+
+func (w *csvWriter) Close() ([]string, error) {
+	if err := w.messages.Close(); err != nil {
+		if w.channels != nil {
+			w.channels.Close()
+		}
+		return nil, fmt.Errorf("error writing CSV file: %w", err)
+	}
+	files := []string{w.filename}
+
+	if w.channels != nil {
+		if err := w.channels.Close(); err != nil {
+			return files, fmt.Errorf("error writing CSV file: %w", err)
+		}
+		files = append(files, w.metaFilename)
+	}
+	return files, nil
+}
+
+// newHeaderCSVWriter creates the CSV file for one kind of data, see
+// CreateFile, and writes its header row. It returns the writer and the
+// file's name.
+func newHeaderCSVWriter(basename, dataType string, headers []string) (*CSVWriter, string, error) {
+	file, err := CreateFile(basename, dataType, "csv")
+	if err != nil {
+		return nil, "", err
+	}
+	writer := NewCSVWriter(file)
+	if err := writer.WriteHeader(headers); err != nil {
+		writer.Close()
+		return nil, "", err
+	}
+	return writer, file.Name(), nil
+}
+
+var messageCSVHeaders = []string{
+	"Channel Title",
+	"Channel Username",
+	"Message ID",
+	"Date",
+	"Date Unix",
+	"Message",
+	"URL",
+	"Indicators",
+}
+
+func messageCSVRecord(msg types.MessageData) []string {
+	return []string{
+		msg.ChannelTitle,
+		msg.ChannelUsername,
+		strconv.Itoa(msg.MessageID),
+		msg.Date,
+		strconv.FormatInt(msg.DateUnix, 10),
+		msg.Message,
+		msg.URL,
+		indicators.Format(msg.Indicators),
+	}
+}
+
+var channelMetadataCSVHeaders = []string{
+	"Channel Title",
+	"Channel Username",
+	"Channel Link",
+	"Channel Admins",
+	"Member Count",
+	"User Join Date",
+}
+
+func channelMetadataCSVRecord(ch types.ChannelMetadata) []string {
+	return []string{
+		ch.ChannelTitle,
+		ch.ChannelUsername,
+		ch.ChannelLink,
+		ch.ChannelAdmins,
+		strconv.Itoa(ch.MemberCount),
+		ch.UserFirstMessage,
+	}
+}
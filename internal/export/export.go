@@ -5,13 +5,23 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strconv"
+
+	"github.com/gnomegl/teleslurp/internal/types"
 )
 
-func WriteJSON(data interface{}, filename string) error {
-	file, err := os.Create(filename)
-	if err != nil {
-		return fmt.Errorf("error creating JSON file: %w", err)
+// WriteJSON writes data to file as indented JSON and closes it
+func WriteJSON(data interface{}, file *os.File) error {
+	if err := writeJSONFile(data, file); err != nil {
+		return err
 	}
+
+	fmt.Printf("Data exported to JSON file: %s\n", file.Name())
+	return nil
+}
+
+// writeJSONFile writes data to file as indented JSON and closes it
+func writeJSONFile(data interface{}, file *os.File) error {
 	defer file.Close()
 
 	encoder := json.NewEncoder(file)
@@ -19,8 +29,9 @@ func WriteJSON(data interface{}, filename string) error {
 	if err := encoder.Encode(data); err != nil {
 		return fmt.Errorf("error encoding JSON: %w", err)
 	}
-
-	fmt.Printf("Data exported to JSON file: %s\n", filename)
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("error writing JSON file: %w", err)
+	}
 	return nil
 }
 
@@ -29,16 +40,12 @@ type CSVWriter struct {
 	writer *csv.Writer
 }
 
-func NewCSVWriter(filename string) (*CSVWriter, error) {
-	file, err := os.Create(filename)
-	if err != nil {
-		return nil, fmt.Errorf("error creating CSV file: %w", err)
-	}
-
+// NewCSVWriter writes CSV records to file, which Close closes
+func NewCSVWriter(file *os.File) *CSVWriter {
 	return &CSVWriter{
 		file:   file,
 		writer: csv.NewWriter(file),
-	}, nil
+	}
 }
 
 func (w *CSVWriter) WriteHeader(headers []string) error {
@@ -55,11 +62,47 @@ func (w *CSVWriter) WriteRecord(record []string) error {
 	return nil
 }
 
+// Flush writes buffered records to disk
+func (w *CSVWriter) Flush() error {
+	w.writer.Flush()
+	if err := w.writer.Error(); err != nil {
+		return fmt.Errorf("error writing CSV: %w", err)
+	}
+	return nil
+}
+
 func (w *CSVWriter) Close() error {
 	w.writer.Flush()
 	return w.file.Close()
 }
 
-func FormatFilename(username, dataType, format string) string {
-	return fmt.Sprintf("%s_%s.%s", username, dataType, format)
+// CreateFile creates the file for one kind of data about a user, e.g.
+// alice_messages.json. Existing files are never overwritten: a numeric suffix
+// is added instead. The file is created exclusively, so concurrent exports
+// can't end up writing to the same name.
+func CreateFile(username, dataType, format string) (*os.File, error) {
+	name := fmt.Sprintf("%s_%s", username, dataType)
+	filename := name + "." + format
+	for i := 2; ; i++ {
+		file, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			return file, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("error creating %s: %w", filename, err)
+		}
+		filename = fmt.Sprintf("%s_%d.%s", name, i, format)
+	}
+}
+
+// TargetBasename names the files of a search after the target's username,
+// or its ID when the username isn't known
+func TargetBasename(user types.User) string {
+	if user.Username != "" {
+		return SafeName(user.Username)
+	}
+	if user.ID != 0 {
+		return strconv.FormatInt(user.ID, 10)
+	}
+	return SafeName("")
 }
@@ -0,0 +1,55 @@
+package export
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// TestCreateFileConcurrent checks that exports racing for the same name
+// each get a file of their own and never overwrite an existing one
+func TestCreateFileConcurrent(t *testing.T) {
+	const exports = 16
+
+	basename := filepath.Join(t.TempDir(), "johndoe")
+	existing := basename + "_messages.json"
+	if err := os.WriteFile(existing, []byte("keep"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	names := make(chan string, exports)
+	var wg sync.WaitGroup
+	for i := 0; i < exports; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			file, err := CreateFile(basename, "messages", "json")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer file.Close()
+			names <- file.Name()
+		}()
+	}
+	wg.Wait()
+	close(names)
+
+	seen := make(map[string]bool)
+	for name := range names {
+		if seen[name] {
+			t.Errorf("%s was created twice", name)
+		}
+		if name == existing {
+			t.Errorf("existing file %s was reused", name)
+		}
+		seen[name] = true
+	}
+	if len(seen) != exports {
+		t.Errorf("got %d files, want %d", len(seen), exports)
+	}
+	if data, err := os.ReadFile(existing); err != nil || string(data) != "keep" {
+		t.Errorf("existing file changed: %q, %v", data, err)
+	}
+}
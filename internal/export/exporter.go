@@ -0,0 +1,124 @@
+package export
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gnomegl/teleslurp/internal/types"
+)
+
+// Search describes the search whose results are being exported
+type Search struct {
+	Target  types.User
+	Profile *types.TGScanResponse // nil when groups came from an input file
+
+	// IncludeMetadata asks exporters that write separate files to also write
+	// the channel metadata
+	IncludeMetadata bool
+}
+
+// SearchResults is everything a search produced, in the order channels were
+// searched
+type SearchResults struct {
+	Search
+	Messages []types.MessageData
+	Channels []types.ChannelMetadata
+}
+
+// Exporter writes search results in one output format
+type Exporter interface {
+	// Name is the value selecting the exporter with --format
+	Name() string
+	// Description is shown in the --format help
+	Description() string
+	// NewWriter starts an export to files named after basename
+	NewWriter(search *Search, basename string) (Writer, error)
+}
+
+// Writer receives the results of a search one channel at a time, as each
+// channel completes. Streaming formats write every channel to disk straight
+// away, so the files written so far survive an interrupted search.
+type Writer interface {
+	// WriteChannel adds a searched channel and the messages found in it
+	WriteChannel(channel types.ChannelMetadata, messages []types.MessageData) error
+	// Close finishes the export and returns the paths it wrote
+	Close() ([]string, error)
+}
+
+// bufferedWriter collects the whole search for exporters that can only
+// render once every channel is known, such as reports with a timeline
+type bufferedWriter struct {
+	results SearchResults
+	render  func(results *SearchResults) ([]string, error)
+}
+
+func newBufferedWriter(search *Search, render func(results *SearchResults) ([]string, error)) *bufferedWriter {
+	return &bufferedWriter{results: SearchResults{Search: *search}, render: render}
+}
+
+func (w *bufferedWriter) WriteChannel(channel types.ChannelMetadata, messages []types.MessageData) error {
+	w.results.Channels = append(w.results.Channels, channel)
+	w.results.Messages = append(w.results.Messages, messages...)
+	return nil
+}
+
+func (w *bufferedWriter) Close() ([]string, error) {
+	return w.render(&w.results)
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Exporter)
+)
+
+// Register makes an exporter available by its name. Registering the same
+// name twice panics.
+func Register(e Exporter) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, dup := registry[e.Name()]; dup {
+		panic("export: Register called twice for " + e.Name())
+	}
+	registry[e.Name()] = e
+}
+
+// Get returns the exporter registered under name
+func Get(name string) (Exporter, error) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	e, ok := registry[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported output format: %s (available: %s)", name, strings.Join(namesLocked(), ", "))
+	}
+	return e, nil
+}
+
+// Names returns the names of all registered exporters, sorted
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return namesLocked()
+}
+
+func namesLocked() []string {
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Usage describes every registered exporter, one per line, for flag help
+func Usage() string {
+	var b strings.Builder
+	for _, name := range Names() {
+		e, _ := Get(name)
+		fmt.Fprintf(&b, "  %-9s %s\n", name, e.Description())
+	}
+	return b.String()
+}
@@ -0,0 +1,82 @@
+package export
+
+import (
+	"fmt"
+
+	"github.com/gnomegl/teleslurp/internal/graph"
+	"github.com/gnomegl/teleslurp/internal/types"
+)
+
+func init() {
+	Register(graphExporter{format: graph.FormatGEXF, description: "User–channel graph as GEXF, for Gephi"})
+	Register(graphExporter{format: graph.FormatGraphML, description: "User–channel graph as GraphML, for Gephi, yEd and networkx"})
+	Register(graphExporter{format: graph.FormatMaltego, description: "User–channel graph as a Maltego entity CSV"})
+}
+
+// graphExporter writes the target, their TGScan groups and former usernames
+// and the channels they posted in as a graph. Use `teleslurp graph` to
+// combine the runs of several users.
+type graphExporter struct {
+	format      string
+	description string
+}
+
+func (e graphExporter) Name() string { return e.format }
+
+func (e graphExporter) Description() string { return e.description }
+
+func (e graphExporter) NewWriter(search *Search, basename string) (Writer, error) {
+	g := graph.New()
+
+	var target string
+	if search.Profile != nil {
+		target = g.AddProfile(search.Profile)
+	}
+	if user := search.Target; target == "" || user.ID != 0 || user.Username != "" {
+		target = g.AddUser(user.ID, user.Username, user.FirstName, user.LastName)
+	}
+
+	return &graphWriter{graph: g, target: target, format: e.format, basename: basename}, nil
+}
+
+type graphWriter struct {
+	graph    *graph.Graph
+	target   string
+	format   string
+	basename string
+}
+
+func (w *graphWriter) WriteChannel(channel types.ChannelMetadata, messages []types.MessageData) error {
+	AddChannelToGraph(w.graph, w.target, channel, len(messages))
+	return nil
+}
+
+func (w *graphWriter) Close() ([]string, error) {
+	dataType := "graph"
+	if w.format == graph.FormatMaltego {
+		dataType = "graph_maltego"
+	}
+	file, err := CreateFile(w.basename, dataType, graph.Extension(w.format))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if err := graph.Write(file, w.graph, w.format); err != nil {
+		return nil, err
+	}
+	if err := file.Close(); err != nil {
+		return nil, fmt.Errorf("error writing graph file: %w", err)
+	}
+	return []string{file.Name()}, nil
+}
+
+// AddChannelToGraph adds a searched channel, the user's posts in it and its
+// admins to a graph
+func AddChannelToGraph(g *graph.Graph, user string, channel types.ChannelMetadata, messages int) {
+	node := g.AddChannel(channel.ChannelID, channel.ChannelUsername, channel.ChannelTitle, channel.MemberCount)
+	if messages > 0 {
+		g.AddEdge(user, node, graph.EdgePosted, float64(messages), channel.UserFirstMessage)
+	}
+	g.AddAdmins(node, channel.ChannelAdmins)
+}
@@ -0,0 +1,168 @@
+package export
+
+import (
+	"fmt"
+	"html/template"
+	"strings"
+	"time"
+
+	"github.com/gnomegl/teleslurp/internal/timeutil"
+	"github.com/gnomegl/teleslurp/internal/types"
+)
+
+func init() {
+	Register(htmlExporter{})
+}
+
+// htmlExporter writes a self-contained HTML report that opens in any browser
+// without network access
+type htmlExporter struct{}
+
+func (htmlExporter) Name() string { return "html" }
+
+func (htmlExporter) Description() string {
+	return "Self-contained HTML report with profile, channels and message timeline"
+}
+
+func (htmlExporter) NewWriter(search *Search, basename string) (Writer, error) {
+	return newBufferedWriter(search, func(results *SearchResults) ([]string, error) {
+		return writeHTMLReport(results, basename)
+	}), nil
+}
+
+func writeHTMLReport(results *SearchResults, basename string) ([]string, error) {
+	file, err := CreateFile(basename, "report", "html")
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	data := htmlReport{
+		Title:     targetName(results),
+		UserID:    profileUserID(results),
+		Username:  results.Target.Username,
+		Generated: timeutil.DisplayTime(time.Now()),
+		Sections:  channelSections(results),
+		Timeline:  timeline(results),
+		Results:   results,
+	}
+	if results.Profile != nil {
+		data.UsernameHistory = results.Profile.Result.UsernameHistory
+		data.IDHistory = results.Profile.Result.IDHistory
+		data.Groups = results.Profile.Result.Groups
+		data.Meta = &results.Profile.Result.Meta
+	}
+
+	if err := htmlTemplate.Execute(file, data); err != nil {
+		return nil, fmt.Errorf("error rendering HTML report: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return nil, fmt.Errorf("error writing HTML report: %w", err)
+	}
+	return []string{file.Name()}, nil
+}
+
+type htmlReport struct {
+	Title           string
+	UserID          int64
+	Username        string
+	Generated       string
+	UsernameHistory []types.UsernameHistory
+	IDHistory       []types.IDHistory
+	Groups          []types.Group
+	Meta            *types.Meta
+	Sections        []channelSection
+	Timeline        []types.MessageData
+	Results         *SearchResults
+}
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"tme": func(username string) string {
+		return "https://t.me/" + strings.TrimPrefix(username, "@")
+	},
+	"date": timeutil.Display,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}} — teleslurp report</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Roboto, sans-serif; margin: 0; background: #f5f6f8; color: #1d2129; }
+main { max-width: 960px; margin: 0 auto; padding: 24px; }
+h1 { margin-bottom: 4px; }
+h2 { border-bottom: 2px solid #d0d5dd; padding-bottom: 4px; margin-top: 40px; }
+a { color: #2a6fdb; text-decoration: none; }
+a:hover { text-decoration: underline; }
+.muted { color: #667085; font-size: 0.9em; }
+.card { background: #fff; border: 1px solid #e4e7ec; border-radius: 8px; padding: 16px; margin: 16px 0; }
+.stats span { display: inline-block; margin-right: 24px; }
+.admin { background: #fef0c7; color: #93370d; border-radius: 4px; padding: 2px 6px; font-size: 0.8em; margin-left: 8px; }
+.message { border-left: 3px solid #2a6fdb; padding: 4px 12px; margin: 12px 0; }
+.message p { margin: 4px 0; white-space: pre-wrap; word-wrap: break-word; }
+table { border-collapse: collapse; width: 100%; background: #fff; }
+th, td { text-align: left; padding: 6px 8px; border-bottom: 1px solid #e4e7ec; vertical-align: top; }
+td.text { white-space: pre-wrap; word-wrap: break-word; }
+nav a { margin-right: 16px; }
+</style>
+</head>
+<body>
+<main>
+<h1>{{.Title}}</h1>
+<p class="muted">{{if .UserID}}User ID {{.UserID}} · {{end}}Generated {{.Generated}} by teleslurp</p>
+<nav><a href="#profile">Profile</a><a href="#channels">Channels</a><a href="#timeline">Timeline</a></nav>
+
+<h2 id="profile">Profile</h2>
+<div class="card stats">
+<span><strong>{{len .Results.Channels}}</strong> channels</span>
+<span><strong>{{len .Results.Messages}}</strong> messages</span>
+{{if .Username}}<span><a href="{{tme .Username}}">@{{.Username}}</a></span>{{end}}
+{{with .Meta}}<span><strong>{{.NumGroups}}</strong> of {{.KnownNumGroups}} known groups found</span>
+{{if .OpCost}}<span><strong>{{.OpCost}}</strong> TGScan credits</span>{{end}}{{end}}
+</div>
+{{if .UsernameHistory}}
+<h3>Username History</h3>
+<table>
+<tr><th>Username</th><th>Date</th></tr>
+{{range .UsernameHistory}}<tr><td><a href="{{tme .Username}}">@{{.Username}}</a></td><td>{{.Date}}</td></tr>
+{{end}}</table>
+{{end}}
+{{if .IDHistory}}
+<h3>ID History</h3>
+<table>
+<tr><th>ID</th><th>Date</th></tr>
+{{range .IDHistory}}<tr><td>{{.ID}}</td><td>{{.Date}}</td></tr>
+{{end}}</table>
+{{end}}
+{{if .Groups}}
+<h3>Known Groups</h3>
+<table>
+<tr><th>Group</th><th>Updated</th></tr>
+{{range .Groups}}<tr><td>{{if .Username}}<a href="{{tme .Username}}">{{.Title}}</a>{{else}}{{.Title}}{{end}}</td><td>{{.DateUpdated}}</td></tr>
+{{end}}</table>
+{{end}}
+
+<h2 id="channels">Channels</h2>
+{{range $i, $s := .Sections}}
+<div class="card" id="channel-{{$i}}">
+<h3>{{if $s.Channel.ChannelLink}}<a href="{{$s.Channel.ChannelLink}}">{{$s.Name}}</a>{{else}}{{$s.Name}}{{end}}{{if $s.IsAdmin $.Username}}<span class="admin">admin</span>{{end}}</h3>
+<p class="muted">{{$s.Channel.MemberCount}} members · {{len $s.Messages}} messages{{if $s.Channel.UserFirstMessage}} · first message {{date $s.Channel.UserFirstMessage}}{{end}}</p>
+{{if $s.Channel.ChannelAdmins}}<p class="muted">Admins: {{$s.Channel.ChannelAdmins}}</p>{{end}}
+{{range $s.Messages}}
+<div class="message">
+<a href="{{.URL}}">{{date .Date}}</a>
+<p>{{.Message}}</p>
+</div>
+{{end}}
+</div>
+{{end}}
+
+<h2 id="timeline">Timeline</h2>
+<table>
+<tr><th>Date</th><th>Channel</th><th>Message</th></tr>
+{{range .Timeline}}<tr><td><a href="{{.URL}}">{{date .Date}}</a></td><td>{{if .ChannelUsername}}<a href="{{tme .ChannelUsername}}">@{{.ChannelUsername}}</a>{{else}}{{.ChannelTitle}}{{end}}</td><td class="text">{{.Message}}</td></tr>
+{{end}}</table>
+</main>
+</body>
+</html>
+`))
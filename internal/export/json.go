@@ -0,0 +1,77 @@
+package export
+
+import "github.com/gnomegl/teleslurp/internal/types"
+
+func init() {
+	Register(jsonExporter{})
+}
+
+// jsonExporter writes the messages, and optionally the channel metadata, as
+// indented JSON arrays
+type jsonExporter struct{}
+
+func (jsonExporter) Name() string { return "json" }
+
+func (jsonExporter) Description() string {
+	return "Messages (and channel metadata with --metadata) as JSON arrays"
+}
+
+func (jsonExporter) NewWriter(search *Search, basename string) (Writer, error) {
+	file, err := CreateFile(basename, "messages", "json")
+	if err != nil {
+		return nil, err
+	}
+	w := &jsonWriter{filename: file.Name(), messages: NewJSONArrayWriter(file)}
+
+	if search.IncludeMetadata {
+		file, err := CreateFile(basename, "channel_metadata", "json")
+		if err != nil {
+			w.messages.Close()
+			return nil, err
+		}
+		w.metaFilename, w.channels = file.Name(), NewJSONArrayWriter(file)
+	}
+	return w, nil
+}
+
+type jsonWriter struct {
+	filename, metaFilename string
+	messages, channels     *JSONArrayWriter
+}
+
+func (w *jsonWriter) WriteChannel(channel types.ChannelMetadata, messages []types.MessageData) error {
+	for _, msg := range messages {
+		if err := w.messages.Write(msg); err != nil {
+			return err
+		}
+	}
+	if err := w.messages.Flush(); err != nil {
+		return err
+	}
+
+	if w.channels == nil {
+		return nil
+	}
+	if err := w.channels.Write(channel); err != nil {
+		return err
+	}
+	return w.channels.Flush()
+}
+
+func (w *jsonWriter) Close() ([]string, error) {
+	if err := w.messages.Close(); err != nil {
+		if w.channels != nil {
+			w.channels.Close()
+		}
+		return nil, err
+	}
+	files := []string{w.filename}
+
+	if w.channels != nil {
+		if err := w.channels.Close(); err != nil {
+			return files, err
+		}
+		files = append(files, w.metaFilename)
+	}
+	return files, nil
+}
@@ -0,0 +1,234 @@
+package export
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ManifestFilename is the name of the manifest in a run folder
+const ManifestFilename = "manifest.json"
+
+// Manifest describes a search run folder: how the files in it were produced
+// and their hashes, so the folder can be checked for changes later
+type Manifest struct {
+	Tool            string                 `json:"tool"`
+	Version         string                 `json:"version"`
+	RunID           int64                  `json:"run_id,omitempty"`
+	Query           string                 `json:"query"`
+	Parameters      map[string]interface{} `json:"parameters"`
+	StartedAt       time.Time              `json:"started_at"`
+	FinishedAt      time.Time              `json:"finished_at"`
+	DurationSeconds float64                `json:"duration_seconds"`
+	Error           string                 `json:"error,omitempty"`
+	Files           []ManifestFile         `json:"files"`
+	// ChainHead is the chain hash of the last file, committing to every file
+	// in order
+	ChainHead string `json:"chain_head"`
+}
+
+// ManifestFile is a file of a run folder. Path is relative to the folder and
+// always uses forward slashes. Chain links the file to the ones before it,
+// see chainHash.
+type ManifestFile struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+	Chain  string `json:"chain"`
+}
+
+// NewRunDir creates the folder for one search run inside outputDir, named
+// after the target and the start time. An existing folder is never reused:
+// a numeric suffix is added instead.
+func NewRunDir(outputDir, target string, started time.Time) (string, error) {
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return "", fmt.Errorf("error creating output directory: %w", err)
+	}
+
+	base := filepath.Join(outputDir, fmt.Sprintf("%s_%s", SafeName(target), started.UTC().Format("20060102T150405Z")))
+	dir := base
+	for i := 2; ; i++ {
+		err := os.Mkdir(dir, 0755)
+		if err == nil {
+			return dir, nil
+		}
+		if !os.IsExist(err) {
+			return "", fmt.Errorf("error creating run directory: %w", err)
+		}
+		dir = fmt.Sprintf("%s_%d", base, i)
+	}
+}
+
+// WriteManifest hashes every file in dir and writes the manifest next to
+// them. It returns the path of the manifest.
+func WriteManifest(dir string, m *Manifest) (string, error) {
+	files, err := hashFiles(dir)
+	if err != nil {
+		return "", err
+	}
+	m.Files = files
+	m.ChainHead = ""
+	for i := range m.Files {
+		m.ChainHead = chainHash(m.ChainHead, m.Files[i])
+		m.Files[i].Chain = m.ChainHead
+	}
+	m.StartedAt = m.StartedAt.UTC()
+	m.FinishedAt = m.FinishedAt.UTC()
+	m.DurationSeconds = m.FinishedAt.Sub(m.StartedAt).Seconds()
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("error encoding manifest: %w", err)
+	}
+
+	filename := filepath.Join(dir, ManifestFilename)
+	if err := os.WriteFile(filename, append(data, '\n'), 0644); err != nil {
+		return "", fmt.Errorf("error writing manifest: %w", err)
+	}
+	return filename, nil
+}
+
+// ReadManifest reads the manifest of a run folder
+func ReadManifest(dir string) (*Manifest, []byte, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ManifestFilename))
+	if err != nil {
+		return nil, nil, fmt.Errorf("error reading manifest: %w", err)
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, nil, fmt.Errorf("error parsing manifest: %w", err)
+	}
+	return &m, data, nil
+}
+
+// VerifyManifest re-hashes the files of a run folder and compares them and
+// the hash chain with the manifest. Files that are not covered by the
+// manifest are reported unless ignore lists them. It returns one problem per
+// mismatch, none if the folder is intact.
+func VerifyManifest(dir string, m *Manifest, ignore ...string) ([]string, error) {
+	current, err := hashFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[string]ManifestFile, len(current))
+	for _, f := range current {
+		found[f.Path] = f
+	}
+
+	var problems []string
+	listed := make(map[string]bool, len(m.Files))
+	chain := ""
+	for _, f := range m.Files {
+		listed[f.Path] = true
+		chain = chainHash(chain, f)
+		if f.Chain != chain {
+			problems = append(problems, fmt.Sprintf("%s: chain hash does not match", f.Path))
+		}
+
+		cur, ok := found[f.Path]
+		switch {
+		case !ok:
+			problems = append(problems, fmt.Sprintf("%s: missing", f.Path))
+		case cur.SHA256 != f.SHA256:
+			problems = append(problems, fmt.Sprintf("%s: SHA-256 does not match", f.Path))
+		case cur.Size != f.Size:
+			problems = append(problems, fmt.Sprintf("%s: size does not match", f.Path))
+		}
+	}
+	if chain != m.ChainHead {
+		problems = append(problems, "chain head does not match the files")
+	}
+
+	for _, name := range ignore {
+		listed[name] = true
+	}
+	for _, f := range current {
+		if !listed[f.Path] {
+			problems = append(problems, fmt.Sprintf("%s: not in manifest", f.Path))
+		}
+	}
+	return problems, nil
+}
+
+// chainHash links a file to the chain hash of the files before it:
+// SHA-256 of the previous chain hash, the file's SHA-256 and its path, one
+// per line. The first file uses an empty previous hash.
+func chainHash(prev string, f ManifestFile) string {
+	sum := sha256.Sum256([]byte(prev + "\n" + f.SHA256 + "\n" + f.Path))
+	return hex.EncodeToString(sum[:])
+}
+
+func hashFiles(dir string) ([]ManifestFile, error) {
+	files := []ManifestFile{}
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == ManifestFilename {
+			return nil
+		}
+
+		sum, size, err := hashFile(path)
+		if err != nil {
+			return err
+		}
+		files = append(files, ManifestFile{Path: rel, Size: size, SHA256: sum})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error hashing output files: %w", err)
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
+
+func hashFile(path string) (string, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer file.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(h, file)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), size, nil
+}
+
+// SafeName makes a username or query usable as part of a filename
+func SafeName(name string) string {
+	name = strings.TrimPrefix(strings.TrimSpace(name), "@")
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-', r == '.':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+	name = strings.Trim(name, ".")
+	if name == "" {
+		return "unknown"
+	}
+	return name
+}
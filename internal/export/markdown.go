@@ -0,0 +1,155 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gnomegl/teleslurp/internal/timeutil"
+)
+
+func init() {
+	Register(markdownExporter{})
+}
+
+// markdownExporter writes a single human-readable report
+type markdownExporter struct{}
+
+func (markdownExporter) Name() string { return "markdown" }
+
+func (markdownExporter) Description() string {
+	return "Report with the user profile and messages grouped by channel, as Markdown"
+}
+
+func (markdownExporter) NewWriter(search *Search, basename string) (Writer, error) {
+	return newBufferedWriter(search, func(results *SearchResults) ([]string, error) {
+		file, err := CreateFile(basename, "report", "md")
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		if _, err := file.WriteString(renderMarkdown(results)); err != nil {
+			return nil, fmt.Errorf("error writing Markdown report: %w", err)
+		}
+		if err := file.Close(); err != nil {
+			return nil, fmt.Errorf("error writing Markdown report: %w", err)
+		}
+		return []string{file.Name()}, nil
+	}), nil
+}
+
+func renderMarkdown(results *SearchResults) string {
+	var b strings.Builder
+	username := results.Target.Username
+
+	fmt.Fprintf(&b, "# %s\n\n", markdownEscape(targetName(results)))
+	if id := profileUserID(results); id != 0 {
+		fmt.Fprintf(&b, "- **User ID:** %d\n", id)
+	}
+	fmt.Fprintf(&b, "- **Channels:** %d\n", len(results.Channels))
+	fmt.Fprintf(&b, "- **Messages:** %d\n", len(results.Messages))
+	if results.Profile != nil {
+		meta := results.Profile.Result.Meta
+		fmt.Fprintf(&b, "- **TGScan groups:** %d found of %d known\n", meta.NumGroups, meta.KnownNumGroups)
+		if meta.OpCost > 0 {
+			fmt.Fprintf(&b, "- **TGScan cost:** %d credits\n", meta.OpCost)
+		}
+	}
+	b.WriteString("\n")
+
+	if results.Profile != nil && len(results.Profile.Result.UsernameHistory) > 0 {
+		b.WriteString("## Username History\n\n")
+		b.WriteString("| Username | Date |\n|---|---|\n")
+		for _, h := range results.Profile.Result.UsernameHistory {
+			fmt.Fprintf(&b, "| @%s | %s |\n", markdownEscape(h.Username), markdownEscape(h.Date))
+		}
+		b.WriteString("\n")
+	}
+
+	if results.Profile != nil && len(results.Profile.Result.IDHistory) > 0 {
+		b.WriteString("## ID History\n\n")
+		b.WriteString("| ID | Date |\n|---|---|\n")
+		for _, h := range results.Profile.Result.IDHistory {
+			fmt.Fprintf(&b, "| %d | %s |\n", h.ID, markdownEscape(h.Date))
+		}
+		b.WriteString("\n")
+	}
+
+	if results.Profile != nil && len(results.Profile.Result.Groups) > 0 {
+		b.WriteString("## Known Groups\n\n")
+		b.WriteString("| Group | Username | Updated |\n|---|---|---|\n")
+		for _, g := range results.Profile.Result.Groups {
+			username := ""
+			if g.Username != "" {
+				username = "@" + g.Username
+			}
+			fmt.Fprintf(&b, "| %s | %s | %s |\n", markdownCell(g.Title), markdownCell(username), markdownEscape(g.DateUpdated))
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("## Channels\n\n")
+	for _, section := range channelSections(results) {
+		title := markdownEscape(section.Name())
+		if section.IsAdmin(username) {
+			title += " 👑 admin"
+		}
+		fmt.Fprintf(&b, "### %s\n\n", title)
+		if section.Channel.ChannelLink != "" {
+			fmt.Fprintf(&b, "- **Link:** <%s>\n", section.Channel.ChannelLink)
+		}
+		fmt.Fprintf(&b, "- **Members:** %d\n", section.Channel.MemberCount)
+		if section.Channel.UserFirstMessage != "" {
+			fmt.Fprintf(&b, "- **First message:** %s\n", timeutil.Display(section.Channel.UserFirstMessage))
+		}
+		fmt.Fprintf(&b, "- **Messages:** %d\n\n", len(section.Messages))
+
+		for _, msg := range section.Messages {
+			fmt.Fprintf(&b, "**[%s](%s)**\n\n", timeutil.Display(msg.Date), msg.URL)
+			for _, line := range strings.Split(msg.Message, "\n") {
+				fmt.Fprintf(&b, "> %s\n", markdownEscape(line))
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	b.WriteString("## Timeline\n\n")
+	b.WriteString("| Date | Channel | Message |\n|---|---|---|\n")
+	for _, msg := range timeline(results) {
+		channel := msg.ChannelTitle
+		if msg.ChannelUsername != "" {
+			channel = "@" + msg.ChannelUsername
+		}
+		fmt.Fprintf(&b, "| [%s](%s) | %s | %s |\n", timeutil.Display(msg.Date), msg.URL,
+			markdownCell(channel), markdownCell(truncate(msg.Message, 200)))
+	}
+	return b.String()
+}
+
+func profileUserID(results *SearchResults) int64 {
+	if results.Profile != nil && results.Profile.Result.User.ID != 0 {
+		return results.Profile.Result.User.ID
+	}
+	return results.Target.ID
+}
+
+var markdownReplacer = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`,
+	"<", `\<`, ">", `\>`, "#", `\#`, "|", `\|`,
+)
+
+func markdownEscape(s string) string {
+	return markdownReplacer.Replace(s)
+}
+
+// markdownCell escapes text for a single table cell
+func markdownCell(s string) string {
+	return markdownEscape(strings.Join(strings.Fields(s), " "))
+}
+
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max]) + "…"
+}
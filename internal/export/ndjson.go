@@ -0,0 +1,77 @@
+package export
+
+import "github.com/gnomegl/teleslurp/internal/types"
+
+func init() {
+	Register(ndjsonExporter{})
+}
+
+// ndjsonExporter writes one JSON object per line, which tools like jq can
+// process as a stream
+type ndjsonExporter struct{}
+
+func (ndjsonExporter) Name() string { return "ndjson" }
+
+func (ndjsonExporter) Description() string {
+	return "Messages (and channel metadata with --metadata) as newline-delimited JSON"
+}
+
+func (ndjsonExporter) NewWriter(search *Search, basename string) (Writer, error) {
+	file, err := CreateFile(basename, "messages", "ndjson")
+	if err != nil {
+		return nil, err
+	}
+	w := &ndjsonWriter{filename: file.Name(), messages: NewNDJSONWriter(file)}
+
+	if search.IncludeMetadata {
+		file, err := CreateFile(basename, "channel_metadata", "ndjson")
+		if err != nil {
+			w.messages.Close()
+			return nil, err
+		}
+		w.metaFilename, w.channels = file.Name(), NewNDJSONWriter(file)
+	}
+	return w, nil
+}
+
+type ndjsonWriter struct {
+	filename, metaFilename string
+	messages, channels     *NDJSONWriter
+}
+
+func (w *ndjsonWriter) WriteChannel(channel types.ChannelMetadata, messages []types.MessageData) error {
+	for _, msg := range messages {
+		if err := w.messages.Write(msg); err != nil {
+			return err
+		}
+	}
+	if err := w.messages.Flush(); err != nil {
+		return err
+	}
+
+	if w.channels == nil {
+		return nil
+	}
+	if err := w.channels.Write(channel); err != nil {
+		return err
+	}
+	return w.channels.Flush()
+}
+
+func (w *ndjsonWriter) Close() ([]string, error) {
+	if err := w.messages.Close(); err != nil {
+		if w.channels != nil {
+			w.channels.Close()
+		}
+		return nil, err
+	}
+	files := []string{w.filename}
+
+	if w.channels != nil {
+		if err := w.channels.Close(); err != nil {
+			return files, err
+		}
+		files = append(files, w.metaFilename)
+	}
+	return files, nil
+}
@@ -0,0 +1,176 @@
+package export
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/gnomegl/teleslurp/internal/types"
+)
+
+// ProfileExporter is implemented by exporters that write the TGScan profile
+// of the target to files of its own. The reports, graphs and STIX bundles
+// include the profile in their output instead.
+type ProfileExporter interface {
+	// WriteProfile writes the profile to files named after basename and
+	// returns their paths
+	WriteProfile(profile *types.TGScanResponse, basename string) ([]string, error)
+}
+
+// WriteProfile writes the TGScan profile in the format of e, if it writes
+// the profile separately, and returns the files written
+func WriteProfile(e Exporter, profile *types.TGScanResponse, basename string) ([]string, error) {
+	p, ok := e.(ProfileExporter)
+	if !ok || profile == nil {
+		return nil, nil
+	}
+	return p.WriteProfile(profile, basename)
+}
+
+// WriteProfile writes the full TGScan response as indented JSON
+func (jsonExporter) WriteProfile(profile *types.TGScanResponse, basename string) ([]string, error) {
+	file, err := CreateFile(basename, "tgscan", "json")
+	if err != nil {
+		return nil, err
+	}
+	if err := writeJSONFile(profile, file); err != nil {
+		return nil, err
+	}
+	return []string{file.Name()}, nil
+}
+
+// Records of the NDJSON profile, told apart by their "record" field
+type (
+	profileUserRecord struct {
+		Record string `json:"record"`
+		types.User
+		types.Meta
+	}
+	profileUsernameRecord struct {
+		Record string `json:"record"`
+		UserID int64  `json:"user_id"`
+		types.UsernameHistory
+	}
+	profileIDRecord struct {
+		Record string `json:"record"`
+		UserID int64  `json:"user_id"`
+		types.IDHistory
+	}
+	profileGroupRecord struct {
+		Record string `json:"record"`
+		UserID int64  `json:"user_id"`
+		types.Group
+	}
+)
+
+// WriteProfile writes one line for the user and the TGScan meta data,
+// followed by one line per former username, former ID and group
+func (ndjsonExporter) WriteProfile(profile *types.TGScanResponse, basename string) ([]string, error) {
+	file, err := CreateFile(basename, "tgscan", "ndjson")
+	if err != nil {
+		return nil, err
+	}
+	w := NewNDJSONWriter(file)
+
+	result := profile.Result
+	userID := result.User.ID
+	records := []interface{}{profileUserRecord{"user", result.User, result.Meta}}
+	for _, h := range result.UsernameHistory {
+		records = append(records, profileUsernameRecord{"username_history", userID, h})
+	}
+	for _, h := range result.IDHistory {
+		records = append(records, profileIDRecord{"id_history", userID, h})
+	}
+	for _, g := range result.Groups {
+		records = append(records, profileGroupRecord{"group", userID, g})
+	}
+
+	for _, record := range records {
+		if err := w.Write(record); err != nil {
+			w.Close()
+			return nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return []string{file.Name()}, nil
+}
+
+// WriteProfile writes the user and TGScan meta data, the username history,
+// the ID history and the groups as one CSV file each
+func (csvExporter) WriteProfile(profile *types.TGScanResponse, basename string) ([]string, error) {
+	result := profile.Result
+	user := result.User
+	userID := strconv.FormatInt(user.ID, 10)
+
+	tables := []struct {
+		dataType string
+		headers  []string
+		records  [][]string
+	}{
+		{
+			dataType: "profile_tgscan",
+			headers:  []string{"User ID", "Username", "First Name", "Last Name", "Search Query", "Known Groups", "Groups Found", "Cost"},
+			records: [][]string{{
+				userID,
+				user.Username,
+				user.FirstName,
+				user.LastName,
+				result.Meta.SearchQuery,
+				strconv.Itoa(result.Meta.KnownNumGroups),
+				strconv.Itoa(result.Meta.NumGroups),
+				strconv.Itoa(result.Meta.OpCost),
+			}},
+		},
+		{
+			dataType: "usernames_tgscan",
+			headers:  []string{"User ID", "Current Username", "Previous Username", "Date Changed"},
+			// The first row is the current username
+			records: [][]string{{userID, user.Username, "", ""}},
+		},
+		{
+			dataType: "ids_tgscan",
+			headers:  []string{"User ID", "Current Username", "Previous ID", "Date Changed"},
+		},
+		{
+			dataType: "groups_tgscan",
+			headers:  []string{"User ID", "User Username", "Group ID", "Group Title", "Group Username", "Date Updated"},
+		},
+	}
+	for _, h := range result.UsernameHistory {
+		tables[1].records = append(tables[1].records, []string{userID, user.Username, h.Username, h.Date})
+	}
+	for _, h := range result.IDHistory {
+		tables[2].records = append(tables[2].records, []string{userID, user.Username, strconv.FormatInt(h.ID, 10), h.Date})
+	}
+	for _, g := range result.Groups {
+		tables[3].records = append(tables[3].records, []string{userID, user.Username, formatGroupID(g.ID), g.Title, g.Username, g.DateUpdated})
+	}
+
+	var files []string
+	for _, table := range tables {
+		writer, filename, err := newHeaderCSVWriter(basename, table.dataType, table.headers)
+		if err != nil {
+			return files, err
+		}
+		for _, record := range table.records {
+			if err := writer.WriteRecord(record); err != nil {
+				writer.Close()
+				return files, err
+			}
+		}
+		if err := writer.Close(); err != nil {
+			return files, fmt.Errorf("error writing CSV file: %w", err)
+		}
+		files = append(files, filename)
+	}
+	return files, nil
+}
+
+// formatGroupID returns a group ID, or "" when TGScan didn't report one
+func formatGroupID(id int64) string {
+	if id == 0 {
+		return ""
+	}
+	return strconv.FormatInt(id, 10)
+}
@@ -0,0 +1,98 @@
+package export
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/gnomegl/teleslurp/internal/types"
+)
+
+// channelSection is a channel and the messages found in it
+type channelSection struct {
+	Channel  types.ChannelMetadata
+	Messages []types.MessageData
+}
+
+// Name returns the channel title with its username, if any
+func (s channelSection) Name() string {
+	if s.Channel.ChannelUsername == "" {
+		return s.Channel.ChannelTitle
+	}
+	return s.Channel.ChannelTitle + " (@" + s.Channel.ChannelUsername + ")"
+}
+
+// IsAdmin reports whether the target is listed as an admin of the channel
+func (s channelSection) IsAdmin(username string) bool {
+	if username == "" {
+		return false
+	}
+	for _, admin := range strings.Split(s.Channel.ChannelAdmins, ", ") {
+		if admin == username {
+			return true
+		}
+	}
+	return false
+}
+
+// channelSections groups the messages by channel, in the order the channels
+// were searched
+func channelSections(results *SearchResults) []channelSection {
+	sections := make([]channelSection, len(results.Channels))
+	index := make(map[string]int, len(results.Channels))
+	for i, ch := range results.Channels {
+		sections[i].Channel = ch
+		index[channelKey(ch.ChannelUsername, ch.ChannelTitle)] = i
+	}
+
+	for _, msg := range results.Messages {
+		key := channelKey(msg.ChannelUsername, msg.ChannelTitle)
+		i, ok := index[key]
+		if !ok {
+			i = len(sections)
+			index[key] = i
+			sections = append(sections, channelSection{Channel: types.ChannelMetadata{
+				ChannelTitle:    msg.ChannelTitle,
+				ChannelUsername: msg.ChannelUsername,
+			}})
+		}
+		sections[i].Messages = append(sections[i].Messages, msg)
+	}
+	return sections
+}
+
+func channelKey(username, title string) string {
+	if username != "" {
+		return "@" + username
+	}
+	return title
+}
+
+// timeline returns all messages ordered by date, oldest first
+func timeline(results *SearchResults) []types.MessageData {
+	messages := make([]types.MessageData, len(results.Messages))
+	copy(messages, results.Messages)
+	sort.SliceStable(messages, func(i, j int) bool {
+		return messages[i].Date < messages[j].Date
+	})
+	return messages
+}
+
+// targetName returns the best available label for the searched user
+func targetName(results *SearchResults) string {
+	user := results.Target
+	if results.Profile != nil && results.Profile.Result.User.ID != 0 {
+		user = results.Profile.Result.User
+	}
+
+	name := strings.TrimSpace(user.FirstName + " " + user.LastName)
+	switch {
+	case user.Username != "" && name != "":
+		return name + " (@" + user.Username + ")"
+	case user.Username != "":
+		return "@" + user.Username
+	case name != "":
+		return name
+	default:
+		return "Unknown user"
+	}
+}
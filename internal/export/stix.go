@@ -0,0 +1,478 @@
+package export
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gnomegl/teleslurp/internal/indicators"
+	"github.com/gnomegl/teleslurp/internal/timeutil"
+	"github.com/gnomegl/teleslurp/internal/types"
+)
+
+func init() {
+	Register(stixExporter{})
+}
+
+// stixExporter writes a STIX 2.1 bundle for threat intelligence platforms
+// such as OpenCTI and MISP: the target as an identity and user-account,
+// channels as group identities, messages as observed-data and the links in
+// them as url observables with indicators
+type stixExporter struct{}
+
+func (stixExporter) Name() string { return "stix" }
+
+func (stixExporter) Description() string {
+	return "STIX 2.1 bundle of the user, channels, messages and the links in them"
+}
+
+// STIX namespaces for deterministic identifiers. Observables use the one
+// the specification defines, so the same URL gets the same ID everywhere;
+// teleslurp's own objects use a fixed namespace, so exporting the same user
+// or channel again updates the objects instead of duplicating them.
+const (
+	stixObservableNamespace = "00abedb4-aa42-466c-9c01-fed23315a9b7"
+	stixTeleslurpNamespace  = "7fe00e47-d505-47c6-8138-50e6d189a6f8"
+)
+
+// stixAccountType is the user-account account_type of Telegram accounts
+const stixAccountType = "telegram"
+
+// stixEpoch is the created time of objects without a date of their own,
+// Telegram's launch. Deterministic IDs must keep the same created time in
+// every export; only modified changes.
+const stixEpoch = "2013-08-14T00:00:00.000Z"
+
+// stixCommon holds the properties of every STIX object
+type stixCommon struct {
+	Type        string `json:"type"`
+	SpecVersion string `json:"spec_version"`
+	ID          string `json:"id"`
+}
+
+// stixDomain holds the properties of domain and relationship objects
+type stixDomain struct {
+	stixCommon
+	Created  string `json:"created"`
+	Modified string `json:"modified"`
+}
+
+type stixIdentity struct {
+	stixDomain
+	Name               string                  `json:"name"`
+	Description        string                  `json:"description,omitempty"`
+	IdentityClass      string                  `json:"identity_class"`
+	ContactInformation string                  `json:"contact_information,omitempty"`
+	TelegramID         int64                   `json:"x_telegram_id,omitempty"`
+	TelegramUsername   string                  `json:"x_telegram_username,omitempty"`
+	UsernameHistory    []types.UsernameHistory `json:"x_telegram_username_history,omitempty"`
+	IDHistory          []types.IDHistory       `json:"x_telegram_id_history,omitempty"`
+	MemberCount        int                     `json:"x_telegram_member_count,omitempty"`
+	Admins             []string                `json:"x_telegram_admins,omitempty"`
+}
+
+type stixUserAccount struct {
+	stixCommon
+	UserID          string                  `json:"user_id,omitempty"`
+	AccountLogin    string                  `json:"account_login,omitempty"`
+	AccountType     string                  `json:"account_type"`
+	DisplayName     string                  `json:"display_name,omitempty"`
+	UsernameHistory []types.UsernameHistory `json:"x_telegram_username_history,omitempty"`
+	IDHistory       []types.IDHistory       `json:"x_telegram_id_history,omitempty"`
+}
+
+type stixURL struct {
+	stixCommon
+	Value string `json:"value"`
+}
+
+type stixObservedData struct {
+	stixDomain
+	FirstObserved   string   `json:"first_observed"`
+	LastObserved    string   `json:"last_observed"`
+	NumberObserved  int      `json:"number_observed"`
+	ObjectRefs      []string `json:"object_refs"`
+	MessageID       int      `json:"x_telegram_message_id"`
+	Message         string   `json:"x_telegram_message,omitempty"`
+	MessageURL      string   `json:"x_telegram_message_url,omitempty"`
+	ChannelTitle    string   `json:"x_telegram_channel_title,omitempty"`
+	ChannelUsername string   `json:"x_telegram_channel_username,omitempty"`
+}
+
+type stixIndicator struct {
+	stixDomain
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Pattern     string `json:"pattern"`
+	PatternType string `json:"pattern_type"`
+	ValidFrom   string `json:"valid_from"`
+}
+
+type stixRelationship struct {
+	stixDomain
+	RelationshipType string `json:"relationship_type"`
+	Description      string `json:"description,omitempty"`
+	SourceRef        string `json:"source_ref"`
+	TargetRef        string `json:"target_ref"`
+	StartTime        string `json:"start_time,omitempty"`
+	MessageCount     int    `json:"x_telegram_message_count,omitempty"`
+}
+
+type stixBundle struct {
+	Type    string        `json:"type"`
+	ID      string        `json:"id"`
+	Objects []interface{} `json:"objects"`
+}
+
+func (stixExporter) NewWriter(search *Search, basename string) (Writer, error) {
+	w := &stixWriter{
+		basename: basename,
+		modified: stixTimestamp(time.Now()),
+		seen:     make(map[string]bool),
+	}
+	w.addTarget(search)
+	return w, nil
+}
+
+// stixWriter collects the bundle's objects, each once, and writes the
+// bundle when the search finishes
+type stixWriter struct {
+	basename string
+	modified string
+	objects  []interface{}
+	seen     map[string]bool
+
+	identity string // the target's identity
+	account  string // the target's user-account
+}
+
+// add appends an object unless one with the same ID is already in the bundle
+func (w *stixWriter) add(id string, object interface{}) {
+	if w.seen[id] {
+		return
+	}
+	w.seen[id] = true
+	w.objects = append(w.objects, object)
+}
+
+// domain returns the common properties of a domain or relationship object.
+// created is the time the object describes, such as a message date, or ""
+// for stixEpoch; modified is the time of the export.
+func (w *stixWriter) domain(typ, id, created string) stixDomain {
+	if created == "" {
+		created = stixEpoch
+	}
+	return stixDomain{
+		stixCommon: stixCommon{Type: typ, SpecVersion: "2.1", ID: id},
+		Created:    created,
+		Modified:   w.modified,
+	}
+}
+
+// addTarget adds the searched user as an identity and a user-account, with
+// the TGScan username and ID history and group memberships when known
+func (w *stixWriter) addTarget(search *Search) {
+	user := search.Target
+	var usernames []types.UsernameHistory
+	var ids []types.IDHistory
+	var groups []types.Group
+	if search.Profile != nil {
+		result := search.Profile.Result
+		if user.ID == 0 && user.Username == "" {
+			user = result.User
+		}
+		if user.FirstName == "" && user.LastName == "" {
+			user.FirstName, user.LastName = result.User.FirstName, result.User.LastName
+		}
+		usernames, ids, groups = result.UsernameHistory, result.IDHistory, result.Groups
+	}
+
+	key := TargetBasename(user)
+	if user.ID != 0 {
+		key = strconv.FormatInt(user.ID, 10)
+	}
+	name := strings.TrimSpace(user.FirstName + " " + user.LastName)
+	switch {
+	case name == "" && user.Username != "":
+		name = "@" + user.Username
+	case name == "":
+		name = key
+	}
+
+	identity := stixIdentity{
+		stixDomain:       w.domain("identity", stixID("identity", "telegram-user:"+key), ""),
+		Name:             name,
+		Description:      "Telegram user",
+		IdentityClass:    "individual",
+		TelegramID:       user.ID,
+		TelegramUsername: user.Username,
+		UsernameHistory:  usernames,
+		IDHistory:        ids,
+	}
+	if user.Username != "" {
+		identity.ContactInformation = "https://t.me/" + user.Username
+	}
+	w.identity = identity.ID
+	w.add(identity.ID, identity)
+
+	account := stixUserAccount{
+		AccountLogin:    user.Username,
+		AccountType:     stixAccountType,
+		DisplayName:     strings.TrimSpace(user.FirstName + " " + user.LastName),
+		UsernameHistory: usernames,
+		IDHistory:       ids,
+	}
+	if user.ID != 0 {
+		account.UserID = strconv.FormatInt(user.ID, 10)
+	}
+	account.stixCommon = stixCommon{Type: "user-account", SpecVersion: "2.1", ID: stixObservableID("user-account", map[string]string{
+		"account_type":  account.AccountType,
+		"user_id":       account.UserID,
+		"account_login": account.AccountLogin,
+	})}
+	w.account = account.ID
+	w.add(account.ID, account)
+	w.relate(account.ID, identity.ID, "related-to", "Telegram account of the user", "", "")
+
+	for _, group := range groups {
+		if group.ID == 0 && group.Username == "" {
+			continue
+		}
+		channel := w.addChannel(group.ID, group.Username, group.Title, 0, "")
+		w.relate(identity.ID, channel, "related-to", "Member according to TGScan", stixDate(group.DateUpdated), "")
+	}
+}
+
+// addChannel adds a channel or group as a group identity and returns its ID
+func (w *stixWriter) addChannel(id int64, username, title string, memberCount int, admins string) string {
+	username = strings.TrimPrefix(username, "@")
+	key := strings.ToLower(username)
+	if id != 0 {
+		key = strconv.FormatInt(id, 10)
+	}
+
+	identity := stixIdentity{
+		stixDomain:       w.domain("identity", stixID("identity", "telegram-channel:"+key), ""),
+		Name:             firstNonEmpty(title, "@"+username, key),
+		Description:      "Telegram channel",
+		IdentityClass:    "group",
+		TelegramID:       id,
+		TelegramUsername: username,
+		MemberCount:      memberCount,
+	}
+	if username != "" {
+		identity.ContactInformation = "https://t.me/" + username
+	}
+	for _, admin := range strings.Split(admins, ",") {
+		if admin = strings.TrimSpace(admin); admin != "" {
+			identity.Admins = append(identity.Admins, admin)
+		}
+	}
+
+	// A group listed by TGScan may be searched later with its member count
+	// and admins, so the richer object replaces the first one
+	if w.seen[identity.ID] && (memberCount > 0 || len(identity.Admins) > 0) {
+		for i, object := range w.objects {
+			if known, ok := object.(stixIdentity); ok && known.ID == identity.ID {
+				w.objects[i] = identity
+			}
+		}
+	}
+	w.add(identity.ID, identity)
+	return identity.ID
+}
+
+// addURL adds a url observable and returns its ID
+func (w *stixWriter) addURL(value string) string {
+	id := stixObservableID("url", map[string]string{"value": value})
+	w.add(id, stixURL{stixCommon: stixCommon{Type: "url", SpecVersion: "2.1", ID: id}, Value: value})
+	return id
+}
+
+// relate adds a relationship between two objects
+func (w *stixWriter) relate(source, target, typ, description, start, created string) {
+	r := w.relationship(source, target, typ, description, start, created)
+	w.add(r.ID, r)
+}
+
+// relationship returns a relationship between two objects. The description
+// is part of its identity, so a user can both be a member of a group and
+// post in it.
+func (w *stixWriter) relationship(source, target, typ, description, start, created string) stixRelationship {
+	id := stixID("relationship", typ+":"+source+"->"+target+":"+description)
+	return stixRelationship{
+		stixDomain:       w.domain("relationship", id, created),
+		RelationshipType: typ,
+		Description:      description,
+		SourceRef:        source,
+		TargetRef:        target,
+		StartTime:        start,
+	}
+}
+
+func (w *stixWriter) WriteChannel(channel types.ChannelMetadata, messages []types.MessageData) error {
+	channelID := w.addChannel(channel.ChannelID, channel.ChannelUsername, channel.ChannelTitle, channel.MemberCount, channel.ChannelAdmins)
+	if len(messages) > 0 {
+		posted := w.relationship(w.identity, channelID, "related-to", "Posted in the channel", stixDate(channel.UserFirstMessage), "")
+		posted.MessageCount = len(messages)
+		w.add(posted.ID, posted)
+	}
+
+	for _, msg := range messages {
+		date := stixDate(msg.Date)
+		observed := date
+		if observed == "" {
+			observed = w.modified
+		}
+
+		refs := []string{w.account}
+		if msg.URL != "" {
+			refs = append(refs, w.addURL(msg.URL))
+		}
+		var links []string
+		for _, ind := range msg.Indicators {
+			if ind.Type != indicators.TypeURL && ind.Type != indicators.TypeTelegramLink {
+				continue
+			}
+			refs = append(refs, w.addURL(ind.Value))
+			links = append(links, ind.Value)
+		}
+
+		data := stixObservedData{
+			stixDomain:      w.domain("observed-data", stixID("observed-data", fmt.Sprintf("telegram-message:%s:%d", channelID, msg.MessageID)), date),
+			FirstObserved:   observed,
+			LastObserved:    observed,
+			NumberObserved:  1,
+			ObjectRefs:      refs,
+			MessageID:       msg.MessageID,
+			Message:         msg.Message,
+			MessageURL:      msg.URL,
+			ChannelTitle:    msg.ChannelTitle,
+			ChannelUsername: msg.ChannelUsername,
+		}
+		w.add(data.ID, data)
+		w.relate(data.ID, channelID, "related-to", "Message posted in the channel", "", date)
+
+		for _, link := range links {
+			indicator := w.addIndicator(link, observed)
+			w.relate(indicator, data.ID, "based-on", "", "", date)
+		}
+	}
+	return nil
+}
+
+// addIndicator adds an indicator matching a link, valid from when it was
+// first seen, and returns its ID
+func (w *stixWriter) addIndicator(link, validFrom string) string {
+	id := stixID("indicator", "url:"+link)
+	w.add(id, stixIndicator{
+		stixDomain:  w.domain("indicator", id, ""),
+		Name:        link,
+		Description: "Link posted by the user on Telegram",
+		Pattern:     fmt.Sprintf("[url:value = '%s']", stixEscape(link)),
+		PatternType: "stix",
+		ValidFrom:   validFrom,
+	})
+	return id
+}
+
+func (w *stixWriter) Close() ([]string, error) {
+	bundle := stixBundle{Type: "bundle", ID: "bundle--" + uuid4(), Objects: w.objects}
+	data, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error encoding STIX bundle: %w", err)
+	}
+
+	file, err := CreateFile(w.basename, "stix", "json")
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return nil, fmt.Errorf("error writing STIX bundle: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return nil, fmt.Errorf("error writing STIX bundle: %w", err)
+	}
+	return []string{file.Name()}, nil
+}
+
+// stixID returns a deterministic identifier for one of teleslurp's objects
+func stixID(typ, key string) string {
+	return typ + "--" + uuid5(stixTeleslurpNamespace, key)
+}
+
+// stixObservableID returns the identifier the STIX specification derives
+// from an observable's ID contributing properties, which are serialized as
+// canonical JSON without the empty ones
+func stixObservableID(typ string, properties map[string]string) string {
+	contributing := make(map[string]string)
+	for k, v := range properties {
+		if v != "" {
+			contributing[k] = v
+		}
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(contributing) // maps of strings always encode, with sorted keys
+	return typ + "--" + uuid5(stixObservableNamespace, strings.TrimSuffix(buf.String(), "\n"))
+}
+
+// uuid5 returns the name based (SHA-1) UUID of name in a namespace
+func uuid5(namespace, name string) string {
+	ns, _ := hex.DecodeString(strings.ReplaceAll(namespace, "-", ""))
+	h := sha1.New()
+	h.Write(ns)
+	h.Write([]byte(name))
+	return formatUUID(h.Sum(nil)[:16], 5)
+}
+
+// uuid4 returns a random UUID
+func uuid4() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return formatUUID(b, 4)
+}
+
+func formatUUID(b []byte, version byte) string {
+	b[6] = b[6]&0x0f | version<<4
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// stixTimestamp formats a time as STIX requires: UTC with milliseconds
+func stixTimestamp(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05.000Z")
+}
+
+// stixDate converts a date as teleslurp and TGScan record it to a STIX
+// timestamp, or returns "" if it is unknown
+func stixDate(value string) string {
+	t, err := timeutil.Parse(value)
+	if err != nil {
+		return ""
+	}
+	return stixTimestamp(t)
+}
+
+// stixEscape escapes a string literal in a STIX pattern
+func stixEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s)
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" && v != "@" {
+			return v
+		}
+	}
+	return ""
+}
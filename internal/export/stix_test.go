@@ -0,0 +1,296 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gnomegl/teleslurp/internal/indicators"
+	"github.com/gnomegl/teleslurp/internal/types"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// stixSchemaDir holds the STIX 2.1 JSON schemas, laid out like the OASIS
+// cti-stix2-json-schemas repository
+const stixSchemaDir = "testdata/stix2.1/schemas"
+
+// stixSchemaURL is the $id prefix of the schemas
+const stixSchemaURL = "http://raw.githubusercontent.com/oasis-open/cti-stix2-json-schemas/stix2.1/schemas/"
+
+// stixSchemaFiles maps the object types the exporter writes to their schema
+var stixSchemaFiles = map[string]string{
+	"bundle":        "common/bundle.json",
+	"identity":      "sdos/identity.json",
+	"indicator":     "sdos/indicator.json",
+	"observed-data": "sdos/observed-data.json",
+	"relationship":  "sros/relationship.json",
+	"url":           "observables/url.json",
+	"user-account":  "observables/user-account.json",
+}
+
+// loadSTIXSchemas compiles the schema of every object type in
+// stixSchemaFiles. Each schema file is registered under its $id so
+// references between them resolve without network access.
+func loadSTIXSchemas(t *testing.T) map[string]*jsonschema.Schema {
+	t.Helper()
+	compiler := jsonschema.NewCompiler()
+	err := filepath.WalkDir(stixSchemaDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || filepath.Ext(path) != ".json" {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var doc struct {
+			ID string `json:"$id"`
+		}
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return fmt.Errorf("error parsing %s: %w", path, err)
+		}
+		return compiler.AddResource(doc.ID, bytes.NewReader(data))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	schemas := make(map[string]*jsonschema.Schema)
+	for typ, file := range stixSchemaFiles {
+		schema, err := compiler.Compile(stixSchemaURL + file)
+		if err != nil {
+			t.Fatalf("error compiling %s: %v", file, err)
+		}
+		schemas[typ] = schema
+	}
+	return schemas
+}
+
+// stixFixture returns a search with a TGScan profile and the channels and
+// messages it found
+func stixFixture() (*Search, []types.ChannelMetadata, [][]types.MessageData) {
+	profile := &types.TGScanResponse{Status: "ok"}
+	profile.Result.User = types.User{ID: 42, Username: "johndoe", FirstName: "John", LastName: "Doe"}
+	profile.Result.UsernameHistory = []types.UsernameHistory{
+		{Username: "johnny", Date: "2023-02-01T10:00:00Z"},
+		{Username: "jd", Date: "2022-06-15"},
+	}
+	profile.Result.IDHistory = []types.IDHistory{{ID: 41, Date: "2021-01-01T00:00:00Z"}}
+	profile.Result.Meta = types.Meta{SearchQuery: "johndoe", KnownNumGroups: 3, NumGroups: 2, OpCost: 1}
+	profile.Result.Groups = []types.Group{
+		{ID: 100, Username: "news", Title: "News", DateUpdated: "2024-04-01T08:00:00Z"},
+		{Username: "chat", Title: "Chat", DateUpdated: "unknown"},
+		{Title: "Private group without ID or username"},
+	}
+
+	search := &Search{Target: profile.Result.User, Profile: profile}
+	channels := []types.ChannelMetadata{
+		{
+			ChannelID:        100,
+			ChannelTitle:     "News",
+			ChannelUsername:  "news",
+			ChannelAdmins:    "alice, bob",
+			MemberCount:      1500,
+			UserFirstMessage: "2024-05-01T14:03:22Z",
+		},
+		{ChannelID: 200, ChannelTitle: "Quiet", ChannelUsername: "quiet"},
+	}
+	text := "Details at https://example.com/a?b=c's and t.me/foo, mail john@example.com"
+	messages := [][]types.MessageData{
+		{
+			{
+				ChannelTitle:    "News",
+				ChannelUsername: "news",
+				MessageID:       7,
+				Date:            "2024-05-01T14:03:22Z",
+				Message:         text,
+				URL:             "https://t.me/news/7",
+				Indicators:      indicators.Extract(text),
+			},
+			{
+				ChannelTitle:    "News",
+				ChannelUsername: "news",
+				MessageID:       8,
+				Message:         "again https://example.com/a?b=c's",
+				Indicators:      indicators.Extract("again https://example.com/a?b=c's"),
+			},
+		},
+		nil,
+	}
+	return search, channels, messages
+}
+
+// exportSTIX writes the fixture as a STIX bundle and returns it decoded
+func exportSTIX(t *testing.T) map[string]interface{} {
+	t.Helper()
+	search, channels, messages := stixFixture()
+	w, err := stixExporter{}.NewWriter(search, filepath.Join(t.TempDir(), "johndoe"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, channel := range channels {
+		if err := w.WriteChannel(channel, messages[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files, err := w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("wrote %v, want one bundle", files)
+	}
+
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var bundle map[string]interface{}
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		t.Fatalf("bundle is not JSON: %v", err)
+	}
+	return bundle
+}
+
+func bundleObjects(t *testing.T, bundle map[string]interface{}) []map[string]interface{} {
+	t.Helper()
+	list, _ := bundle["objects"].([]interface{})
+	objects := make([]map[string]interface{}, 0, len(list))
+	for i, o := range list {
+		object, ok := o.(map[string]interface{})
+		if !ok {
+			t.Fatalf("objects[%d] is %T, want an object", i, o)
+		}
+		objects = append(objects, object)
+	}
+	return objects
+}
+
+func TestSTIXBundleSchema(t *testing.T) {
+	schemas := loadSTIXSchemas(t)
+	bundle := exportSTIX(t)
+
+	if err := schemas["bundle"].Validate(bundle); err != nil {
+		t.Errorf("bundle: %#v", err)
+	}
+
+	types := make(map[string]int)
+	for i, object := range bundleObjects(t, bundle) {
+		typ, _ := object["type"].(string)
+		id, _ := object["id"].(string)
+		path := fmt.Sprintf("objects[%d] (%s)", i, id)
+		types[typ]++
+
+		schema, ok := schemas[typ]
+		if !ok || typ == "bundle" {
+			t.Errorf("%s: no schema for type %q", path, typ)
+			continue
+		}
+		if err := schema.Validate(object); err != nil {
+			t.Errorf("%s: %#v", path, err)
+		}
+	}
+
+	// The fixture has every kind of object the exporter writes
+	for typ := range stixSchemaFiles {
+		if typ != "bundle" && types[typ] == 0 {
+			t.Errorf("bundle has no %s objects", typ)
+		}
+	}
+}
+
+func TestSTIXBundleReferences(t *testing.T) {
+	objects := bundleObjects(t, exportSTIX(t))
+
+	byID := make(map[string]map[string]interface{})
+	for _, object := range objects {
+		id, _ := object["id"].(string)
+		if byID[id] != nil {
+			t.Errorf("%s appears more than once", id)
+		}
+		byID[id] = object
+	}
+
+	for _, object := range objects {
+		for name, value := range object {
+			var refs []interface{}
+			switch {
+			case strings.HasSuffix(name, "_ref"):
+				refs = []interface{}{value}
+			case strings.HasSuffix(name, "_refs"):
+				refs, _ = value.([]interface{})
+			default:
+				continue
+			}
+			for _, r := range refs {
+				ref, _ := r.(string)
+				target, ok := byID[ref]
+				if !ok {
+					t.Errorf("%s.%s: %q is not in the bundle", object["id"], name, r)
+					continue
+				}
+				if typ, _ := target["type"].(string); !strings.HasPrefix(ref, typ+"--") {
+					t.Errorf("%s.%s: %s refers to a %s", object["id"], name, ref, typ)
+				}
+				// Observed data refers to the observables it saw
+				if name == "object_refs" && object["type"] == "observed-data" {
+					if _, sdo := target["created"]; sdo && target["type"] != "relationship" {
+						t.Errorf("%s.object_refs: %s is not an observable", object["id"], ref)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestSTIXStableIDs(t *testing.T) {
+	ids := func() []string {
+		var ids []string
+		for _, object := range bundleObjects(t, exportSTIX(t)) {
+			ids = append(ids, object["id"].(string))
+		}
+		sort.Strings(ids)
+		return ids
+	}
+
+	first, second := ids(), ids()
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("object IDs changed between exports:\n%v\n%v", first, second)
+	}
+}
+
+func TestSTIXStableCreated(t *testing.T) {
+	created := func() map[string]string {
+		created := make(map[string]string)
+		for _, object := range bundleObjects(t, exportSTIX(t)) {
+			id := object["id"].(string)
+			c, ok := object["created"].(string)
+			if !ok {
+				continue
+			}
+			if m, _ := object["modified"].(string); m < c {
+				t.Errorf("%s: modified %s is before created %s", id, m, c)
+			}
+			created[id] = c
+			if object["type"] == "observed-data" && object["x_telegram_message_id"] == 7.0 && c != "2024-05-01T14:03:22.000Z" {
+				t.Errorf("%s: created %s, want the message date", id, c)
+			}
+		}
+		return created
+	}
+
+	first := created()
+	// Let the export time change between the exports
+	time.Sleep(5 * time.Millisecond)
+	second := created()
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("created times changed between exports:\n%v\n%v", first, second)
+	}
+}
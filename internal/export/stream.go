@@ -0,0 +1,107 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// JSONArrayWriter writes a JSON array one element at a time, so the elements
+// never have to be held in memory together. Everything written before Flush
+// is on disk, but the array is only valid JSON after Close.
+type JSONArrayWriter struct {
+	file *os.File
+	buf  *bufio.Writer
+	n    int
+}
+
+// NewJSONArrayWriter writes an array to file, which Close closes
+func NewJSONArrayWriter(file *os.File) *JSONArrayWriter {
+	return &JSONArrayWriter{file: file, buf: bufio.NewWriter(file)}
+}
+
+// Write appends an element to the array
+func (w *JSONArrayWriter) Write(v interface{}) error {
+	data, err := json.MarshalIndent(v, "  ", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding JSON: %w", err)
+	}
+
+	sep := ",\n  "
+	if w.n == 0 {
+		sep = "[\n  "
+	}
+	if _, err := w.buf.WriteString(sep); err != nil {
+		return fmt.Errorf("error writing JSON: %w", err)
+	}
+	if _, err := w.buf.Write(data); err != nil {
+		return fmt.Errorf("error writing JSON: %w", err)
+	}
+	w.n++
+	return nil
+}
+
+// Flush writes buffered elements to disk
+func (w *JSONArrayWriter) Flush() error {
+	if err := w.buf.Flush(); err != nil {
+		return fmt.Errorf("error writing JSON: %w", err)
+	}
+	return nil
+}
+
+// Close ends the array and closes the file
+func (w *JSONArrayWriter) Close() error {
+	end := "\n]\n"
+	if w.n == 0 {
+		end = "[]\n"
+	}
+	if _, err := w.buf.WriteString(end); err != nil {
+		w.file.Close()
+		return fmt.Errorf("error writing JSON: %w", err)
+	}
+	if err := w.Flush(); err != nil {
+		w.file.Close()
+		return err
+	}
+	return w.file.Close()
+}
+
+// NDJSONWriter writes newline-delimited JSON, one value per line. Every
+// flushed line is a complete record, so partial files stay usable.
+type NDJSONWriter struct {
+	file    *os.File
+	buf     *bufio.Writer
+	encoder *json.Encoder
+}
+
+// NewNDJSONWriter writes lines to file, which Close closes
+func NewNDJSONWriter(file *os.File) *NDJSONWriter {
+	buf := bufio.NewWriter(file)
+	return &NDJSONWriter{file: file, buf: buf, encoder: json.NewEncoder(buf)}
+}
+
+// Write appends a value as one line
+func (w *NDJSONWriter) Write(v interface{}) error {
+	if err := w.encoder.Encode(v); err != nil {
+		return fmt.Errorf("error encoding NDJSON: %w", err)
+	}
+	return nil
+}
+
+// Flush writes buffered lines to disk
+func (w *NDJSONWriter) Flush() error {
+	if err := w.buf.Flush(); err != nil {
+		return fmt.Errorf("error writing NDJSON: %w", err)
+	}
+	return nil
+}
+
+// Close flushes and closes the file
+func (w *NDJSONWriter) Close() error {
+	if err := w.Flush(); err != nil {
+		w.file.Close()
+		return err
+	}
+	return w.file.Close()
+}
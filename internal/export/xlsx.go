@@ -0,0 +1,431 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gnomegl/teleslurp/internal/indicators"
+	"github.com/gnomegl/teleslurp/internal/timeutil"
+	"github.com/gnomegl/teleslurp/internal/types"
+	"github.com/xuri/excelize/v2"
+)
+
+func init() {
+	Register(xlsxExporter{})
+}
+
+// xlsxExporter writes an Excel workbook: a summary sheet, the TGScan
+// profile, the channel metadata and one sheet of messages per channel
+type xlsxExporter struct{}
+
+func (xlsxExporter) Name() string { return "xlsx" }
+
+func (xlsxExporter) Description() string {
+	return "Excel workbook with summary, profile, channel and per-channel message sheets"
+}
+
+// Sheets every workbook starts with
+const (
+	xlsxSummarySheet  = "Summary"
+	xlsxProfileSheet  = "Profile"
+	xlsxChannelsSheet = "Channels"
+)
+
+// xlsxMaxCellLength is the most characters Excel accepts in a cell
+const xlsxMaxCellLength = 32767
+
+func (xlsxExporter) NewWriter(search *Search, basename string) (Writer, error) {
+	f := excelize.NewFile()
+	w := &xlsxWriter{
+		search:   search,
+		basename: basename,
+		file:     f,
+		sheets:   map[string]bool{"summary": true, "profile": true, "channels": true},
+	}
+
+	var err error
+	if w.header, err = f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Bold: true},
+		Fill: excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"DDE4EE"}},
+	}); err != nil {
+		return nil, fmt.Errorf("error creating XLSX styles: %w", err)
+	}
+	dateFormat := "yyyy-mm-dd hh:mm:ss"
+	if w.date, err = f.NewStyle(&excelize.Style{CustomNumFmt: &dateFormat}); err != nil {
+		return nil, fmt.Errorf("error creating XLSX styles: %w", err)
+	}
+	if w.link, err = f.NewStyle(&excelize.Style{Font: &excelize.Font{Color: "1F5FBF", Underline: "single"}}); err != nil {
+		return nil, fmt.Errorf("error creating XLSX styles: %w", err)
+	}
+	if w.wrap, err = f.NewStyle(&excelize.Style{Alignment: &excelize.Alignment{WrapText: true, Vertical: "top"}}); err != nil {
+		return nil, fmt.Errorf("error creating XLSX styles: %w", err)
+	}
+
+	if err := f.SetSheetName("Sheet1", xlsxSummarySheet); err != nil {
+		return nil, fmt.Errorf("error creating XLSX sheet: %w", err)
+	}
+	if search.Profile != nil {
+		if err := w.writeProfile(search.Profile); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := f.NewSheet(xlsxChannelsSheet); err != nil {
+		return nil, fmt.Errorf("error creating XLSX sheet: %w", err)
+	}
+	if err := w.writeHeader(xlsxChannelsSheet, 1, []string{"Channel Title", "Channel Username", "Channel Link", "Channel Admins", "Member Count", "User Join Date", "Messages", "Sheet"}, []float64{30, 20, 35, 40, 14, 20, 10, 30}); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// xlsxWriter adds a sheet to the workbook for every channel as it completes.
+// The workbook itself is only saved by Close.
+type xlsxWriter struct {
+	search   *Search
+	basename string
+	file     *excelize.File
+	sheets   map[string]bool // lower-cased, as Excel compares sheet names
+
+	// Channels and their message counts, for the summary
+	channels []types.ChannelMetadata
+	counts   []int
+
+	header, date, link, wrap int
+}
+
+func (w *xlsxWriter) WriteChannel(channel types.ChannelMetadata, messages []types.MessageData) error {
+	sheet := w.sheetName(channelSection{Channel: channel}.Name())
+	if _, err := w.file.NewSheet(sheet); err != nil {
+		return fmt.Errorf("error creating XLSX sheet: %w", err)
+	}
+	if err := w.writeHeader(sheet, 1, []string{"Message ID", "Date", "Message", "URL", "Indicators"}, []float64{12, 20, 80, 35, 40}); err != nil {
+		return err
+	}
+
+	for i, msg := range messages {
+		row := i + 2
+		if err := w.setRow(sheet, row, []interface{}{
+			msg.MessageID,
+			w.dateValue(msg.Date),
+			xlsxText(msg.Message),
+			msg.URL,
+			xlsxText(indicators.Format(msg.Indicators)),
+		}); err != nil {
+			return err
+		}
+		if err := w.setDate(sheet, "B", row, msg.Date); err != nil {
+			return err
+		}
+		if err := w.file.SetCellStyle(sheet, cell("C", row), cell("C", row), w.wrap); err != nil {
+			return fmt.Errorf("error writing XLSX sheet: %w", err)
+		}
+		if err := w.setLink(sheet, "D", row, msg.URL); err != nil {
+			return err
+		}
+	}
+
+	row := len(w.channels) + 2
+	if err := w.setRow(xlsxChannelsSheet, row, []interface{}{
+		channel.ChannelTitle,
+		channel.ChannelUsername,
+		channel.ChannelLink,
+		channel.ChannelAdmins,
+		channel.MemberCount,
+		w.dateValue(channel.UserFirstMessage),
+		len(messages),
+		sheet,
+	}); err != nil {
+		return err
+	}
+	if err := w.setLink(xlsxChannelsSheet, "C", row, channel.ChannelLink); err != nil {
+		return err
+	}
+	if err := w.setDate(xlsxChannelsSheet, "F", row, channel.UserFirstMessage); err != nil {
+		return err
+	}
+	if err := w.file.SetCellHyperLink(xlsxChannelsSheet, cell("H", row), fmt.Sprintf("'%s'!A1", sheet), "Location"); err != nil {
+		return fmt.Errorf("error writing XLSX sheet: %w", err)
+	}
+
+	w.channels = append(w.channels, channel)
+	w.counts = append(w.counts, len(messages))
+	return nil
+}
+
+func (w *xlsxWriter) Close() ([]string, error) {
+	defer w.file.Close()
+
+	if err := w.writeSummary(); err != nil {
+		return nil, err
+	}
+	w.file.SetActiveSheet(0)
+
+	file, err := CreateFile(w.basename, "report", "xlsx")
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	if err := w.file.Write(file); err != nil {
+		return nil, fmt.Errorf("error writing XLSX file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return nil, fmt.Errorf("error writing XLSX file: %w", err)
+	}
+	return []string{file.Name()}, nil
+}
+
+// writeSummary lists the channels like the summary printed after a search
+func (w *xlsxWriter) writeSummary() error {
+	sheet := xlsxSummarySheet
+	target := w.search.Target
+
+	rows := [][]interface{}{
+		{"Target", targetName(&SearchResults{Search: *w.search})},
+		{"User ID", profileUserID(&SearchResults{Search: *w.search})},
+		{"Generated", w.dateValue(timeutil.Now())},
+	}
+	for i, r := range rows {
+		if err := w.setRow(sheet, i+1, r); err != nil {
+			return err
+		}
+		if err := w.file.SetCellStyle(sheet, cell("A", i+1), cell("A", i+1), w.header); err != nil {
+			return fmt.Errorf("error writing XLSX sheet: %w", err)
+		}
+	}
+	if err := w.file.SetCellStyle(sheet, "B3", "B3", w.date); err != nil {
+		return fmt.Errorf("error writing XLSX sheet: %w", err)
+	}
+
+	start := len(rows) + 2
+	if err := w.writeHeader(sheet, start, []string{"Channel", "Admin", "Messages", "Members", "First Seen"}, []float64{45, 8, 10, 12, 20}); err != nil {
+		return err
+	}
+
+	var totalMessages, totalMembers int
+	for i, ch := range w.channels {
+		row := start + 1 + i
+		admin := ""
+		if (channelSection{Channel: ch}).IsAdmin(target.Username) {
+			admin = "Yes"
+		}
+		if err := w.setRow(sheet, row, []interface{}{
+			channelSection{Channel: ch}.Name(),
+			admin,
+			w.counts[i],
+			ch.MemberCount,
+			w.dateValue(ch.UserFirstMessage),
+		}); err != nil {
+			return err
+		}
+		if err := w.setDate(sheet, "E", row, ch.UserFirstMessage); err != nil {
+			return err
+		}
+		totalMessages += w.counts[i]
+		totalMembers += ch.MemberCount
+	}
+
+	totals := start + 1 + len(w.channels)
+	if err := w.setRow(sheet, totals, []interface{}{fmt.Sprintf("Total: %d channels", len(w.channels)), "", totalMessages, totalMembers}); err != nil {
+		return err
+	}
+	if err := w.file.SetCellStyle(sheet, cell("A", totals), cell("D", totals), w.header); err != nil {
+		return fmt.Errorf("error writing XLSX sheet: %w", err)
+	}
+	return nil
+}
+
+// writeProfile adds the TGScan profile: the user, the TGScan meta data,
+// username and ID history and known groups
+func (w *xlsxWriter) writeProfile(profile *types.TGScanResponse) error {
+	sheet := xlsxProfileSheet
+	if _, err := w.file.NewSheet(sheet); err != nil {
+		return fmt.Errorf("error creating XLSX sheet: %w", err)
+	}
+
+	user := profile.Result.User
+	rows := [][]interface{}{
+		{"User ID", user.ID},
+		{"Username", user.Username},
+		{"First Name", user.FirstName},
+		{"Last Name", user.LastName},
+		{"Search Query", profile.Result.Meta.SearchQuery},
+		{"Known Groups", profile.Result.Meta.KnownNumGroups},
+		{"Groups Found", profile.Result.Meta.NumGroups},
+		{"Cost", profile.Result.Meta.OpCost},
+	}
+	for i, r := range rows {
+		if err := w.setRow(sheet, i+1, r); err != nil {
+			return err
+		}
+		if err := w.file.SetCellStyle(sheet, cell("A", i+1), cell("A", i+1), w.header); err != nil {
+			return fmt.Errorf("error writing XLSX sheet: %w", err)
+		}
+	}
+
+	row := len(rows) + 2
+	if err := w.writeHeader(sheet, row, []string{"Previous Username", "Date Changed"}, []float64{30, 35}); err != nil {
+		return err
+	}
+	for _, h := range profile.Result.UsernameHistory {
+		row++
+		if err := w.setRow(sheet, row, []interface{}{h.Username, w.dateValue(h.Date)}); err != nil {
+			return err
+		}
+		if err := w.setDate(sheet, "B", row, h.Date); err != nil {
+			return err
+		}
+	}
+
+	row += 2
+	if err := w.writeHeader(sheet, row, []string{"Previous ID", "Date Changed"}, nil); err != nil {
+		return err
+	}
+	for _, h := range profile.Result.IDHistory {
+		row++
+		if err := w.setRow(sheet, row, []interface{}{h.ID, w.dateValue(h.Date)}); err != nil {
+			return err
+		}
+		if err := w.setDate(sheet, "B", row, h.Date); err != nil {
+			return err
+		}
+	}
+
+	row += 2
+	if err := w.writeHeader(sheet, row, []string{"Group Title", "Group Username", "Date Updated", "Link"}, nil); err != nil {
+		return err
+	}
+	for _, g := range profile.Result.Groups {
+		row++
+		link := ""
+		if g.Username != "" {
+			link = "https://t.me/" + g.Username
+		}
+		if err := w.setRow(sheet, row, []interface{}{g.Title, g.Username, w.dateValue(g.DateUpdated), link}); err != nil {
+			return err
+		}
+		if err := w.setDate(sheet, "C", row, g.DateUpdated); err != nil {
+			return err
+		}
+		if err := w.setLink(sheet, "D", row, link); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeHeader writes a bold header row and, if given, the column widths
+func (w *xlsxWriter) writeHeader(sheet string, row int, headers []string, widths []float64) error {
+	values := make([]interface{}, len(headers))
+	for i, h := range headers {
+		values[i] = h
+	}
+	if err := w.setRow(sheet, row, values); err != nil {
+		return err
+	}
+	last, _ := excelize.ColumnNumberToName(len(headers))
+	if err := w.file.SetCellStyle(sheet, cell("A", row), cell(last, row), w.header); err != nil {
+		return fmt.Errorf("error writing XLSX sheet: %w", err)
+	}
+
+	for i, width := range widths {
+		col, _ := excelize.ColumnNumberToName(i + 1)
+		if err := w.file.SetColWidth(sheet, col, col, width); err != nil {
+			return fmt.Errorf("error writing XLSX sheet: %w", err)
+		}
+	}
+	if row == 1 {
+		if err := w.file.SetPanes(sheet, &excelize.Panes{Freeze: true, YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft"}); err != nil {
+			return fmt.Errorf("error writing XLSX sheet: %w", err)
+		}
+	}
+	return nil
+}
+
+func (w *xlsxWriter) setRow(sheet string, row int, values []interface{}) error {
+	if err := w.file.SetSheetRow(sheet, cell("A", row), &values); err != nil {
+		return fmt.Errorf("error writing XLSX sheet: %w", err)
+	}
+	return nil
+}
+
+// setDate styles a cell as a date if its value parsed as one
+func (w *xlsxWriter) setDate(sheet, col string, row int, value string) error {
+	if _, ok := w.dateValue(value).(time.Time); !ok {
+		return nil
+	}
+	if err := w.file.SetCellStyle(sheet, cell(col, row), cell(col, row), w.date); err != nil {
+		return fmt.Errorf("error writing XLSX sheet: %w", err)
+	}
+	return nil
+}
+
+// setLink turns a cell holding a URL into a hyperlink
+func (w *xlsxWriter) setLink(sheet, col string, row int, url string) error {
+	if url == "" {
+		return nil
+	}
+	if err := w.file.SetCellHyperLink(sheet, cell(col, row), url, "External"); err != nil {
+		return fmt.Errorf("error writing XLSX sheet: %w", err)
+	}
+	if err := w.file.SetCellStyle(sheet, cell(col, row), cell(col, row), w.link); err != nil {
+		return fmt.Errorf("error writing XLSX sheet: %w", err)
+	}
+	return nil
+}
+
+// dateValue returns a date as a time.Time, so Excel stores it as a date,
+// or unchanged if it isn't one. Excel dates have no zone, so times are
+// stored as the wall clock in the --timezone.
+func (w *xlsxWriter) dateValue(value string) interface{} {
+	if value == "" {
+		return ""
+	}
+	t, err := timeutil.Parse(value)
+	if err != nil {
+		return value
+	}
+	if len(value) > len("2006-01-02") {
+		t = timeutil.In(t)
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+}
+
+// sheetName makes a unique, valid sheet name from a channel name
+func (w *xlsxWriter) sheetName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+	name = strings.Trim(name, "'")
+	if strings.TrimSpace(name) == "" {
+		name = "Channel"
+	}
+
+	candidate := truncateRunes(name, 31)
+	for i := 2; w.sheets[strings.ToLower(candidate)]; i++ {
+		suffix := fmt.Sprintf(" (%d)", i)
+		candidate = truncateRunes(name, 31-len(suffix)) + suffix
+	}
+	w.sheets[strings.ToLower(candidate)] = true
+	return candidate
+}
+
+func truncateRunes(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max])
+}
+
+// xlsxText cuts text to what fits in a cell
+func xlsxText(s string) string {
+	return truncateRunes(s, xlsxMaxCellLength)
+}
+
+func cell(col string, row int) string {
+	return fmt.Sprintf("%s%d", col, row)
+}
@@ -0,0 +1,113 @@
+package filter
+
+// Matcher finds all occurrences of a fixed set of terms in a single pass over
+// the text using an Aho-Corasick automaton, so matching cost does not grow
+// with the number of terms in a watchlist.
+type Matcher struct {
+	nodes []acNode
+	terms []string
+}
+
+type acNode struct {
+	next    map[byte]int
+	fail    int
+	outputs []int // indices into terms ending at this node
+}
+
+// NewMatcher compiles the terms into an automaton. Terms are matched as
+// given, so callers should normalize case beforehand.
+func NewMatcher(terms []string) *Matcher {
+	m := &Matcher{nodes: []acNode{{next: map[byte]int{}}}}
+
+	seen := make(map[string]bool)
+	for _, term := range terms {
+		if term == "" || seen[term] {
+			continue
+		}
+		seen[term] = true
+		m.insert(term)
+	}
+
+	m.build()
+	return m
+}
+
+// Len returns the number of distinct terms in the automaton
+func (m *Matcher) Len() int {
+	return len(m.terms)
+}
+
+func (m *Matcher) insert(term string) {
+	state := 0
+	for i := 0; i < len(term); i++ {
+		c := term[i]
+		next, ok := m.nodes[state].next[c]
+		if !ok {
+			next = len(m.nodes)
+			m.nodes = append(m.nodes, acNode{next: map[byte]int{}})
+			m.nodes[state].next[c] = next
+		}
+		state = next
+	}
+	m.nodes[state].outputs = append(m.nodes[state].outputs, len(m.terms))
+	m.terms = append(m.terms, term)
+}
+
+// build computes failure links breadth-first
+func (m *Matcher) build() {
+	queue := make([]int, 0, len(m.nodes))
+	for _, child := range m.nodes[0].next {
+		queue = append(queue, child)
+	}
+
+	for len(queue) > 0 {
+		state := queue[0]
+		queue = queue[1:]
+
+		for c, child := range m.nodes[state].next {
+			queue = append(queue, child)
+
+			fail := m.nodes[state].fail
+			for fail != 0 {
+				if _, ok := m.nodes[fail].next[c]; ok {
+					break
+				}
+				fail = m.nodes[fail].fail
+			}
+			if target, ok := m.nodes[fail].next[c]; ok && target != child {
+				m.nodes[child].fail = target
+			}
+
+			// Inherit matches of the suffix we fall back to
+			m.nodes[child].outputs = append(m.nodes[child].outputs, m.nodes[m.nodes[child].fail].outputs...)
+		}
+	}
+}
+
+// FindAll returns the distinct terms found in text, in order of first occurrence
+func (m *Matcher) FindAll(text string) []string {
+	var found []string
+	seen := make(map[int]bool)
+
+	state := 0
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		for state != 0 {
+			if _, ok := m.nodes[state].next[c]; ok {
+				break
+			}
+			state = m.nodes[state].fail
+		}
+		if next, ok := m.nodes[state].next[c]; ok {
+			state = next
+		}
+
+		for _, idx := range m.nodes[state].outputs {
+			if !seen[idx] {
+				seen[idx] = true
+				found = append(found, m.terms[idx])
+			}
+		}
+	}
+	return found
+}
@@ -1,11 +1,16 @@
 package filter
 
 import (
+	"context"
 	"fmt"
+	"os"
 	"regexp"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/gnomegl/teleslurp/internal/database"
+	"github.com/gnomegl/teleslurp/internal/indicators"
 )
 
 type MessageFilter interface {
@@ -13,86 +18,289 @@ type MessageFilter interface {
 }
 
 type FilterManager struct {
-	filters []MessageFilter
-	db      *database.DB
+	mu      sync.RWMutex
+	filters []rule
+	version int64
+	db      database.Store
 }
 
-func NewFilterManager(db *database.DB) *FilterManager {
+func NewFilterManager(db database.Store) *FilterManager {
 	return &FilterManager{
 		db:      db,
-		filters: []MessageFilter{},
+		filters: []rule{},
 	}
 }
 
-// LoadFilters loads all active filters from the database
+// LoadFilters loads all active filters from the database and atomically
+// replaces the current rule set
 func (fm *FilterManager) LoadFilters() error {
+	// Read the version first so a change made while loading triggers another reload
+	version, err := fm.db.GetChangeVersion("message_filters")
+	if err != nil {
+		return fmt.Errorf("error reading filter version: %w", err)
+	}
+
 	dbFilters, err := fm.db.GetActiveFilters()
 	if err != nil {
 		return fmt.Errorf("error loading filters: %w", err)
 	}
 
-	fm.filters = []MessageFilter{}
-	for _, f := range dbFilters {
-		switch f.Type {
-		case "keyword":
-			fm.filters = append(fm.filters, &KeywordFilter{
-				Keywords: strings.Split(f.Pattern, ","),
-				Action:   f.Action,
-			})
-		case "regex":
-			re, err := regexp.Compile(f.Pattern)
+	filters := buildFilters(fm.db, dbFilters)
+
+	fm.mu.Lock()
+	fm.filters = filters
+	fm.version = version
+	fm.mu.Unlock()
+
+	return nil
+}
+
+// Watch polls the filter table for changes and reloads the rule set when
+// filters are added, enabled or disabled from another process. It blocks
+// until the context is cancelled.
+func (fm *FilterManager) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			version, err := fm.db.GetChangeVersion("message_filters")
 			if err != nil {
-				fmt.Printf("Invalid regex pattern %s: %v\n", f.Pattern, err)
+				fmt.Printf("Warning: Failed to check filter version: %v\n", err)
+				continue
+			}
+
+			fm.mu.RLock()
+			changed := version != fm.version
+			fm.mu.RUnlock()
+
+			if !changed {
 				continue
 			}
-			fm.filters = append(fm.filters, &RegexFilter{
-				Pattern: re,
-				Action:  f.Action,
-			})
-		case "user":
-			// User filter expects comma-separated user IDs
-			fm.filters = append(fm.filters, &UserFilter{
-				UserIDs: f.Pattern,
-				Action:  f.Action,
-			})
-		case "channel":
-			// Channel filter expects comma-separated channel IDs
-			fm.filters = append(fm.filters, &ChannelFilter{
-				ChannelIDs: f.Pattern,
-				Action:     f.Action,
-			})
-		case "length":
-			fm.filters = append(fm.filters, &LengthFilter{
-				MinLength: parseMinLength(f.Pattern),
-				Action:    f.Action,
-			})
+
+			if err := fm.LoadFilters(); err != nil {
+				fmt.Printf("Warning: Failed to reload message filters: %v\n", err)
+				continue
+			}
+			fmt.Printf("Reloaded message filters (%d active)\n", fm.Count())
+		}
+	}
+}
+
+// Count returns the number of loaded filters
+func (fm *FilterManager) Count() int {
+	fm.mu.RLock()
+	defer fm.mu.RUnlock()
+	return len(fm.filters)
+}
+
+func buildFilters(db database.Store, dbFilters []database.MessageFilter) []rule {
+	rules := []rule{}
+	for _, f := range dbFilters {
+		mf := buildFilter(db, f)
+		if mf == nil {
+			continue
 		}
+		rules = append(rules, rule{ID: f.ID, Name: f.Name, Filter: mf})
 	}
 
+	return rules
+}
+
+func buildFilter(db database.Store, f database.MessageFilter) MessageFilter {
+	switch f.Type {
+	case "keyword":
+		return &KeywordFilter{
+			Keywords:    strings.Split(f.Pattern, ","),
+			Action:      f.Action,
+			Normalize:   f.Options["normalize"] == "true",
+			WholeWord:   f.Options["word"] == "true",
+			MaxDistance: parseMinLength(f.Options["max_distance"]),
+		}
+	case "regex":
+		re, err := regexp.Compile(f.Pattern)
+		if err != nil {
+			fmt.Printf("Invalid regex pattern %s: %v\n", f.Pattern, err)
+			return nil
+		}
+		return &RegexFilter{
+			Pattern: re,
+			Action:  f.Action,
+		}
+	case "user":
+		// User filter expects comma-separated user IDs
+		return &UserFilter{
+			UserIDs: f.Pattern,
+			Action:  f.Action,
+		}
+	case "channel":
+		// Channel filter expects comma-separated channel IDs
+		return &ChannelFilter{
+			ChannelIDs: f.Pattern,
+			Action:     f.Action,
+		}
+	case "length":
+		return &LengthFilter{
+			MinLength: parseMinLength(f.Pattern),
+			Action:    f.Action,
+		}
+	case "indicator":
+		return NewIndicatorFilter(strings.Split(f.Pattern, ","), f.Action)
+	case "keyword_list":
+		terms, err := loadKeywordList(db, int64(f.ID), f.Pattern)
+		if err != nil {
+			fmt.Printf("Could not load keyword list for filter %s: %v\n", f.Name, err)
+			return nil
+		}
+		return NewKeywordListFilter(terms, f.Options["normalize"] == "true", f.Action)
+	}
 	return nil
 }
 
+// loadKeywordList reads the terms of a keyword list filter. The pattern is
+// either "file:<path>" for a newline-separated file or "table" for terms
+// stored in the filter_keywords table.
+func loadKeywordList(db database.Store, filterID int64, pattern string) ([]string, error) {
+	if path, ok := strings.CutPrefix(pattern, "file:"); ok {
+		return ReadKeywordFile(path)
+	}
+	if pattern == "table" {
+		return db.GetFilterKeywords(filterID)
+	}
+	return nil, fmt.Errorf("invalid keyword list source %q (expected file:<path> or table)", pattern)
+}
+
+// ReadKeywordFile reads one term per line, skipping blank lines and # comments
+func ReadKeywordFile(path string) ([]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading keyword file: %w", err)
+	}
+
+	var terms []string
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		terms = append(terms, line)
+	}
+	return terms, nil
+}
+
+// rule is a loaded filter together with its database identity
+type rule struct {
+	ID     int
+	Name   string
+	Filter MessageFilter
+}
+
+// TermMatcher is implemented by filters that can report which terms matched
+type TermMatcher interface {
+	MatchedTerms(message string) []string
+}
+
+// Decision describes the outcome of running the filters on a message
+type Decision struct {
+	Process    bool
+	Action     string // 'forward', 'ignored', 'highlight'
+	FilterID   int    // 0 when no filter matched
+	FilterName string
+	Terms      []string // terms matched by the deciding filter, if it reports them
+}
+
 // ProcessMessage runs all filters on a message and returns whether to process it
 func (fm *FilterManager) ProcessMessage(message string, channelID int64, userID int64) (bool, string) {
-	for _, filter := range fm.filters {
-		shouldProcess, action := filter.ShouldProcess(message, channelID, userID)
-		if action == "ignore" && !shouldProcess {
-			return false, "ignored"
+	decision, _ := fm.decide(message, channelID, userID)
+	return decision.Process, decision.Action
+}
+
+// Evaluate runs all filters on a message, records match statistics for every
+// matching filter and writes an audit row describing the decision
+func (fm *FilterManager) Evaluate(messageID int, message string, channelID int64, userID int64) Decision {
+	decision, matched := fm.decide(message, channelID, userID)
+
+	for _, id := range matched {
+		if err := fm.db.RecordFilterMatch(id); err != nil {
+			fmt.Printf("Warning: Failed to record filter match: %v\n", err)
 		}
-		if action == "highlight" && shouldProcess {
-			return true, "highlight"
+	}
+
+	if err := fm.db.SaveFilterAudit(channelID, messageID, userID, decision.FilterID, decision.FilterName, decision.Action); err != nil {
+		fmt.Printf("Warning: Failed to save filter audit: %v\n", err)
+	}
+
+	return decision
+}
+
+// decide evaluates filters in priority order. The first matching ignore or
+// highlight filter decides; otherwise the first matching forward filter is
+// credited with the default forward. It also returns the IDs of all filters
+// that matched up to the decision.
+func (fm *FilterManager) decide(message string, channelID int64, userID int64) (Decision, []int) {
+	fm.mu.RLock()
+	rules := fm.filters
+	fm.mu.RUnlock()
+
+	decision := Decision{Process: true, Action: "forward"}
+	var matched []int
+	for _, r := range rules {
+		shouldProcess, action := r.Filter.ShouldProcess(message, channelID, userID)
+		if !shouldProcess {
+			continue
+		}
+		matched = append(matched, r.ID)
+
+		var terms []string
+		if tm, ok := r.Filter.(TermMatcher); ok {
+			terms = tm.MatchedTerms(message)
+		}
+
+		switch action {
+		case "ignore":
+			return Decision{Process: false, Action: "ignored", FilterID: r.ID, FilterName: r.Name, Terms: terms}, matched
+		case "highlight":
+			return Decision{Process: true, Action: "highlight", FilterID: r.ID, FilterName: r.Name, Terms: terms}, matched
+		default:
+			if decision.FilterID == 0 {
+				decision.FilterID = r.ID
+				decision.FilterName = r.Name
+				decision.Terms = terms
+			}
 		}
 	}
-	return true, "forward"
+	return decision, matched
 }
 
 // KeywordFilter filters messages based on keywords
 type KeywordFilter struct {
 	Keywords []string
 	Action   string
+
+	// Normalize folds homoglyphs, leetspeak, diacritics and zero-width
+	// characters in both the message and the keywords before matching
+	Normalize bool
+	// WholeWord only matches keywords on word boundaries
+	WholeWord bool
+	// MaxDistance is the number of character edits tolerated per keyword
+	MaxDistance int
+}
+
+// KeywordOptions selects the matching mode of a keyword filter
+type KeywordOptions struct {
+	Normalize   bool
+	WholeWord   bool
+	MaxDistance int
 }
 
 func (f *KeywordFilter) ShouldProcess(message string, channelID int64, userID int64) (bool, string) {
+	if f.Normalize || f.WholeWord || f.MaxDistance > 0 {
+		return f.matchNormalized(message)
+	}
+
 	messageLower := strings.ToLower(message)
 	for _, keyword := range f.Keywords {
 		if strings.Contains(messageLower, strings.ToLower(strings.TrimSpace(keyword))) {
@@ -102,6 +310,128 @@ func (f *KeywordFilter) ShouldProcess(message string, channelID int64, userID in
 	return false, ""
 }
 
+func (f *KeywordFilter) matchNormalized(message string) (bool, string) {
+	prepare := strings.ToLower
+	if f.Normalize {
+		prepare = Normalize
+	}
+
+	text := prepare(message)
+	var textTokens []string
+	if f.WholeWord {
+		textTokens = tokenize(text)
+	}
+
+	for _, keyword := range f.Keywords {
+		kw := prepare(strings.TrimSpace(keyword))
+		if kw == "" {
+			continue
+		}
+
+		if f.WholeWord {
+			if containsWords(textTokens, tokenize(kw), f.MaxDistance) {
+				return true, f.Action
+			}
+			continue
+		}
+
+		// Spaces are ignored on both sides to defeat s p a c e d out words
+		if fuzzyContains(squash(text), squash(kw), f.MaxDistance) {
+			return true, f.Action
+		}
+	}
+	return false, ""
+}
+
+// KeywordListFilter matches large keyword watchlists in a single pass
+type KeywordListFilter struct {
+	Matcher   *Matcher
+	Normalize bool
+	Action    string
+}
+
+// NewKeywordListFilter compiles the terms into an Aho-Corasick automaton
+func NewKeywordListFilter(terms []string, normalize bool, action string) *KeywordListFilter {
+	prepare := strings.ToLower
+	if normalize {
+		prepare = Normalize
+	}
+
+	prepared := make([]string, 0, len(terms))
+	for _, term := range terms {
+		if t := prepare(strings.TrimSpace(term)); t != "" {
+			prepared = append(prepared, t)
+		}
+	}
+
+	return &KeywordListFilter{
+		Matcher:   NewMatcher(prepared),
+		Normalize: normalize,
+		Action:    action,
+	}
+}
+
+func (f *KeywordListFilter) ShouldProcess(message string, channelID int64, userID int64) (bool, string) {
+	if len(f.MatchedTerms(message)) > 0 {
+		return true, f.Action
+	}
+	return false, ""
+}
+
+// MatchedTerms returns the watchlist terms found in the message
+func (f *KeywordListFilter) MatchedTerms(message string) []string {
+	if f.Normalize {
+		return f.Matcher.FindAll(Normalize(message))
+	}
+	return f.Matcher.FindAll(strings.ToLower(message))
+}
+
+// IndicatorFilter filters messages containing indicators of the given types,
+// such as crypto addresses or phone numbers
+type IndicatorFilter struct {
+	Types  map[string]bool
+	Action string
+}
+
+// NewIndicatorFilter creates a filter for the given indicator types. The
+// aliases "any" and "crypto" expand to all types and all crypto address types.
+func NewIndicatorFilter(types []string, action string) *IndicatorFilter {
+	f := &IndicatorFilter{Types: map[string]bool{}, Action: action}
+	for _, t := range types {
+		switch t = strings.ToLower(strings.TrimSpace(t)); t {
+		case "any":
+			for _, typ := range indicators.Types {
+				f.Types[typ] = true
+			}
+		case "crypto":
+			for _, typ := range indicators.CryptoTypes {
+				f.Types[typ] = true
+			}
+		default:
+			f.Types[t] = true
+		}
+	}
+	return f
+}
+
+func (f *IndicatorFilter) ShouldProcess(message string, channelID int64, userID int64) (bool, string) {
+	if len(f.MatchedTerms(message)) > 0 {
+		return true, f.Action
+	}
+	return false, ""
+}
+
+// MatchedTerms returns the values of matching indicators in the message
+func (f *IndicatorFilter) MatchedTerms(message string) []string {
+	var values []string
+	for _, ind := range indicators.Extract(message) {
+		if f.Types[ind.Type] {
+			values = append(values, ind.Value)
+		}
+	}
+	return values
+}
+
 // RegexFilter filters messages based on regex patterns
 type RegexFilter struct {
 	Pattern *regexp.Regexp
@@ -183,13 +513,70 @@ func parseMinLength(pattern string) int {
 // Helper functions for managing filters
 
 // AddKeywordFilter adds a keyword filter to the database
-func AddKeywordFilter(db *database.DB, name string, keywords []string, action string, priority int) error {
+func AddKeywordFilter(db database.Store, name string, keywords []string, opts KeywordOptions, action string, priority int) error {
 	pattern := strings.Join(keywords, ",")
-	return db.AddMessageFilter(name, pattern, "keyword", action, priority)
+
+	options := map[string]string{}
+	if opts.Normalize {
+		options["normalize"] = "true"
+	}
+	if opts.WholeWord {
+		options["word"] = "true"
+	}
+	if opts.MaxDistance > 0 {
+		options["max_distance"] = fmt.Sprintf("%d", opts.MaxDistance)
+	}
+
+	_, err := db.AddMessageFilterWithOptions(name, pattern, "keyword", action, priority, options)
+	return err
+}
+
+// AddKeywordListFilter adds a keyword list filter to the database. The source
+// is either "file:<path>" or "table"; for table-backed lists the given terms
+// are stored in the filter_keywords table.
+func AddKeywordListFilter(db database.Store, name string, source string, terms []string, normalize bool, action string, priority int) (int64, error) {
+	if path, ok := strings.CutPrefix(source, "file:"); ok {
+		// Validate the file now rather than at monitor load time
+		if _, err := ReadKeywordFile(path); err != nil {
+			return 0, err
+		}
+	} else if source != "table" {
+		return 0, fmt.Errorf("invalid keyword list source %q (expected file:<path> or table)", source)
+	}
+
+	options := map[string]string{}
+	if normalize {
+		options["normalize"] = "true"
+	}
+
+	filterID, err := db.AddMessageFilterWithOptions(name, source, "keyword_list", action, priority, options)
+	if err != nil {
+		return 0, err
+	}
+
+	if source == "table" && len(terms) > 0 {
+		if _, err := db.AddFilterKeywords(filterID, terms); err != nil {
+			return filterID, err
+		}
+	}
+	return filterID, nil
+}
+
+// AddIndicatorFilter adds an indicator type filter to the database
+func AddIndicatorFilter(db database.Store, name string, types []string, action string, priority int) error {
+	var cleaned []string
+	for _, t := range types {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t != "any" && t != "crypto" && !indicators.IsValidType(t) {
+			return fmt.Errorf("invalid indicator type %q (valid: any, crypto, %s)", t, strings.Join(indicators.Types, ", "))
+		}
+		cleaned = append(cleaned, t)
+	}
+	return db.AddMessageFilter(name, strings.Join(cleaned, ","), "indicator", action, priority)
 }
 
 // AddRegexFilter adds a regex filter to the database
-func AddRegexFilter(db *database.DB, name string, pattern string, action string, priority int) error {
+func AddRegexFilter(db database.Store, name string, pattern string, action string, priority int) error {
 	// Validate regex first
 	if _, err := regexp.Compile(pattern); err != nil {
 		return fmt.Errorf("invalid regex pattern: %w", err)
@@ -198,7 +585,7 @@ func AddRegexFilter(db *database.DB, name string, pattern string, action string,
 }
 
 // AddUserFilter adds a user filter to the database
-func AddUserFilter(db *database.DB, name string, userIDs []int64, action string, priority int) error {
+func AddUserFilter(db database.Store, name string, userIDs []int64, action string, priority int) error {
 	ids := make([]string, len(userIDs))
 	for i, id := range userIDs {
 		ids[i] = fmt.Sprintf("%d", id)
@@ -208,7 +595,7 @@ func AddUserFilter(db *database.DB, name string, userIDs []int64, action string,
 }
 
 // AddChannelFilter adds a channel filter to the database
-func AddChannelFilter(db *database.DB, name string, channelIDs []int64, action string, priority int) error {
+func AddChannelFilter(db database.Store, name string, channelIDs []int64, action string, priority int) error {
 	ids := make([]string, len(channelIDs))
 	for i, id := range channelIDs {
 		ids[i] = fmt.Sprintf("%d", id)
@@ -218,7 +605,7 @@ func AddChannelFilter(db *database.DB, name string, channelIDs []int64, action s
 }
 
 // AddLengthFilter adds a length filter to the database
-func AddLengthFilter(db *database.DB, name string, minLength int, action string, priority int) error {
+func AddLengthFilter(db database.Store, name string, minLength int, action string, priority int) error {
 	pattern := fmt.Sprintf("%d", minLength)
 	return db.AddMessageFilter(name, pattern, "length", action, priority)
 }
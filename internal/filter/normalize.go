@@ -0,0 +1,155 @@
+package filter
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// confusables folds look-alike characters and common leetspeak substitutions
+// onto the Latin letter they are used to imitate
+var confusables = map[rune]rune{
+	// Cyrillic
+	'а': 'a', 'в': 'b', 'е': 'e', 'ё': 'e', 'к': 'k', 'м': 'm', 'н': 'h',
+	'о': 'o', 'р': 'p', 'с': 'c', 'т': 't', 'у': 'y', 'х': 'x', 'ѕ': 's',
+	'і': 'i', 'ї': 'i', 'ј': 'j', 'ԁ': 'd', 'ԛ': 'q', 'ԝ': 'w', 'ь': 'b',
+	'һ': 'h', 'ӏ': 'l',
+	// Greek
+	'α': 'a', 'β': 'b', 'ε': 'e', 'η': 'n', 'ι': 'i', 'κ': 'k', 'ν': 'v',
+	'ο': 'o', 'ρ': 'p', 'τ': 't', 'υ': 'u', 'χ': 'x', 'ω': 'w',
+	// Leetspeak
+	'0': 'o', '1': 'i', '3': 'e', '4': 'a', '5': 's', '7': 't', '8': 'b',
+	'@': 'a', '$': 's', '!': 'i', '|': 'l',
+}
+
+// Normalize folds text into a canonical form for obfuscation-resistant
+// matching: NFKC compatibility folding, lowercasing, diacritic removal,
+// confusable and leetspeak folding, zero-width character removal and
+// whitespace collapsing.
+func Normalize(text string) string {
+	// NFKC folds fullwidth forms, ligatures and styled math letters
+	text = strings.ToLower(norm.NFKC.String(text))
+
+	// Decompose so diacritics become separate combining marks we can drop
+	text = norm.NFD.String(text)
+
+	var b strings.Builder
+	b.Grow(len(text))
+	lastSpace := true
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			// Combining marks (diacritics)
+			continue
+		case unicode.Is(unicode.Cf, r):
+			// Format characters: zero-width spaces and joiners, BOM, soft hyphen
+			continue
+		case unicode.IsSpace(r):
+			if !lastSpace {
+				b.WriteByte(' ')
+				lastSpace = true
+			}
+			continue
+		}
+
+		if folded, ok := confusables[r]; ok {
+			r = folded
+		}
+		b.WriteRune(r)
+		lastSpace = false
+	}
+
+	return strings.TrimSpace(b.String())
+}
+
+// squash removes all spaces so s p a c e d out words still match
+func squash(text string) string {
+	return strings.ReplaceAll(text, " ", "")
+}
+
+// tokenize splits normalized text into words on anything that is not a
+// letter or digit
+func tokenize(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// containsWords reports whether the keyword tokens appear as a run of whole
+// words in the text tokens, allowing up to maxDistance edits
+func containsWords(textTokens, keywordTokens []string, maxDistance int) bool {
+	if len(keywordTokens) == 0 || len(keywordTokens) > len(textTokens) {
+		return false
+	}
+
+	keyword := strings.Join(keywordTokens, " ")
+	for i := 0; i+len(keywordTokens) <= len(textTokens); i++ {
+		window := strings.Join(textTokens[i:i+len(keywordTokens)], " ")
+		if window == keyword {
+			return true
+		}
+		if maxDistance > 0 && levenshtein([]rune(window), []rune(keyword)) <= maxDistance {
+			return true
+		}
+	}
+	return false
+}
+
+// fuzzyContains reports whether pattern occurs anywhere in text with at most
+// maxDistance edits, using Sellers' approximate substring matching
+func fuzzyContains(text, pattern string, maxDistance int) bool {
+	t := []rune(text)
+	p := []rune(pattern)
+	if len(p) == 0 {
+		return false
+	}
+	if len(p) <= maxDistance {
+		return true
+	}
+
+	// prev[i] is the best edit distance of p[:i] ending at the current text position
+	prev := make([]int, len(p)+1)
+	curr := make([]int, len(p)+1)
+	for i := range prev {
+		prev[i] = i
+	}
+
+	for j := 1; j <= len(t); j++ {
+		curr[0] = 0 // a match may start anywhere in the text
+		for i := 1; i <= len(p); i++ {
+			cost := 1
+			if p[i-1] == t[j-1] {
+				cost = 0
+			}
+			curr[i] = min(prev[i-1]+cost, prev[i]+1, curr[i-1]+1)
+		}
+		if curr[len(p)] <= maxDistance {
+			return true
+		}
+		prev, curr = curr, prev
+	}
+	return false
+}
+
+// levenshtein returns the edit distance between two rune slices
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j-1]+cost, prev[j]+1, curr[j-1]+1)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,262 @@
+// Package graph builds the network of users and channels found by searches,
+// for tools like Gephi and Maltego
+package graph
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gnomegl/teleslurp/internal/types"
+)
+
+// Node types
+const (
+	NodeUser     = "user"
+	NodeChannel  = "channel"
+	NodeUsername = "username"
+	NodeID       = "telegram_id"
+)
+
+// Edge types
+const (
+	// EdgeMember links a user to a group TGScan lists them in
+	EdgeMember = "member"
+	// EdgePosted links a user to a channel they posted in, weighted by the
+	// number of messages found
+	EdgePosted = "posted"
+	// EdgeAdmin links an admin to their channel
+	EdgeAdmin = "admin"
+	// EdgeHadUsername links a user to a username they used before
+	EdgeHadUsername = "had_username"
+	// EdgeHadID links a user to a Telegram ID TGScan saw them with before
+	EdgeHadID = "had_id"
+)
+
+// Node is a user, channel, former username or former ID
+type Node struct {
+	ID          string
+	Type        string
+	Label       string
+	TelegramID  int64
+	Username    string
+	MemberCount int
+	Link        string
+}
+
+// Edge is a directed relationship from a user to a channel or username
+type Edge struct {
+	ID     string
+	Source string
+	Target string
+	Type   string
+	Weight float64
+	// Date is when the relationship was observed, if known
+	Date string
+}
+
+// Graph collects nodes and edges, merging the same user or channel seen
+// several times by ID or username
+type Graph struct {
+	Nodes []*Node
+	Edges []*Edge
+
+	nodes   map[string]*Node
+	edges   map[string]*Edge
+	aliases map[string]string // type:@username → node ID
+}
+
+// New returns an empty graph
+func New() *Graph {
+	return &Graph{
+		nodes:   make(map[string]*Node),
+		edges:   make(map[string]*Edge),
+		aliases: make(map[string]string),
+	}
+}
+
+// AddUser adds a user, or updates the known one, and returns its node ID.
+// Either id or name must be set; name is a username or, for admins without
+// one, a display name.
+func (g *Graph) AddUser(id int64, name, firstName, lastName string) string {
+	name = strings.TrimPrefix(name, "@")
+	username := name
+	if strings.Contains(name, " ") {
+		username = ""
+	}
+
+	label := strings.TrimSpace(firstName + " " + lastName)
+	switch {
+	case username != "" && label != "":
+		label = fmt.Sprintf("%s (@%s)", label, username)
+	case username != "":
+		label = "@" + username
+	case label == "":
+		label = name
+	}
+	if label == "" {
+		label = fmt.Sprintf("ID: %d", id)
+	}
+
+	node := g.node(NodeUser, id, name, label)
+	node.Username = firstNonEmpty(node.Username, username)
+	if username != "" {
+		node.Link = "https://t.me/" + username
+	}
+	return node.ID
+}
+
+// AddChannel adds a channel, or updates the known one, and returns its node ID
+func (g *Graph) AddChannel(id int64, username, title string, memberCount int) string {
+	username = strings.TrimPrefix(username, "@")
+	label := firstNonEmpty(title, "@"+username)
+
+	node := g.node(NodeChannel, id, username, label)
+	node.Username = firstNonEmpty(node.Username, username)
+	if memberCount > node.MemberCount {
+		node.MemberCount = memberCount
+	}
+	switch {
+	case node.Username != "":
+		node.Link = "https://t.me/" + node.Username
+	case node.TelegramID != 0:
+		node.Link = fmt.Sprintf("https://t.me/c/%d", node.TelegramID)
+	}
+	return node.ID
+}
+
+// node finds a node by ID or alias, creating it if needed. A node first
+// seen by name gets its ID filled in when it shows up with one.
+func (g *Graph) node(typ string, id int64, name, label string) *Node {
+	alias := ""
+	if name != "" {
+		alias = typ + ":@" + strings.ToLower(name)
+	}
+	key := ""
+	if id != 0 {
+		key = typ + ":" + strconv.FormatInt(id, 10)
+	}
+
+	var node *Node
+	if key != "" {
+		node = g.nodes[key]
+	}
+	if node == nil && alias != "" {
+		node = g.nodes[g.aliases[alias]]
+	}
+	if node == nil {
+		nodeID := firstNonEmpty(key, alias)
+		node = &Node{ID: nodeID, Type: typ, Label: label}
+		g.nodes[nodeID] = node
+		g.Nodes = append(g.Nodes, node)
+	}
+
+	if id != 0 && node.TelegramID == 0 {
+		node.TelegramID = id
+		if key != "" {
+			g.nodes[key] = node
+		}
+	}
+	if alias != "" {
+		g.aliases[alias] = node.ID
+	}
+	if label != "" && (node.Label == "" || strings.HasPrefix(node.Label, "@") || strings.HasPrefix(node.Label, "ID: ")) {
+		node.Label = label
+	}
+	return node
+}
+
+// AddEdge adds a relationship. Seeing the same relationship again keeps the
+// higher weight and the latest date, so repeated runs don't inflate it.
+func (g *Graph) AddEdge(source, target, typ string, weight float64, date string) {
+	id := typ + ":" + source + "->" + target
+	if e, ok := g.edges[id]; ok {
+		if weight > e.Weight {
+			e.Weight = weight
+		}
+		if date > e.Date {
+			e.Date = date
+		}
+		return
+	}
+
+	e := &Edge{ID: id, Source: source, Target: target, Type: typ, Weight: weight, Date: date}
+	g.edges[id] = e
+	g.Edges = append(g.Edges, e)
+}
+
+// AddUsernameHistory links a user to the usernames they had before
+func (g *Graph) AddUsernameHistory(user string, history []types.UsernameHistory) {
+	for _, h := range history {
+		username := strings.TrimPrefix(h.Username, "@")
+		if username == "" {
+			continue
+		}
+		id := NodeUsername + ":@" + strings.ToLower(username)
+		if _, ok := g.nodes[id]; !ok {
+			node := &Node{ID: id, Type: NodeUsername, Label: "@" + username, Username: username}
+			g.nodes[id] = node
+			g.Nodes = append(g.Nodes, node)
+		}
+		g.AddEdge(user, id, EdgeHadUsername, 1, h.Date)
+	}
+}
+
+// AddIDHistory links a user to the Telegram IDs they had before
+func (g *Graph) AddIDHistory(user string, history []types.IDHistory) {
+	for _, h := range history {
+		if h.ID == 0 {
+			continue
+		}
+		id := NodeID + ":" + strconv.FormatInt(h.ID, 10)
+		if _, ok := g.nodes[id]; !ok {
+			node := &Node{ID: id, Type: NodeID, Label: fmt.Sprintf("ID: %d", h.ID), TelegramID: h.ID}
+			g.nodes[id] = node
+			g.Nodes = append(g.Nodes, node)
+		}
+		g.AddEdge(user, id, EdgeHadID, 1, h.Date)
+	}
+}
+
+// AddGroups links a user to the groups TGScan lists them in
+func (g *Graph) AddGroups(user string, groups []types.Group) {
+	for _, group := range groups {
+		if group.ID == 0 && group.Username == "" {
+			continue
+		}
+		channel := g.AddChannel(group.ID, group.Username, group.Title, 0)
+		g.AddEdge(user, channel, EdgeMember, 1, group.DateUpdated)
+	}
+}
+
+// AddAdmins links the admins of a channel, given as the comma separated list
+// searches record, to the channel
+func (g *Graph) AddAdmins(channel, admins string) {
+	for _, admin := range strings.Split(admins, ",") {
+		admin = strings.TrimSpace(admin)
+		if admin == "" {
+			continue
+		}
+		g.AddEdge(g.AddUser(0, admin, "", ""), channel, EdgeAdmin, 1, "")
+	}
+}
+
+// AddProfile adds a TGScan profile: the user, their username and ID history
+// and their groups. It returns the user's node ID.
+func (g *Graph) AddProfile(profile *types.TGScanResponse) string {
+	user := profile.Result.User
+	id := g.AddUser(user.ID, user.Username, user.FirstName, user.LastName)
+	g.AddUsernameHistory(id, profile.Result.UsernameHistory)
+	g.AddIDHistory(id, profile.Result.IDHistory)
+	g.AddGroups(id, profile.Result.Groups)
+	return id
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" && v != "@" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,342 @@
+package graph
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Output formats
+const (
+	FormatGEXF    = "gexf"
+	FormatGraphML = "graphml"
+	FormatMaltego = "maltego"
+)
+
+// Extension returns the file extension of a format
+func Extension(format string) string {
+	if format == FormatMaltego {
+		return "csv"
+	}
+	return format
+}
+
+// Write renders the graph in the given format
+func Write(w io.Writer, g *Graph, format string) error {
+	switch format {
+	case FormatGEXF:
+		return WriteGEXF(w, g)
+	case FormatGraphML:
+		return WriteGraphML(w, g)
+	case FormatMaltego:
+		return WriteMaltego(w, g)
+	default:
+		return fmt.Errorf("unsupported graph format: %s", format)
+	}
+}
+
+// Node and edge attributes, in the order they are declared
+var (
+	nodeAttributes = []attribute{
+		{"type", "string"},
+		{"telegram_id", "long"},
+		{"username", "string"},
+		{"member_count", "integer"},
+		{"link", "string"},
+	}
+	edgeAttributes = []attribute{
+		{"type", "string"},
+		{"date", "string"},
+	}
+)
+
+type attribute struct {
+	Name, Type string
+}
+
+func nodeValues(n *Node) []string {
+	return []string{n.Type, formatID(n.TelegramID), n.Username, strconv.Itoa(n.MemberCount), n.Link}
+}
+
+func edgeValues(e *Edge) []string {
+	return []string{e.Type, e.Date}
+}
+
+func formatID(id int64) string {
+	if id == 0 {
+		return ""
+	}
+	return strconv.FormatInt(id, 10)
+}
+
+func formatWeight(w float64) string {
+	return strconv.FormatFloat(w, 'f', -1, 64)
+}
+
+// GEXF 1.3, https://gexf.net/schema.html
+type gexfDocument struct {
+	XMLName xml.Name  `xml:"gexf"`
+	XMLNS   string    `xml:"xmlns,attr"`
+	Version string    `xml:"version,attr"`
+	Meta    gexfMeta  `xml:"meta"`
+	Graph   gexfGraph `xml:"graph"`
+}
+
+type gexfMeta struct {
+	LastModified string `xml:"lastmodifieddate,attr"`
+	Creator      string `xml:"creator"`
+	Description  string `xml:"description"`
+}
+
+type gexfGraph struct {
+	Mode       string           `xml:"mode,attr"`
+	EdgeType   string           `xml:"defaultedgetype,attr"`
+	Attributes []gexfAttributes `xml:"attributes"`
+	Nodes      []gexfNode       `xml:"nodes>node"`
+	Edges      []gexfEdge       `xml:"edges>edge"`
+}
+
+type gexfAttributes struct {
+	Class      string          `xml:"class,attr"`
+	Attributes []gexfAttribute `xml:"attribute"`
+}
+
+type gexfAttribute struct {
+	ID    string `xml:"id,attr"`
+	Title string `xml:"title,attr"`
+	Type  string `xml:"type,attr"`
+}
+
+type gexfNode struct {
+	ID     string      `xml:"id,attr"`
+	Label  string      `xml:"label,attr"`
+	Values []gexfValue `xml:"attvalues>attvalue"`
+}
+
+type gexfEdge struct {
+	ID     string      `xml:"id,attr"`
+	Source string      `xml:"source,attr"`
+	Target string      `xml:"target,attr"`
+	Label  string      `xml:"label,attr"`
+	Weight string      `xml:"weight,attr"`
+	Values []gexfValue `xml:"attvalues>attvalue"`
+}
+
+type gexfValue struct {
+	For   string `xml:"for,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// WriteGEXF renders the graph as GEXF for Gephi
+func WriteGEXF(w io.Writer, g *Graph) error {
+	doc := gexfDocument{
+		XMLNS:   "http://gexf.net/1.3",
+		Version: "1.3",
+		Meta: gexfMeta{
+			LastModified: time.Now().Format("2006-01-02"),
+			Creator:      "teleslurp",
+			Description:  "Telegram users and channels",
+		},
+		Graph: gexfGraph{
+			Mode:     "static",
+			EdgeType: "directed",
+			Attributes: []gexfAttributes{
+				{Class: "node", Attributes: gexfAttributeList("n", nodeAttributes)},
+				{Class: "edge", Attributes: gexfAttributeList("e", edgeAttributes)},
+			},
+		},
+	}
+
+	for _, n := range g.Nodes {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, gexfNode{ID: n.ID, Label: n.Label, Values: gexfValues("n", nodeValues(n))})
+	}
+	for _, e := range g.Edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, gexfEdge{
+			ID:     e.ID,
+			Source: e.Source,
+			Target: e.Target,
+			Label:  e.Type,
+			Weight: formatWeight(e.Weight),
+			Values: gexfValues("e", edgeValues(e)),
+		})
+	}
+	return writeXML(w, doc)
+}
+
+func gexfAttributeList(prefix string, attrs []attribute) []gexfAttribute {
+	list := make([]gexfAttribute, len(attrs))
+	for i, a := range attrs {
+		list[i] = gexfAttribute{ID: fmt.Sprintf("%s%d", prefix, i), Title: a.Name, Type: a.Type}
+	}
+	return list
+}
+
+// gexfValues skips empty values, which Gephi would fail to parse as numbers
+func gexfValues(prefix string, values []string) []gexfValue {
+	var list []gexfValue
+	for i, v := range values {
+		if v != "" {
+			list = append(list, gexfValue{For: fmt.Sprintf("%s%d", prefix, i), Value: v})
+		}
+	}
+	return list
+}
+
+// GraphML, http://graphml.graphdrawing.org/
+type graphMLDocument struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID   string `xml:"id,attr"`
+	For  string `xml:"for,attr"`
+	Name string `xml:"attr.name,attr"`
+	Type string `xml:"attr.type,attr"`
+}
+
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	ID     string        `xml:"id,attr"`
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// WriteGraphML renders the graph as GraphML for Gephi, yEd and networkx
+func WriteGraphML(w io.Writer, g *Graph) error {
+	doc := graphMLDocument{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []graphMLKey{
+			{ID: "label", For: "node", Name: "label", Type: "string"},
+			{ID: "weight", For: "edge", Name: "weight", Type: "double"},
+		},
+		Graph: graphMLGraph{ID: "teleslurp", EdgeDefault: "directed"},
+	}
+	for _, a := range nodeAttributes {
+		doc.Keys = append(doc.Keys, graphMLKey{ID: "n_" + a.Name, For: "node", Name: a.Name, Type: graphMLType(a.Type)})
+	}
+	for _, a := range edgeAttributes {
+		doc.Keys = append(doc.Keys, graphMLKey{ID: "e_" + a.Name, For: "edge", Name: a.Name, Type: graphMLType(a.Type)})
+	}
+
+	for _, n := range g.Nodes {
+		data := []graphMLData{{Key: "label", Value: n.Label}}
+		data = append(data, graphMLValues("n_", nodeAttributes, nodeValues(n))...)
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphMLNode{ID: n.ID, Data: data})
+	}
+	for _, e := range g.Edges {
+		data := []graphMLData{{Key: "weight", Value: formatWeight(e.Weight)}}
+		data = append(data, graphMLValues("e_", edgeAttributes, edgeValues(e))...)
+		doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{ID: e.ID, Source: e.Source, Target: e.Target, Data: data})
+	}
+	return writeXML(w, doc)
+}
+
+func graphMLType(t string) string {
+	if t == "integer" {
+		return "int"
+	}
+	return t
+}
+
+func graphMLValues(prefix string, attrs []attribute, values []string) []graphMLData {
+	var data []graphMLData
+	for i, v := range values {
+		if v != "" {
+			data = append(data, graphMLData{Key: prefix + attrs[i].Name, Value: v})
+		}
+	}
+	return data
+}
+
+func writeXML(w io.Writer, doc interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("error encoding graph: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// Maltego entity types of the node types
+var maltegoEntities = map[string]string{
+	NodeUser:     "maltego.Alias",
+	NodeChannel:  "maltego.OnlineGroup",
+	NodeUsername: "maltego.Alias",
+	NodeID:       "maltego.UniqueIdentifier",
+}
+
+var maltegoHeaders = []string{
+	"Source Entity", "Source Value", "Source Label", "Source Telegram ID",
+	"Link", "Weight", "Date",
+	"Target Entity", "Target Value", "Target Label", "Target Telegram ID", "Target URL",
+}
+
+// WriteMaltego renders the graph as a CSV of links for Maltego's Import
+// Graph from Table, one row per edge with both entities
+func WriteMaltego(w io.Writer, g *Graph) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(maltegoHeaders); err != nil {
+		return fmt.Errorf("error writing Maltego CSV: %w", err)
+	}
+
+	for _, e := range g.Edges {
+		source, target := g.nodes[e.Source], g.nodes[e.Target]
+		if source == nil || target == nil {
+			continue
+		}
+		record := []string{
+			maltegoEntities[source.Type], maltegoValue(source), source.Label, formatID(source.TelegramID),
+			e.Type, formatWeight(e.Weight), e.Date,
+			maltegoEntities[target.Type], maltegoValue(target), target.Label, formatID(target.TelegramID), target.Link,
+		}
+		if err := writer.Write(record); err != nil {
+			return fmt.Errorf("error writing Maltego CSV: %w", err)
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("error writing Maltego CSV: %w", err)
+	}
+	return nil
+}
+
+// maltegoValue is the entity value: the username if known, so entities
+// merge with ones from other transforms, otherwise the label. Former IDs
+// are the bare ID.
+func maltegoValue(n *Node) string {
+	if n.Type == NodeID {
+		return formatID(n.TelegramID)
+	}
+	if n.Username != "" {
+		return n.Username
+	}
+	return n.Label
+}
@@ -0,0 +1,223 @@
+package indicators
+
+import (
+	"crypto/sha256"
+	"math/big"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Indicator types
+const (
+	TypeURL          = "url"
+	TypeTelegramLink = "telegram_link"
+	TypeMention      = "mention"
+	TypeEmail        = "email"
+	TypePhone        = "phone"
+	TypeBTC          = "btc"
+	TypeETH          = "eth"
+	TypeLTC          = "ltc"
+	TypeTRX          = "trx"
+	TypeXMR          = "xmr"
+)
+
+// Types lists every indicator type the extractor produces
+var Types = []string{
+	TypeURL, TypeTelegramLink, TypeMention, TypeEmail, TypePhone,
+	TypeBTC, TypeETH, TypeLTC, TypeTRX, TypeXMR,
+}
+
+// CryptoTypes lists the cryptocurrency address types
+var CryptoTypes = []string{TypeBTC, TypeETH, TypeLTC, TypeTRX, TypeXMR}
+
+// Indicator is a typed value extracted from message text
+type Indicator struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+var (
+	urlRegex      = regexp.MustCompile(`(?i)\b(?:https?://|(?:t|telegram)\.me/)[^\s<>"'` + "`" + `]+`)
+	emailRegex    = regexp.MustCompile(`\b[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}\b`)
+	mentionRegex  = regexp.MustCompile(`(?:^|[^A-Za-z0-9_@./])@([A-Za-z][A-Za-z0-9_]{3,31})\b`)
+	phoneRegex    = regexp.MustCompile(`\+\d[\d\s().-]{6,18}\d`)
+	btcRegex      = regexp.MustCompile(`\b(?:[13][1-9A-HJ-NP-Za-km-z]{25,34}|bc1[02-9ac-hj-np-z]{25,62})\b`)
+	ethRegex      = regexp.MustCompile(`\b0x[0-9a-fA-F]{40}\b`)
+	ltcRegex      = regexp.MustCompile(`\b(?:[LM][1-9A-HJ-NP-Za-km-z]{26,33}|ltc1[02-9ac-hj-np-z]{25,62})\b`)
+	trxRegex      = regexp.MustCompile(`\bT[1-9A-HJ-NP-Za-km-z]{33}\b`)
+	xmrRegex      = regexp.MustCompile(`\b[48][1-9A-HJ-NP-Za-km-z]{94}\b`)
+	trailingPunct = ".,;:!?)]}'\""
+)
+
+// Extract returns the distinct indicators found in text
+func Extract(text string) []Indicator {
+	if text == "" {
+		return nil
+	}
+
+	var found []Indicator
+	seen := make(map[Indicator]bool)
+	add := func(typ, value string) {
+		ind := Indicator{Type: typ, Value: value}
+		if value == "" || seen[ind] {
+			return
+		}
+		seen[ind] = true
+		found = append(found, ind)
+	}
+
+	for _, match := range urlRegex.FindAllString(text, -1) {
+		match = strings.TrimRight(match, trailingPunct)
+		if isTelegramLink(match) {
+			add(TypeTelegramLink, normalizeTelegramLink(match))
+		} else {
+			add(TypeURL, match)
+		}
+	}
+
+	for _, match := range mentionRegex.FindAllStringSubmatch(text, -1) {
+		add(TypeMention, "@"+strings.ToLower(match[1]))
+	}
+
+	for _, match := range emailRegex.FindAllString(text, -1) {
+		add(TypeEmail, strings.ToLower(match))
+	}
+
+	for _, match := range phoneRegex.FindAllString(text, -1) {
+		add(TypePhone, normalizePhone(match))
+	}
+
+	for _, match := range btcRegex.FindAllString(text, -1) {
+		if strings.HasPrefix(match, "bc1") || validBase58Check(match) {
+			add(TypeBTC, match)
+		}
+	}
+
+	for _, match := range ethRegex.FindAllString(text, -1) {
+		add(TypeETH, strings.ToLower(match))
+	}
+
+	for _, match := range ltcRegex.FindAllString(text, -1) {
+		if strings.HasPrefix(match, "ltc1") || validBase58Check(match) {
+			add(TypeLTC, match)
+		}
+	}
+
+	for _, match := range trxRegex.FindAllString(text, -1) {
+		if validBase58Check(match) {
+			add(TypeTRX, match)
+		}
+	}
+
+	for _, match := range xmrRegex.FindAllString(text, -1) {
+		add(TypeXMR, match)
+	}
+
+	return found
+}
+
+// IsValidType reports whether typ is a known indicator type
+func IsValidType(typ string) bool {
+	for _, t := range Types {
+		if t == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// Format renders indicators as "type:value" pairs separated by "; " for
+// flat formats such as CSV
+func Format(inds []Indicator) string {
+	parts := make([]string, len(inds))
+	for i, ind := range inds {
+		parts[i] = ind.Type + ":" + ind.Value
+	}
+	return strings.Join(parts, "; ")
+}
+
+func isTelegramLink(link string) bool {
+	lower := strings.ToLower(link)
+	if strings.HasPrefix(lower, "t.me/") || strings.HasPrefix(lower, "telegram.me/") {
+		return true
+	}
+
+	u, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	return host == "t.me" || host == "telegram.me"
+}
+
+// normalizeTelegramLink rewrites t.me links to a canonical https://t.me/ form
+func normalizeTelegramLink(link string) string {
+	lower := strings.ToLower(link)
+	for _, prefix := range []string{"https://", "http://"} {
+		if strings.HasPrefix(lower, prefix) {
+			link = link[len(prefix):]
+			lower = lower[len(prefix):]
+		}
+	}
+	link = link[strings.Index(lower, ".me/")+len(".me/"):]
+	return "https://t.me/" + link
+}
+
+// normalizePhone keeps the leading + and digits only
+func normalizePhone(phone string) string {
+	var b strings.Builder
+	b.WriteByte('+')
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+
+	// E.164 numbers have at most 15 digits
+	digits := b.Len() - 1
+	if digits < 8 || digits > 15 {
+		return ""
+	}
+	return b.String()
+}
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// validBase58Check verifies the double-SHA256 checksum of a Base58Check
+// encoded address, which weeds out random words matching the address shape
+func validBase58Check(address string) bool {
+	n := new(big.Int)
+	radix := big.NewInt(58)
+	for _, r := range address {
+		idx := strings.IndexRune(base58Alphabet, r)
+		if idx < 0 {
+			return false
+		}
+		n.Mul(n, radix)
+		n.Add(n, big.NewInt(int64(idx)))
+	}
+
+	decoded := n.Bytes()
+	// Leading '1's encode leading zero bytes
+	for _, r := range address {
+		if r != '1' {
+			break
+		}
+		decoded = append([]byte{0}, decoded...)
+	}
+
+	if len(decoded) < 5 {
+		return false
+	}
+
+	payload, checksum := decoded[:len(decoded)-4], decoded[len(decoded)-4:]
+	first := sha256.Sum256(payload)
+	second := sha256.Sum256(first[:])
+	for i := 0; i < 4; i++ {
+		if second[i] != checksum[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,261 @@
+// Package presence turns the recorded status updates of a user into online
+// sessions, activity patterns and absences.
+package presence
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gnomegl/teleslurp/internal/database"
+	"github.com/gnomegl/teleslurp/internal/timeutil"
+)
+
+// maxAbsences is how many of the longest absences a report lists
+const maxAbsences = 5
+
+// missedUpdateGap is how long after an online status expired the next update
+// may arrive before the monitor is assumed to have missed updates in between
+const missedUpdateGap = 30 * time.Minute
+
+// Seconds is a duration that is written to JSON as whole seconds
+type Seconds time.Duration
+
+// MarshalJSON implements json.Marshaler
+func (s Seconds) MarshalJSON() ([]byte, error) {
+	return strconv.AppendInt(nil, int64(time.Duration(s)/time.Second), 10), nil
+}
+
+// Session is a continuous period the user was online
+type Session struct {
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	Duration Seconds   `json:"duration_seconds"`
+}
+
+// Absence is a gap between two sessions
+type Absence struct {
+	From     time.Time `json:"from"`
+	To       time.Time `json:"to"`
+	Duration Seconds   `json:"duration_seconds"`
+}
+
+// Day summarizes the sessions that started on one calendar day
+type Day struct {
+	Date     string  `json:"date"`
+	Sessions int     `json:"sessions"`
+	Online   Seconds `json:"online_seconds"`
+}
+
+// Heatmap holds the minutes spent online per weekday (Sunday first, as in
+// time.Weekday) and hour of day
+type Heatmap [7][24]int
+
+// Report is the presence analysis of one user
+type Report struct {
+	UserID          int64     `json:"user_id"`
+	Username        string    `json:"username,omitempty"`
+	Name            string    `json:"name,omitempty"`
+	Updates         int       `json:"updates"`
+	FirstUpdate     time.Time `json:"first_update"`
+	LastUpdate      time.Time `json:"last_update"`
+	Sessions        []Session `json:"sessions"`
+	Days            []Day     `json:"days"`
+	SessionsPerDay  float64   `json:"sessions_per_day"`
+	Heatmap         Heatmap   `json:"heatmap"`
+	LongestAbsences []Absence `json:"longest_absences"`
+
+	History   []database.UserStatusUpdate `json:"history"`
+	Sightings []database.UserSighting     `json:"sightings"`
+}
+
+// Analyze builds a report from status updates in chronological order. The
+// stored times are UTC; they are converted to loc, the --timezone display
+// zone, so days and the activity heatmap follow the analyst's clock.
+func Analyze(userID int64, updates []database.UserStatusUpdate, loc *time.Location) *Report {
+	r := &Report{
+		UserID:    userID,
+		Updates:   len(updates),
+		Sessions:  []Session{},
+		Days:      []Day{},
+		History:   updates,
+		Sightings: []database.UserSighting{},
+	}
+	if len(updates) == 0 {
+		return r
+	}
+
+	last := updates[len(updates)-1]
+	r.Username = last.Username
+	r.Name = last.FirstName
+	if last.LastName != "" {
+		if r.Name != "" {
+			r.Name += " "
+		}
+		r.Name += last.LastName
+	}
+	r.FirstUpdate = parseTime(updates[0].StatusTime, loc)
+	r.LastUpdate = parseTime(last.StatusTime, loc)
+
+	r.Sessions = sessions(updates, loc)
+	r.Days = days(r.Sessions, r.FirstUpdate, r.LastUpdate)
+	if len(r.Days) > 0 {
+		r.SessionsPerDay = float64(len(r.Sessions)) / float64(len(r.Days))
+	}
+	r.Heatmap = heatmap(r.Sessions)
+	r.LongestAbsences = longestAbsences(r.Sessions, maxAbsences)
+	return r
+}
+
+// sessions pairs online updates with the following offline update. A session
+// without an offline update ends when its last online status expired, which
+// covers updates the monitor missed while it wasn't running.
+func sessions(updates []database.UserStatusUpdate, loc *time.Location) []Session {
+	result := []Session{}
+	var open bool
+	var start, expires time.Time
+
+	closeAt := func(end time.Time) {
+		if end.Before(start) {
+			end = start
+		}
+		result = append(result, Session{Start: start, End: end, Duration: Seconds(end.Sub(start))})
+		open = false
+	}
+
+	for _, u := range updates {
+		at := parseTime(u.StatusTime, loc)
+		if at.IsZero() {
+			continue
+		}
+
+		switch u.State {
+		case database.StatusOnline:
+			if open && missedUpdates(expires, at) {
+				closeAt(expires)
+			}
+			if !open {
+				open = true
+				start = at
+			}
+			expires = parseTime(u.Expires, loc)
+		case database.StatusOffline:
+			wasOnline := parseTime(u.WasOnline, loc)
+			if wasOnline.After(at) {
+				wasOnline = at
+			}
+			if open && missedUpdates(expires, at) {
+				// Updates were missed; end the session when it expired and
+				// count the last seen time as a separate sighting
+				closeAt(expires)
+			}
+			switch {
+			case open && wasOnline.IsZero():
+				closeAt(at)
+			case open:
+				closeAt(wasOnline)
+			case !wasOnline.IsZero() && (len(result) == 0 || wasOnline.After(result[len(result)-1].End)):
+				// Came online and left between two updates
+				start = wasOnline
+				closeAt(wasOnline)
+			}
+		default:
+			if open {
+				closeAt(at)
+			}
+		}
+	}
+
+	if open {
+		end := expires
+		if end.IsZero() {
+			end = start
+		}
+		closeAt(end)
+	}
+	return result
+}
+
+// missedUpdates reports whether an update at at came so long after the online
+// status expired that updates in between were likely missed
+func missedUpdates(expires, at time.Time) bool {
+	return !expires.IsZero() && at.After(expires.Add(missedUpdateGap))
+}
+
+// days summarizes sessions per calendar day over the whole observed range,
+// including days without any session
+func days(sessions []Session, first, last time.Time) []Day {
+	if first.IsZero() || last.IsZero() {
+		return []Day{}
+	}
+
+	byDate := make(map[string]*Day)
+	var result []Day
+	for d := truncateDay(first); !d.After(last); d = d.AddDate(0, 0, 1) {
+		result = append(result, Day{Date: d.Format("2006-01-02")})
+	}
+	for i := range result {
+		byDate[result[i].Date] = &result[i]
+	}
+
+	for _, s := range sessions {
+		day, ok := byDate[s.Start.Format("2006-01-02")]
+		if !ok {
+			continue
+		}
+		day.Sessions++
+		day.Online += s.Duration
+	}
+	return result
+}
+
+func truncateDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// heatmap spreads each session over the weekday and hour cells it covers.
+// Sessions shorter than a minute still count as one minute, so a user seen
+// only briefly still shows up.
+func heatmap(sessions []Session) Heatmap {
+	var h Heatmap
+	for _, s := range sessions {
+		if time.Duration(s.Duration) < time.Minute {
+			h[s.Start.Weekday()][s.Start.Hour()]++
+			continue
+		}
+		for t := s.Start; t.Before(s.End); t = t.Add(time.Minute) {
+			h[t.Weekday()][t.Hour()]++
+		}
+	}
+	return h
+}
+
+// longestAbsences returns the n longest gaps between sessions, longest first
+func longestAbsences(sessions []Session, n int) []Absence {
+	absences := []Absence{}
+	for i := 1; i < len(sessions); i++ {
+		from, to := sessions[i-1].End, sessions[i].Start
+		if !to.After(from) {
+			continue
+		}
+		absences = append(absences, Absence{From: from, To: to, Duration: Seconds(to.Sub(from))})
+	}
+
+	sort.SliceStable(absences, func(i, j int) bool {
+		return absences[i].Duration > absences[j].Duration
+	})
+	if len(absences) > n {
+		absences = absences[:n]
+	}
+	return absences
+}
+
+// parseTime reads a stored time in the zone the report is made for, or
+// returns the zero time if it is missing
+func parseTime(value string, loc *time.Location) time.Time {
+	t, err := timeutil.Parse(value)
+	if err != nil {
+		return time.Time{}
+	}
+	return t.In(loc)
+}
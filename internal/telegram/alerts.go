@@ -0,0 +1,138 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strings"
+
+	"github.com/gnomegl/teleslurp/internal/database"
+	"github.com/gnomegl/teleslurp/internal/indicators"
+	"github.com/gnomegl/teleslurp/internal/timeutil"
+	"github.com/gotd/td/tg"
+)
+
+// Chat types recorded with user sightings
+const (
+	chatTypeChannel    = "channel"
+	chatTypeSupergroup = "supergroup"
+	chatTypeGroup      = "group"
+	chatTypePrivate    = "private"
+)
+
+// messageSender returns the user who sent a message, or 0 if it wasn't sent
+// by a user. Incoming private messages carry the sender only in the peer.
+func messageSender(msg *tg.Message) int64 {
+	if peerUser, ok := msg.FromID.(*tg.PeerUser); ok {
+		return peerUser.UserID
+	}
+	if peerUser, ok := msg.PeerID.(*tg.PeerUser); ok && msg.FromID == nil && !msg.Out {
+		return peerUser.UserID
+	}
+	return 0
+}
+
+// sightingChat fills in the chat of a sighting from the update entities
+func sightingChat(s *database.UserSighting, e tg.Entities, msg *tg.Message) {
+	switch peer := msg.PeerID.(type) {
+	case *tg.PeerChannel:
+		s.ChatID = peer.ChannelID
+		s.ChatType = chatTypeSupergroup
+		if ch, ok := e.Channels[peer.ChannelID]; ok {
+			s.ChatTitle = ch.Title
+			s.ChatUsername = ch.Username
+			if ch.Broadcast {
+				s.ChatType = chatTypeChannel
+			}
+		}
+		s.URL = formatMessageURL(s.ChatID, msg.ID, s.ChatUsername)
+	case *tg.PeerChat:
+		s.ChatID = peer.ChatID
+		s.ChatType = chatTypeGroup
+		if chat, ok := e.Chats[peer.ChatID]; ok {
+			s.ChatTitle = chat.Title
+		}
+	case *tg.PeerUser:
+		s.ChatID = peer.UserID
+		s.ChatType = chatTypePrivate
+		s.ChatTitle = "Private chat"
+	}
+}
+
+// alertWatchedUser forwards a message posted by a watched user to the target
+// channel under an alert header and records the sighting. Messages in
+// channels are also stored like monitored messages so they can be queried.
+func (c *Client) alertWatchedUser(ctx context.Context, e tg.Entities, msg *tg.Message, senderID int64, targetChannelID int64, db database.Store) {
+	sighting := database.UserSighting{
+		UserID:      senderID,
+		MessageID:   msg.ID,
+		MessageDate: timeutil.FromUnix(int64(msg.Date)),
+		Message:     msg.Message,
+	}
+	if user, ok := e.Users[senderID]; ok {
+		sighting.Username = user.Username
+		sighting.FirstName = user.FirstName
+		sighting.LastName = user.LastName
+	}
+	sightingChat(&sighting, e, msg)
+
+	fmt.Printf("Watched user %d posted in %s %d\n", senderID, sighting.ChatType, sighting.ChatID)
+
+	if db != nil {
+		if err := db.SaveUserSighting(sighting); err != nil {
+			fmt.Printf("Warning: Failed to save user sighting to database: %v\n", err)
+		}
+		if _, ok := msg.PeerID.(*tg.PeerChannel); ok {
+			if err := db.SaveMessage(sighting.ChatID, sighting.ChatTitle, sighting.ChatUsername, msg.ID, senderID, sighting.MessageDate, msg.Message, sighting.URL); err != nil {
+				fmt.Printf("Warning: Failed to save message to database: %v\n", err)
+			}
+			if err := db.SaveIndicators(sighting.ChatID, msg.ID, indicators.Extract(msg.Message)); err != nil {
+				fmt.Printf("Warning: Failed to save message indicators to database: %v\n", err)
+			}
+		}
+	}
+
+	_, err := c.api.MessagesSendMessage(ctx, &tg.MessagesSendMessageRequest{
+		Peer: &tg.InputPeerChannel{
+			ChannelID:  targetChannelID,
+			AccessHash: 0,
+		},
+		Message:  formatSightingAlert(sighting, msg.Media != nil),
+		RandomID: rand.Int63(),
+	})
+	if err != nil {
+		fmt.Printf("Error sending watched user alert: %v\n", err)
+		return
+	}
+	fmt.Println("Successfully sent watched user alert")
+}
+
+func formatSightingAlert(s database.UserSighting, hasMedia bool) string {
+	user := strings.TrimSpace(s.FirstName + " " + s.LastName)
+	if s.Username != "" {
+		user = strings.TrimSpace(fmt.Sprintf("%s (@%s)", user, s.Username))
+	}
+	if user == "" {
+		user = fmt.Sprintf("ID: %d", s.UserID)
+	}
+
+	chat := s.ChatTitle
+	if s.ChatUsername != "" {
+		chat += " (@" + s.ChatUsername + ")"
+	}
+
+	var b strings.Builder
+	b.WriteString("🚨 Watched User Activity\n")
+	fmt.Fprintf(&b, "User: %s\n", user)
+	fmt.Fprintf(&b, "In: %s [%s]\n", chat, s.ChatType)
+	fmt.Fprintf(&b, "Date: %s\n", timeutil.Display(s.MessageDate))
+	if s.URL != "" {
+		fmt.Fprintf(&b, "Link: %s\n", s.URL)
+	}
+	b.WriteString("\n")
+	b.WriteString(s.Message)
+	if hasMedia {
+		b.WriteString("\n[Message contains media]")
+	}
+	return b.String()
+}
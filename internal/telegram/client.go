@@ -3,13 +3,17 @@ package telegram
 import (
 	"context"
 	"fmt"
+	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/gnomegl/teleslurp/internal/config"
 	"github.com/gnomegl/teleslurp/internal/database"
+	"github.com/gnomegl/teleslurp/internal/evidence"
 	"github.com/gnomegl/teleslurp/internal/export"
 	"github.com/gnomegl/teleslurp/internal/filter"
+	"github.com/gnomegl/teleslurp/internal/indicators"
+	"github.com/gnomegl/teleslurp/internal/timeutil"
 	"github.com/gnomegl/teleslurp/internal/types"
 	"github.com/gotd/td/session"
 	"github.com/gotd/td/telegram"
@@ -19,113 +23,23 @@ import (
 	"math/rand"
 )
 
-type MessageData struct {
-	ChannelTitle    string `json:"channel_title"`
-	ChannelUsername string `json:"channel_username"`
-	MessageID       int    `json:"message_id"`
-	Date            string `json:"date"`
-	Message         string `json:"message"`
-	URL             string `json:"url"`
-}
-
-type ChannelMetadata struct {
-	ChannelTitle     string `json:"channel_title"`
-	ChannelUsername  string `json:"channel_username"`
-	ChannelLink      string `json:"channel_link"`
-	ChannelAdmins    string `json:"channel_admins"`
-	MemberCount      int    `json:"member_count"`
-	UserFirstMessage string `json:"user_first_message"`
-}
-
-type OutputFormat string
-
-const (
-	FormatJSON OutputFormat = "json"
-	FormatCSV  OutputFormat = "csv"
-)
-
-func exportMessagesToJSON(messages []MessageData, username string) error {
-	filename := export.FormatFilename(username, "messages", "json")
-	return export.WriteJSON(messages, filename)
-}
-
-func exportMessagesToCSV(messages []MessageData, username string) error {
-	filename := export.FormatFilename(username, "messages", "csv")
-	writer, err := export.NewCSVWriter(filename)
-	if err != nil {
-		return err
-	}
-	defer writer.Close()
-
-	headers := []string{
-		"Channel Title",
-		"Channel Username",
-		"Message ID",
-		"Date",
-		"Message",
-		"URL",
-	}
-	if err := writer.WriteHeader(headers); err != nil {
-		return err
-	}
-
-	for _, msg := range messages {
-		record := []string{
-			msg.ChannelTitle,
-			msg.ChannelUsername,
-			fmt.Sprintf("%d", msg.MessageID),
-			msg.Date,
-			msg.Message,
-			msg.URL,
-		}
-		if err := writer.WriteRecord(record); err != nil {
-			return err
-		}
-	}
-
-	return nil
-}
-
-func exportChannelMetadataToJSON(metadata []ChannelMetadata, username string) error {
-	filename := export.FormatFilename(username, "channel_metadata", "json")
-	return export.WriteJSON(metadata, filename)
-}
-
-func exportChannelMetadataToCSV(metadata []ChannelMetadata, username string) error {
-	filename := export.FormatFilename(username, "channel_metadata", "csv")
-	writer, err := export.NewCSVWriter(filename)
-	if err != nil {
-		return err
-	}
-	defer writer.Close()
-
-	headers := []string{
-		"Channel Title",
-		"Channel Username",
-		"Channel Link",
-		"Channel Admins",
-		"Member Count",
-		"User Join Date",
-	}
-	if err := writer.WriteHeader(headers); err != nil {
-		return err
-	}
-
-	for _, ch := range metadata {
-		record := []string{
-			ch.ChannelTitle,
-			ch.ChannelUsername,
-			ch.ChannelLink,
-			ch.ChannelAdmins,
-			fmt.Sprintf("%d", ch.MemberCount),
-			ch.UserFirstMessage,
-		}
-		if err := writer.WriteRecord(record); err != nil {
-			return err
-		}
-	}
-
-	return nil
+// SearchOptions controls how search results are exported and recorded
+type SearchOptions struct {
+	// Format names the exporter, see export.Names
+	Format         string
+	ExportMetadata bool
+	// OutputDir is where exported files are written, the working directory
+	// when empty
+	OutputDir string
+	// Evidence writes the raw payload of every exported message to the
+	// evidence log in OutputDir
+	Evidence bool
+	// Profile is the TGScan lookup of the target, included in reports
+	Profile *types.TGScanResponse
+
+	// DB and RunID record results against a search run when both are set
+	DB    *database.DB
+	RunID int64
 }
 
 type Client struct {
@@ -248,21 +162,30 @@ func (c *Client) ResolveChannelUsername(ctx context.Context, username string) (i
 
 // ResolveUserUsername resolves a user's username to their ID and access hash
 func (c *Client) ResolveUserUsername(ctx context.Context, username string) (int64, int64, string, string, error) {
+	tgUser, err := c.LookupUser(ctx, username)
+	if err != nil {
+		return 0, 0, "", "", err
+	}
+	fullName := strings.TrimSpace(fmt.Sprintf("%s %s", tgUser.FirstName, tgUser.LastName))
+	return tgUser.ID, tgUser.AccessHash, tgUser.Username, fullName, nil
+}
+
+// LookupUser resolves a username to the Telegram user it belongs to
+func (c *Client) LookupUser(ctx context.Context, username string) (*tg.User, error) {
 	cleanUsername := strings.TrimPrefix(username, "@")
 
 	resolvedUser, err := c.api.ContactsResolveUsername(ctx, cleanUsername)
 	if err != nil {
-		return 0, 0, "", "", fmt.Errorf("error resolving username %s: %w", cleanUsername, err)
+		return nil, fmt.Errorf("error resolving username %s: %w", cleanUsername, err)
 	}
 
 	for _, u := range resolvedUser.Users {
-		if tgUser, ok := u.(*tg.User); ok && tgUser.Username == cleanUsername {
-			fullName := strings.TrimSpace(fmt.Sprintf("%s %s", tgUser.FirstName, tgUser.LastName))
-			return tgUser.ID, tgUser.AccessHash, tgUser.Username, fullName, nil
+		if tgUser, ok := u.(*tg.User); ok && strings.EqualFold(tgUser.Username, cleanUsername) {
+			return tgUser, nil
 		}
 	}
 
-	return 0, 0, "", "", fmt.Errorf("could not find user with username: %s", cleanUsername)
+	return nil, fmt.Errorf("could not find user with username: %s", cleanUsername)
 }
 
 func (c *Client) tryResolveUsernameFromGroups(ctx context.Context, userID int64, groups []types.Group) (string, int64) {
@@ -359,7 +282,7 @@ func (c *Client) searchChannel(ctx context.Context, channel types.Group, userID,
 	result := &ChannelSearchResult{
 		ChannelID:  channelID,
 		AccessHash: channelAccessHash,
-		Messages:   []MessageData{},
+		Messages:   []types.MessageData{},
 	}
 
 	chats, err := c.getChannelInfo(ctx, channelID, channelAccessHash)
@@ -418,11 +341,12 @@ func (c *Client) searchChannel(ctx context.Context, channel types.Group, userID,
 		}
 	}
 
-	messages, firstMessageDate, err := c.searchMessages(ctx, channelID, channelAccessHash, userID, userAccessHash)
+	messages, raw, firstMessageDate, err := c.searchMessages(ctx, channelID, channelAccessHash, userID, userAccessHash)
 	if err != nil {
 		return nil, err
 	}
 	result.Messages = messages
+	result.Raw = raw
 	result.FirstMessageDate = firstMessageDate
 
 	return result, nil
@@ -478,8 +402,9 @@ func (c *Client) getChannelAdmins(ctx context.Context, channelID, accessHash int
 	return adminList, nil
 }
 
-func (c *Client) searchMessages(ctx context.Context, channelID, channelAccessHash, userID, userAccessHash int64) ([]MessageData, time.Time, error) {
-	var messages []MessageData
+func (c *Client) searchMessages(ctx context.Context, channelID, channelAccessHash, userID, userAccessHash int64) ([]types.MessageData, []evidence.Retrieved, time.Time, error) {
+	var messages []types.MessageData
+	var raw []evidence.Retrieved
 	var firstMessageDate time.Time
 	offset := 0
 
@@ -506,12 +431,13 @@ func (c *Client) searchMessages(ctx context.Context, channelID, channelAccessHas
 
 		result, err := c.api.MessagesSearch(ctx, req)
 		if err != nil {
-			return nil, firstMessageDate, fmt.Errorf("error searching messages: %w", err)
+			return nil, nil, firstMessageDate, fmt.Errorf("error searching messages: %w", err)
 		}
+		retrievedAt := time.Now()
 
 		msgs, ok := result.(*tg.MessagesChannelMessages)
 		if !ok {
-			return nil, firstMessageDate, fmt.Errorf("unexpected response type")
+			return nil, nil, firstMessageDate, fmt.Errorf("unexpected response type")
 		}
 
 		if len(msgs.Messages) == 0 {
@@ -532,12 +458,15 @@ func (c *Client) searchMessages(ctx context.Context, channelID, channelAccessHas
 					}
 				}
 				messageURL := formatMessageURL(channelID, m.ID, channelUsername)
-				messages = append(messages, MessageData{
-					MessageID: m.ID,
-					Date:      messageDate.Format("2006-01-02 15:04:05"),
-					Message:   m.Message,
-					URL:       messageURL,
+				messages = append(messages, types.MessageData{
+					MessageID:  m.ID,
+					Date:       timeutil.Format(messageDate),
+					DateUnix:   messageDate.Unix(),
+					Message:    m.Message,
+					URL:        messageURL,
+					Indicators: indicators.Extract(m.Message),
 				})
+				raw = append(raw, evidence.Retrieved{Message: m, URL: messageURL, RetrievedAt: retrievedAt})
 			}
 		}
 
@@ -549,7 +478,7 @@ func (c *Client) searchMessages(ctx context.Context, channelID, channelAccessHas
 		}
 	}
 
-	return messages, firstMessageDate, nil
+	return messages, raw, firstMessageDate, nil
 }
 
 func formatMessageURL(channelID int64, messageID int, username string) string {
@@ -566,11 +495,13 @@ type ChannelSearchResult struct {
 	Username         string
 	MemberCount      int
 	Admins           []string
-	Messages         []MessageData
+	Messages         []types.MessageData
 	FirstMessageDate time.Time
+	// Raw holds the messages as received, for the evidence log
+	Raw []evidence.Retrieved
 }
 
-func (c *Client) Run(ctx context.Context, searchUser *types.User, groups []types.Group, format OutputFormat, exportMetadata bool) error {
+func (c *Client) Run(ctx context.Context, searchUser *types.User, groups []types.Group, opts SearchOptions) error {
 	if err := c.client.Run(ctx, func(ctx context.Context) error {
 		if err := c.authenticate(ctx); err != nil {
 			return err
@@ -611,10 +542,44 @@ func (c *Client) Run(ctx context.Context, searchUser *types.User, groups []types
 			}
 		}
 
+		if opts.DB != nil && opts.RunID != 0 {
+			if err := opts.DB.UpdateSearchRunTarget(opts.RunID, userID, searchUser.Username, searchUser.FirstName, searchUser.LastName); err != nil {
+				fmt.Printf("Warning: Failed to record search target: %v\n", err)
+			}
+		}
+
+		exporter, err := export.Get(opts.Format)
+		if err != nil {
+			return err
+		}
+		stream := &exportStream{
+			exporter: exporter,
+			dir:      opts.OutputDir,
+			search: export.Search{
+				Target:          *searchUser,
+				Profile:         opts.Profile,
+				IncludeMetadata: opts.ExportMetadata,
+			},
+		}
+		defer stream.Close()
+
+		var evidenceLog *evidence.Writer
+		if opts.Evidence {
+			self, err := c.client.Self(ctx)
+			if err != nil {
+				return fmt.Errorf("error getting account for evidence log: %w", err)
+			}
+			evidenceLog, err = evidence.NewWriter(opts.OutputDir, evidence.NewAccount(self))
+			if err != nil {
+				return err
+			}
+			defer evidenceLog.Close()
+		}
+
 		fmt.Printf("\nSearching %d groups for user ID %d...\n", len(groups), userID)
 
-		var allMessages []MessageData
-		var allMetadata []ChannelMetadata
+		var allMetadata []types.ChannelMetadata
+		var messageCounts []int
 
 		bar := progressbar.NewOptions(len(groups),
 			progressbar.OptionSetDescription("Progress"),
@@ -654,6 +619,8 @@ func (c *Client) Run(ctx context.Context, searchUser *types.User, groups []types
 				continue
 			}
 
+			c.recordSearchResult(opts, result, userID, searchUser.Username)
+
 			if len(result.Messages) > 0 {
 
 				for i := range result.Messages {
@@ -661,31 +628,48 @@ func (c *Client) Run(ctx context.Context, searchUser *types.User, groups []types
 					result.Messages[i].ChannelUsername = result.Username
 				}
 
-				allMessages = append(allMessages, result.Messages...)
-				allMetadata = append(allMetadata, ChannelMetadata{
+				meta := types.ChannelMetadata{
+					ChannelID:        result.ChannelID,
 					ChannelTitle:     result.Title,
 					ChannelUsername:  result.Username,
 					ChannelLink:      formatMessageURL(result.ChannelID, 0, result.Username),
 					ChannelAdmins:    strings.Join(result.Admins, ", "),
 					MemberCount:      result.MemberCount,
-					UserFirstMessage: result.FirstMessageDate.Format("2006-01-02 15:04:05"),
-				})
+					UserFirstMessage: timeutil.Format(result.FirstMessageDate),
+				}
+				allMetadata = append(allMetadata, meta)
+				messageCounts = append(messageCounts, len(result.Messages))
+
+				if err := stream.WriteChannel(meta, result.Messages); err != nil {
+					return err
+				}
+				if evidenceLog != nil {
+					if err := evidenceLog.WriteChannel(result.ChannelID, result.Username, result.Raw); err != nil {
+						return fmt.Errorf("error writing evidence log: %w", err)
+					}
+				}
 			}
 
 			bar.Add(1)
 			time.Sleep(2 * time.Second)
 		}
 
-		if len(allMessages) == 0 {
+		if evidenceLog != nil {
+			if err := evidenceLog.Close(); err != nil {
+				return fmt.Errorf("error writing evidence log: %w", err)
+			}
+		}
+
+		if len(allMetadata) == 0 {
 			fmt.Println("No messages found")
 			return nil
 		}
 
-		if err := c.printSummary(allMetadata, allMessages, searchUser); err != nil {
+		if err := c.printSummary(allMetadata, messageCounts, searchUser); err != nil {
 			return err
 		}
 
-		return c.exportResults(allMessages, allMetadata, searchUser.Username, format, exportMetadata)
+		return stream.Close()
 	}); err != nil {
 		return fmt.Errorf("error running client: %w", err)
 	}
@@ -693,7 +677,56 @@ func (c *Client) Run(ctx context.Context, searchUser *types.User, groups []types
 	return nil
 }
 
-func (c *Client) printSummary(metadata []ChannelMetadata, messages []MessageData, searchUser *types.User) error {
+// recordSearchResult stores a searched channel and the messages found in it
+// against the current search run. Failures are reported but don't abort the search.
+func (c *Client) recordSearchResult(opts SearchOptions, result *ChannelSearchResult, userID int64, username string) {
+	if opts.DB == nil || opts.RunID == 0 {
+		return
+	}
+
+	firstMessage := timeutil.Format(result.FirstMessageDate)
+
+	isAdmin := false
+	for _, admin := range result.Admins {
+		if username != "" && admin == username {
+			isAdmin = true
+			break
+		}
+	}
+
+	if err := opts.DB.SaveSearchRunChannel(opts.RunID, database.SearchRunChannel{
+		ChannelID:        result.ChannelID,
+		Title:            result.Title,
+		Username:         result.Username,
+		Link:             formatMessageURL(result.ChannelID, 0, result.Username),
+		Admins:           strings.Join(result.Admins, ", "),
+		IsAdmin:          isAdmin,
+		MemberCount:      result.MemberCount,
+		MessageCount:     len(result.Messages),
+		FirstMessageDate: firstMessage,
+	}); err != nil {
+		fmt.Printf("Warning: Failed to save channel to database: %v\n", err)
+	}
+
+	if err := opts.DB.SaveChannelMetadata(result.ChannelID, result.Title, result.Username, result.MemberCount, result.Username != ""); err != nil {
+		fmt.Printf("Warning: Failed to save channel metadata to database: %v\n", err)
+	}
+
+	for _, msg := range result.Messages {
+		if err := opts.DB.SaveMessage(result.ChannelID, result.Title, result.Username, msg.MessageID, userID, msg.Date, msg.Message, msg.URL); err != nil {
+			fmt.Printf("Warning: Failed to save message to database: %v\n", err)
+			continue
+		}
+		if err := opts.DB.SaveIndicators(result.ChannelID, msg.MessageID, msg.Indicators); err != nil {
+			fmt.Printf("Warning: Failed to save message indicators to database: %v\n", err)
+		}
+		if err := opts.DB.SaveSearchRunMessage(opts.RunID, result.ChannelID, msg.MessageID); err != nil {
+			fmt.Printf("Warning: Failed to link message to search run: %v\n", err)
+		}
+	}
+}
+
+func (c *Client) printSummary(metadata []types.ChannelMetadata, messageCounts []int, searchUser *types.User) error {
 	if len(metadata) == 0 {
 		fmt.Printf("\n❌ No messages found in any channels.\n")
 		return nil
@@ -706,13 +739,8 @@ func (c *Client) printSummary(metadata []ChannelMetadata, messages []MessageData
 	var totalMessages int
 	var totalMembers int
 
-	for _, meta := range metadata {
-		messageCount := 0
-		for _, msg := range messages {
-			if msg.ChannelUsername == meta.ChannelUsername || (meta.ChannelUsername == "" && msg.ChannelTitle == meta.ChannelTitle) {
-				messageCount++
-			}
-		}
+	for i, meta := range metadata {
+		messageCount := messageCounts[i]
 		totalMessages += messageCount
 		totalMembers += meta.MemberCount
 
@@ -740,8 +768,8 @@ func (c *Client) printSummary(metadata []ChannelMetadata, messages []MessageData
 		}
 
 		fmt.Printf("  Messages: %d | Members: %d\n", messageCount, meta.MemberCount)
-		if meta.UserFirstMessage != "" && meta.UserFirstMessage != "0001-01-01 00:00:00" {
-			fmt.Printf("  First seen: %s\n", meta.UserFirstMessage)
+		if meta.UserFirstMessage != "" {
+			fmt.Printf("  First seen: %s\n", timeutil.Display(meta.UserFirstMessage))
 		}
 	}
 
@@ -754,41 +782,53 @@ func (c *Client) printSummary(metadata []ChannelMetadata, messages []MessageData
 	return nil
 }
 
-func (c *Client) exportResults(messages []MessageData, metadata []ChannelMetadata, username string, format OutputFormat, exportMetadata bool) error {
-	fmt.Printf("\nExporting data...\n")
+// exportStream feeds search results to the exporter as each channel
+// completes. The writer is opened with the first channel that has messages,
+// so a search without results writes no files.
+type exportStream struct {
+	exporter export.Exporter
+	dir      string
+	search   export.Search
+	writer   export.Writer
+}
 
-	switch format {
-	case FormatJSON:
-		filename := export.FormatFilename(username, "messages", "json")
-		if err := exportMessagesToJSON(messages, username); err != nil {
-			return fmt.Errorf("failed to export messages: %v", err)
+func (s *exportStream) WriteChannel(meta types.ChannelMetadata, messages []types.MessageData) error {
+	if s.writer == nil {
+		basename := filepath.Join(s.dir, export.TargetBasename(s.search.Target))
+		writer, err := s.exporter.NewWriter(&s.search, basename)
+		if err != nil {
+			return fmt.Errorf("failed to export results: %w", err)
 		}
-		fmt.Printf("✓ Messages exported to: %s\n", filename)
+		s.writer = writer
+	}
+	if err := s.writer.WriteChannel(meta, messages); err != nil {
+		return fmt.Errorf("failed to export results: %w", err)
+	}
+	return nil
+}
 
-		if exportMetadata {
-			metaFilename := export.FormatFilename(username, "channel_metadata", "json")
-			if err := exportChannelMetadataToJSON(metadata, username); err != nil {
-				return fmt.Errorf("failed to export metadata: %v", err)
-			}
-			fmt.Printf("✓ Metadata exported to: %s\n", metaFilename)
-		}
-	case FormatCSV:
-		if err := exportMessagesToCSV(messages, username); err != nil {
-			return fmt.Errorf("failed to export messages: %v", err)
-		}
-		if exportMetadata {
-			if err := exportChannelMetadataToCSV(metadata, username); err != nil {
-				return fmt.Errorf("failed to export metadata: %v", err)
-			}
-		}
-	default:
-		return fmt.Errorf("unsupported output format: %s", format)
+// Close finishes the export. It is safe to call more than once, so it can be
+// deferred to finish partial exports when the search fails.
+func (s *exportStream) Close() error {
+	if s.writer == nil {
+		return nil
 	}
+	writer := s.writer
+	s.writer = nil
+
+	fmt.Printf("\nExporting data...\n")
 
+	files, err := writer.Close()
+	for _, file := range files {
+		fmt.Printf("✓ Exported to: %s\n", file)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to export results: %w", err)
+	}
 	return nil
 }
 
-func (c *Client) GetChannelMessages(ctx context.Context, channelID int64) ([]MessageData, error) {
+func (c *Client) GetChannelMessages(ctx context.Context, channelID int64) ([]types.MessageData, error) {
 	if err := c.authenticate(ctx); err != nil {
 		return nil, fmt.Errorf("error authenticating: %w", err)
 	}
@@ -801,7 +841,7 @@ func (c *Client) GetChannelMessages(ctx context.Context, channelID int64) ([]Mes
 		return nil, fmt.Errorf("error getting channel: %w", err)
 	}
 
-	messages := make([]MessageData, 0)
+	messages := make([]types.MessageData, 0)
 	channelInfo := channel.Chats[0]
 	var channelTitle string
 	if ch, ok := channelInfo.(*tg.Channel); ok {
@@ -826,20 +866,22 @@ func (c *Client) GetChannelMessages(ctx context.Context, channelID int64) ([]Mes
 			continue
 		}
 
-		messages = append(messages, MessageData{
+		messages = append(messages, types.MessageData{
 			ChannelTitle: channelTitle,
 			MessageID:    message.ID,
-			Date:         time.Unix(int64(message.Date), 0).Format(time.RFC3339),
+			Date:         timeutil.FromUnix(int64(message.Date)),
+			DateUnix:     int64(message.Date),
 			Message:      message.Message,
 			URL:          formatMessageURL(channelID, message.ID, msgs.Chats[0].(*tg.Channel).Username),
+			Indicators:   indicators.Extract(message.Message),
 		})
 	}
 
 	return messages, nil
 }
 
-func (c *Client) GetChannelsMessages(ctx context.Context, channelIDs []int64) ([]MessageData, error) {
-	var allMessages []MessageData
+func (c *Client) GetChannelsMessages(ctx context.Context, channelIDs []int64) ([]types.MessageData, error) {
+	var allMessages []types.MessageData
 	for _, channelID := range channelIDs {
 		messages, err := c.GetChannelMessages(ctx, channelID)
 		if err != nil {
@@ -851,7 +893,7 @@ func (c *Client) GetChannelsMessages(ctx context.Context, channelIDs []int64) ([
 	return allMessages, nil
 }
 
-func (c *Client) MonitorChannels(ctx context.Context, channelIDs []int64, handler func(MessageData) error) error {
+func (c *Client) MonitorChannels(ctx context.Context, channelIDs []int64, handler func(types.MessageData) error) error {
 	if err := c.authenticate(ctx); err != nil {
 		return fmt.Errorf("error authenticating: %w", err)
 	}
@@ -1080,23 +1122,7 @@ func (c *Client) MonitorChannels(ctx context.Context, channelIDs []int64, handle
 
 	// Get initial channel states
 	fmt.Println("Getting initial channel states...")
-	for channelID := range channels {
-		fmt.Printf("Getting initial state for channel %d\n", channelID)
-		_, err := c.api.UpdatesGetChannelDifference(ctx, &tg.UpdatesGetChannelDifferenceRequest{
-			Channel: &tg.InputChannel{
-				ChannelID:  channelID,
-				AccessHash: 0,
-			},
-			Filter: &tg.ChannelMessagesFilterEmpty{},
-			Pts:    0,
-			Limit:  100,
-		})
-		if err != nil {
-			fmt.Printf("Error getting channel difference for %d: %v\n", channelID, err)
-		} else {
-			fmt.Printf("Successfully got initial state for channel %d\n", channelID)
-		}
-	}
+	c.GetInitialChannelStates(ctx, channelIDs)
 
 	// Run the client to start receiving updates
 	return c.client.Run(ctx, func(ctx context.Context) error {
@@ -1118,25 +1144,13 @@ func (c *Client) RunWithContext(ctx context.Context, f func(context.Context) err
 	})
 }
 
-func (c *Client) MonitorAndForward(ctx context.Context, sourceChannelIDs []int64, targetChannelID int64, db *database.DB) error {
+func (c *Client) MonitorAndForward(ctx context.Context, sourceChannelIDs []int64, targetChannelID int64, db database.Store) error {
 	return c.MonitorAndForwardWithUsers(ctx, sourceChannelIDs, targetChannelID, nil, db)
 }
 
 // MonitorAndForwardWithUsers monitors channels and user status changes
-func (c *Client) MonitorAndForwardWithUsers(ctx context.Context, sourceChannelIDs []int64, targetChannelID int64, monitorUserIDs []int64, db *database.DB) error {
-	fmt.Printf("Starting MonitorAndForward with source channels: %v, target: %d, monitoring users: %v\n", sourceChannelIDs, targetChannelID, monitorUserIDs)
-
-	// Create a map of channel IDs for quick lookup
-	channels := make(map[int64]bool)
-	for _, id := range sourceChannelIDs {
-		channels[id] = true
-	}
-
-	// Create a map of user IDs to monitor
-	monitorUsers := make(map[int64]bool)
-	for _, id := range monitorUserIDs {
-		monitorUsers[id] = true
-	}
+func (c *Client) MonitorAndForwardWithUsers(ctx context.Context, sourceChannelIDs []int64, targetChannelID int64, monitorUserIDs []int64, db database.Store) error {
+	routes := NewMonitorRoutes(sourceChannelIDs, targetChannelID, monitorUserIDs)
 
 	// Initialize filter manager
 	var filterManager *filter.FilterManager
@@ -1149,6 +1163,14 @@ func (c *Client) MonitorAndForwardWithUsers(ctx context.Context, sourceChannelID
 		}
 	}
 
+	return c.MonitorWithRoutes(ctx, routes, filterManager, db)
+}
+
+// MonitorWithRoutes monitors channels and user status changes using routes
+// and filters that may be replaced while the monitor is running
+func (c *Client) MonitorWithRoutes(ctx context.Context, routes *MonitorRoutes, filterManager *filter.FilterManager, db database.Store) error {
+	fmt.Printf("Starting MonitorAndForward with source channels: %v, target: %d, monitoring users: %v\n", routes.Sources(), routes.Target(), routes.Users())
+
 	// Create a dispatcher and register handlers
 	dispatcher := tg.NewUpdateDispatcher()
 	fmt.Println("Created update dispatcher")
@@ -1164,6 +1186,13 @@ func (c *Client) MonitorAndForwardWithUsers(ctx context.Context, sourceChannelID
 		}
 		fmt.Printf("Message content: %s\n", msg.Message)
 
+		// Watched users are alerted on from every chat the account can see,
+		// not only the configured sources. Their messages in sources still go
+		// through the filters and are forwarded like any other.
+		if senderID := messageSender(msg); senderID != 0 && routes.IsWatchedUser(senderID) {
+			c.alertWatchedUser(ctx, e, msg, senderID, routes.Target(), db)
+		}
+
 		// Check if this is from a monitored channel
 		peer, ok := msg.PeerID.(*tg.PeerChannel)
 		if !ok {
@@ -1171,29 +1200,34 @@ func (c *Client) MonitorAndForwardWithUsers(ctx context.Context, sourceChannelID
 			return nil
 		}
 		channelID := peer.ChannelID
-		if !channels[channelID] {
+		if !routes.IsSource(channelID) {
 			fmt.Printf("Message from unmonitored channel: %d\n", channelID)
 			return nil
 		}
 		fmt.Printf("Message is from monitored channel: %d\n", channelID)
 
-		// Apply message filters if available
-		if filterManager != nil {
-			// Get the user ID from the message (if available)
-			var senderUserID int64
-			if msg.FromID != nil {
-				if peerUser, ok := msg.FromID.(*tg.PeerUser); ok {
-					senderUserID = peerUser.UserID
-				}
+		// Snapshot the target so a reload mid-update doesn't split the message
+		targetChannelID := routes.Target()
+
+		// Get the user ID from the message (if available)
+		var senderUserID int64
+		if msg.FromID != nil {
+			if peerUser, ok := msg.FromID.(*tg.PeerUser); ok {
+				senderUserID = peerUser.UserID
 			}
+		}
 
+		// Apply message filters if available
+		var matchedTerms []string
+		if filterManager != nil {
 			// Check if message should be processed based on filters
-			shouldProcess, action := filterManager.ProcessMessage(msg.Message, channelID, senderUserID)
-			if !shouldProcess {
-				fmt.Printf("Message filtered out (action: %s)\n", action)
+			decision := filterManager.Evaluate(msg.ID, msg.Message, channelID, senderUserID)
+			if !decision.Process {
+				fmt.Printf("Message filtered out (action: %s, filter: %s)\n", decision.Action, decision.FilterName)
 				return nil
 			}
-			if action == "highlight" {
+			matchedTerms = decision.Terms
+			if decision.Action == "highlight" {
 				// Prepend highlight emoji to message
 				msg.Message = "⚡ " + msg.Message
 				fmt.Println("Message marked as highlighted")
@@ -1233,6 +1267,11 @@ func (c *Client) MonitorAndForwardWithUsers(ctx context.Context, sourceChannelID
 			attribution = fmt.Sprintf("\n\nForwarded from: %s", channelTitle)
 		}
 
+		// Show which watchlist terms triggered the filter
+		if len(matchedTerms) > 0 {
+			attribution += fmt.Sprintf("\nMatched: %s", strings.Join(matchedTerms, ", "))
+		}
+
 		// Prepare message text with attribution
 		messageText := fmt.Sprintf("%s%s", msg.Message, attribution)
 		fmt.Printf("Prepared message text: %s\n", messageText)
@@ -1246,9 +1285,12 @@ func (c *Client) MonitorAndForwardWithUsers(ctx context.Context, sourceChannelID
 
 		// Save message to database
 		messageURL := formatMessageURL(channelID, msg.ID, channelInfo.(*tg.Channel).Username)
-		if err := db.SaveMessage(channelID, channelTitle, channelInfo.(*tg.Channel).Username, msg.ID, time.Unix(int64(msg.Date), 0).Format("2006-01-02 15:04:05"), msg.Message, messageURL); err != nil {
+		if err := db.SaveMessage(channelID, channelTitle, channelInfo.(*tg.Channel).Username, msg.ID, senderUserID, timeutil.FromUnix(int64(msg.Date)), msg.Message, messageURL); err != nil {
 			fmt.Printf("Warning: Failed to save message to database: %v\n", err)
 		}
+		if err := db.SaveIndicators(channelID, msg.ID, indicators.Extract(msg.Message)); err != nil {
+			fmt.Printf("Warning: Failed to save message indicators to database: %v\n", err)
+		}
 
 		// Handle media
 		if msg.Media != nil {
@@ -1385,147 +1427,105 @@ func (c *Client) MonitorAndForwardWithUsers(ctx context.Context, sourceChannelID
 		return nil
 	})
 
-	// Register handler for user status updates (if monitoring users)
-	if len(monitorUsers) > 0 {
-		dispatcher.OnUserStatus(func(ctx context.Context, e tg.Entities, update *tg.UpdateUserStatus) error {
-			// Only process if this user is being monitored
-			if !monitorUsers[update.UserID] {
-				return nil
-			}
-
-			fmt.Printf("User status update - UserID: %d\n", update.UserID)
-
-			// Get user info
-			users, err := c.api.UsersGetUsers(ctx, []tg.InputUserClass{
-				&tg.InputUser{
-					UserID:     update.UserID,
-					AccessHash: 0,
-				},
-			})
-			if err != nil {
-				fmt.Printf("Error getting user info: %v\n", err)
-				return nil
-			}
-
-			if len(users) > 0 {
-				if user, ok := users[0].(*tg.User); ok {
-					var statusText string
-					switch status := update.Status.(type) {
-					case *tg.UserStatusOnline:
-						statusText = fmt.Sprintf("online (expires: %v)", time.Unix(int64(status.Expires), 0))
-					case *tg.UserStatusOffline:
-						statusText = fmt.Sprintf("offline (was online: %v)", time.Unix(int64(status.WasOnline), 0))
-					case *tg.UserStatusRecently:
-						statusText = "recently active"
-					case *tg.UserStatusLastWeek:
-						statusText = "last seen within a week"
-					case *tg.UserStatusLastMonth:
-						statusText = "last seen within a month"
-					default:
-						statusText = fmt.Sprintf("unknown status: %T", status)
-					}
-
-					message := fmt.Sprintf("👤 User Status Update\nUser: %s %s (@%s)\nStatus: %s",
-						user.FirstName, user.LastName, user.Username, statusText)
-
-					// Send notification to target channel
-					_, err = c.api.MessagesSendMessage(ctx, &tg.MessagesSendMessageRequest{
-						Peer: &tg.InputPeerChannel{
-							ChannelID:  targetChannelID,
-							AccessHash: 0,
-						},
-						Message:  message,
-						RandomID: rand.Int63(),
-					})
-					if err != nil {
-						fmt.Printf("Error sending user status update: %v\n", err)
-					}
+	// Register handler for messages in basic groups and private chats, which
+	// are only of interest when a watched user posts them
+	dispatcher.OnNewMessage(func(ctx context.Context, e tg.Entities, update *tg.UpdateNewMessage) error {
+		msg, ok := update.Message.(*tg.Message)
+		if !ok {
+			return nil
+		}
 
-					// Save to database
-					if db != nil {
-						statusTime := time.Now().Format("2006-01-02 15:04:05")
-						if err := db.SaveUserStatusUpdate(update.UserID, user.Username, user.FirstName, user.LastName, statusText, statusTime); err != nil {
-							fmt.Printf("Warning: Failed to save user status to database: %v\n", err)
-						}
-					}
-				}
-			}
+		if senderID := messageSender(msg); senderID != 0 && routes.IsWatchedUser(senderID) {
+			c.alertWatchedUser(ctx, e, msg, senderID, routes.Target(), db)
+		}
+		return nil
+	})
 
+	// Register handler for user status updates. It is always registered since
+	// watched users may be added while the monitor is running
+	dispatcher.OnUserStatus(func(ctx context.Context, e tg.Entities, update *tg.UpdateUserStatus) error {
+		// Only process if this user is being monitored
+		if !routes.IsWatchedUser(update.UserID) {
 			return nil
-		})
-		fmt.Println("Registered user status handler")
-	}
+		}
 
-	fmt.Println("Registered message handlers")
-	if len(monitorUsers) > 0 {
-		dispatcher.OnUserStatus(func(ctx context.Context, e tg.Entities, update *tg.UpdateUserStatus) error {
-			// Only process if this user is being monitored
-			if !monitorUsers[update.UserID] {
-				return nil
-			}
+		fmt.Printf("User status update - UserID: %d\n", update.UserID)
 
-			fmt.Printf("User status update - UserID: %d\n", update.UserID)
+		// Get user info
+		users, err := c.api.UsersGetUsers(ctx, []tg.InputUserClass{
+			&tg.InputUser{
+				UserID:     update.UserID,
+				AccessHash: 0,
+			},
+		})
+		if err != nil {
+			fmt.Printf("Error getting user info: %v\n", err)
+			return nil
+		}
 
-			// Get user info
-			users, err := c.api.UsersGetUsers(ctx, []tg.InputUserClass{
-				&tg.InputUser{
+		if len(users) > 0 {
+			if user, ok := users[0].(*tg.User); ok {
+				record := database.UserStatusUpdate{
 					UserID:     update.UserID,
-					AccessHash: 0,
-				},
-			})
-			if err != nil {
-				fmt.Printf("Error getting user info: %v\n", err)
-				return nil
-			}
+					Username:   user.Username,
+					FirstName:  user.FirstName,
+					LastName:   user.LastName,
+					StatusTime: timeutil.Now(),
+				}
 
-			if len(users) > 0 {
-				if user, ok := users[0].(*tg.User); ok {
-					var statusText string
-					switch status := update.Status.(type) {
-					case *tg.UserStatusOnline:
-						statusText = fmt.Sprintf("online (expires: %v)", time.Unix(int64(status.Expires), 0))
-					case *tg.UserStatusOffline:
-						statusText = fmt.Sprintf("offline (was online: %v)", time.Unix(int64(status.WasOnline), 0))
-					case *tg.UserStatusRecently:
-						statusText = "recently active"
-					case *tg.UserStatusLastWeek:
-						statusText = "last seen within a week"
-					case *tg.UserStatusLastMonth:
-						statusText = "last seen within a month"
-					default:
-						statusText = fmt.Sprintf("unknown status: %T", status)
-					}
+				var statusText string
+				switch status := update.Status.(type) {
+				case *tg.UserStatusOnline:
+					record.State = database.StatusOnline
+					record.Expires = timeutil.FromUnix(int64(status.Expires))
+					statusText = fmt.Sprintf("online (expires: %s)", timeutil.Display(record.Expires))
+				case *tg.UserStatusOffline:
+					record.State = database.StatusOffline
+					record.WasOnline = timeutil.FromUnix(int64(status.WasOnline))
+					statusText = fmt.Sprintf("offline (was online: %s)", timeutil.Display(record.WasOnline))
+				case *tg.UserStatusRecently:
+					statusText = "recently active"
+					record.State = database.StatusRecently
+				case *tg.UserStatusLastWeek:
+					statusText = "last seen within a week"
+					record.State = database.StatusLastWeek
+				case *tg.UserStatusLastMonth:
+					statusText = "last seen within a month"
+					record.State = database.StatusLastMonth
+				default:
+					statusText = fmt.Sprintf("unknown status: %T", status)
+					record.State = database.StatusUnknown
+				}
+				record.Status = statusText
 
-					message := fmt.Sprintf("👤 User Status Update\nUser: %s %s (@%s)\nStatus: %s",
-						user.FirstName, user.LastName, user.Username, statusText)
+				message := fmt.Sprintf("👤 User Status Update\nUser: %s %s (@%s)\nStatus: %s",
+					user.FirstName, user.LastName, user.Username, statusText)
 
-					// Send notification to target channel
-					_, err = c.api.MessagesSendMessage(ctx, &tg.MessagesSendMessageRequest{
-						Peer: &tg.InputPeerChannel{
-							ChannelID:  targetChannelID,
-							AccessHash: 0,
-						},
-						Message:  message,
-						RandomID: rand.Int63(),
-					})
-					if err != nil {
-						fmt.Printf("Error sending user status update: %v\n", err)
-					}
+				// Send notification to target channel
+				_, err = c.api.MessagesSendMessage(ctx, &tg.MessagesSendMessageRequest{
+					Peer: &tg.InputPeerChannel{
+						ChannelID:  routes.Target(),
+						AccessHash: 0,
+					},
+					Message:  message,
+					RandomID: rand.Int63(),
+				})
+				if err != nil {
+					fmt.Printf("Error sending user status update: %v\n", err)
+				}
 
-					// Save to database
-					if db != nil {
-						statusTime := time.Now().Format("2006-01-02 15:04:05")
-						if err := db.SaveUserStatusUpdate(update.UserID, user.Username, user.FirstName, user.LastName, statusText, statusTime); err != nil {
-							fmt.Printf("Warning: Failed to save user status to database: %v\n", err)
-						}
+				// Save to database
+				if db != nil {
+					if err := db.SaveUserStatusUpdate(record); err != nil {
+						fmt.Printf("Warning: Failed to save user status to database: %v\n", err)
 					}
 				}
 			}
+		}
 
-			return nil
-		})
-		fmt.Println("Registered user status handler")
-	}
+		return nil
+	})
+	fmt.Println("Registered user status handler")
 
 	fmt.Println("Registered message handlers")
 
@@ -1539,7 +1539,18 @@ func (c *Client) MonitorAndForwardWithUsers(ctx context.Context, sourceChannelID
 
 	// Get initial channel states
 	fmt.Println("Getting initial channel states...")
-	for channelID := range channels {
+	c.GetInitialChannelStates(ctx, routes.Sources())
+
+	fmt.Println("Entering main loop...")
+	<-ctx.Done()
+	fmt.Println("Update loop terminated")
+	return nil
+}
+
+// GetInitialChannelStates fetches the update state of each channel, which
+// makes Telegram start pushing its new messages to the update loop
+func (c *Client) GetInitialChannelStates(ctx context.Context, channelIDs []int64) {
+	for _, channelID := range channelIDs {
 		fmt.Printf("Getting initial state for channel %d\n", channelID)
 		_, err := c.api.UpdatesGetChannelDifference(ctx, &tg.UpdatesGetChannelDifferenceRequest{
 			Channel: &tg.InputChannel{
@@ -1556,14 +1567,9 @@ func (c *Client) MonitorAndForwardWithUsers(ctx context.Context, sourceChannelID
 			fmt.Printf("Successfully got initial state for channel %d\n", channelID)
 		}
 	}
-
-	fmt.Println("Entering main loop...")
-	<-ctx.Done()
-	fmt.Println("Update loop terminated")
-	return nil
 }
 
-func RunClient(ctx context.Context, cfg *config.Config, searchUser *types.User, groups []types.Group, format OutputFormat, exportMetadata bool) error {
+func RunClient(ctx context.Context, cfg *config.Config, searchUser *types.User, groups []types.Group, opts SearchOptions) error {
 	client := NewClient(cfg)
-	return client.Run(ctx, searchUser, groups, format, exportMetadata)
+	return client.Run(ctx, searchUser, groups, opts)
 }
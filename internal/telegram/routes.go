@@ -0,0 +1,113 @@
+package telegram
+
+import (
+	"sync"
+)
+
+// MonitorRoutes holds the source chats, target channel and watched users of a
+// running monitor. Handlers read it on every update, so it can be swapped while
+// the monitor is running without restarting the update loop.
+//
+// Watched users come from two places, the monitor config and the
+// monitored_users table, and each is replaced independently.
+type MonitorRoutes struct {
+	mu          sync.RWMutex
+	sources     map[int64]bool
+	target      int64
+	users       map[int64]bool
+	storedUsers map[int64]bool
+}
+
+// NewMonitorRoutes creates routes for the given sources, target and users
+func NewMonitorRoutes(sourceIDs []int64, targetID int64, userIDs []int64) *MonitorRoutes {
+	r := &MonitorRoutes{}
+	r.Update(sourceIDs, targetID, userIDs)
+	return r
+}
+
+// Update atomically replaces the routes and returns the sources that were
+// not monitored before
+func (r *MonitorRoutes) Update(sourceIDs []int64, targetID int64, userIDs []int64) []int64 {
+	sources := idSet(sourceIDs)
+	users := idSet(userIDs)
+
+	r.mu.Lock()
+	var added []int64
+	for id := range sources {
+		if !r.sources[id] {
+			added = append(added, id)
+		}
+	}
+	r.sources = sources
+	r.target = targetID
+	r.users = users
+	r.mu.Unlock()
+	return added
+}
+
+// SetStoredUsers atomically replaces the watched users from the database
+func (r *MonitorRoutes) SetStoredUsers(userIDs []int64) {
+	users := idSet(userIDs)
+
+	r.mu.Lock()
+	r.storedUsers = users
+	r.mu.Unlock()
+}
+
+func idSet(ids []int64) map[int64]bool {
+	set := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set
+}
+
+// IsSource reports whether the channel is a monitored source
+func (r *MonitorRoutes) IsSource(channelID int64) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.sources[channelID]
+}
+
+// IsWatchedUser reports whether the user's status is being monitored
+func (r *MonitorRoutes) IsWatchedUser(userID int64) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.users[userID] || r.storedUsers[userID]
+}
+
+// Target returns the channel messages are forwarded to
+func (r *MonitorRoutes) Target() int64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.target
+}
+
+// Sources returns the IDs of all monitored sources
+func (r *MonitorRoutes) Sources() []int64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ids := make([]int64, 0, len(r.sources))
+	for id := range r.sources {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// Users returns the IDs of all watched users
+func (r *MonitorRoutes) Users() []int64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ids := make([]int64, 0, len(r.users)+len(r.storedUsers))
+	for id := range r.users {
+		ids = append(ids, id)
+	}
+	for id := range r.storedUsers {
+		if !r.users[id] {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,127 @@
+// Package timeutil keeps the times teleslurp stores and exports in one
+// format, UTC RFC 3339, and converts them to the --timezone for display
+package timeutil
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	// Embedded zone database, so --timezone works on systems without one
+	_ "time/tzdata"
+)
+
+// Layout is the format of every stored and exported time
+const Layout = time.RFC3339
+
+// DisplayLayout is the format of times shown in the terminal and reports
+const DisplayLayout = "2006-01-02 15:04:05 MST"
+
+// legacyLayout is how times were stored before they were normalized to UTC
+// RFC 3339, in the local time of the machine that stored them
+const legacyLayout = "2006-01-02 15:04:05"
+
+var location = time.Local
+
+// Format returns t in UTC as RFC 3339, or "" for the zero time
+func Format(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(Layout)
+}
+
+// FromUnix formats a Unix timestamp, as Telegram sends them
+func FromUnix(sec int64) string {
+	return Format(time.Unix(sec, 0))
+}
+
+// Now returns the current time formatted for storage
+func Now() string {
+	return Format(time.Now())
+}
+
+// Parse reads a time in RFC 3339, the legacy "2006-01-02 15:04:05" local
+// time format or as a date. TGScan dates are parsed the same way.
+func Parse(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{legacyLayout, "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q", value)
+}
+
+// Unix returns a stored time as a Unix timestamp, or 0 if it can't be parsed
+func Unix(value string) int64 {
+	t, err := Parse(value)
+	if err != nil || t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+// LoadLocation returns the zone named by --timezone: "local" or "" for the
+// machine's zone, "UTC" or an IANA name such as "Europe/Berlin"
+func LoadLocation(name string) (*time.Location, error) {
+	if name == "" || strings.EqualFold(name, "local") {
+		return time.Local, nil
+	}
+	if strings.EqualFold(name, "utc") {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q", name)
+	}
+	return loc, nil
+}
+
+// SetLocation sets the zone times are displayed in
+func SetLocation(loc *time.Location) {
+	location = loc
+}
+
+// Location returns the zone times are displayed in
+func Location() *time.Location {
+	return location
+}
+
+// In converts t to the display zone
+func In(t time.Time) time.Time {
+	return t.In(location)
+}
+
+// Display formats a stored time in the display zone. Dates without a time,
+// as TGScan reports them, and values that aren't times are returned
+// unchanged.
+func Display(value string) string {
+	if len(value) <= len("2006-01-02") {
+		return value
+	}
+	t, err := Parse(value)
+	if err != nil {
+		return value
+	}
+	return DisplayTime(t)
+}
+
+// DisplayTime formats t in the display zone
+func DisplayTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.In(location).Format(DisplayLayout)
+}
+
+// ParseDate reads a YYYY-MM-DD date as midnight in the display zone
+func ParseDate(value string) (time.Time, error) {
+	t, err := time.ParseInLocation("2006-01-02", value, location)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", value)
+	}
+	return t, nil
+}
@@ -1,5 +1,7 @@
 package types
 
+import "github.com/gnomegl/teleslurp/internal/indicators"
+
 type User struct {
 	ID        int64  `json:"id"`
 	Username  string `json:"username"`
@@ -41,3 +43,26 @@ type TGScanResponse struct {
 		Groups          []Group          `json:"groups"`
 	} `json:"result"`
 }
+
+// MessageData is a message found by a search
+type MessageData struct {
+	ChannelTitle    string                 `json:"channel_title"`
+	ChannelUsername string                 `json:"channel_username"`
+	MessageID       int                    `json:"message_id"`
+	Date            string                 `json:"date"` // UTC, RFC 3339
+	DateUnix        int64                  `json:"date_unix"`
+	Message         string                 `json:"message"`
+	URL             string                 `json:"url"`
+	Indicators      []indicators.Indicator `json:"indicators,omitempty"`
+}
+
+// ChannelMetadata describes a channel a search found messages in
+type ChannelMetadata struct {
+	ChannelID        int64  `json:"channel_id,omitempty"`
+	ChannelTitle     string `json:"channel_title"`
+	ChannelUsername  string `json:"channel_username"`
+	ChannelLink      string `json:"channel_link"`
+	ChannelAdmins    string `json:"channel_admins"`
+	MemberCount      int    `json:"member_count"`
+	UserFirstMessage string `json:"user_first_message"`
+}